@@ -16,6 +16,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/peerstore"
 	"github.com/libp2p/go-libp2p/core/pnet"
 	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/core/record"
 	"github.com/libp2p/go-libp2p/core/routing"
 	"github.com/libp2p/go-libp2p/core/sec"
 	"github.com/libp2p/go-libp2p/core/sec/insecure"
@@ -32,6 +33,7 @@ import (
 	tptu "github.com/libp2p/go-libp2p/p2p/net/upgrader"
 	circuitv2 "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/client"
 	relayv2 "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/relay"
+	"github.com/libp2p/go-libp2p/p2p/protocol/connectback"
 	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
 	"github.com/libp2p/go-libp2p/p2p/transport/quicreuse"
 	"github.com/prometheus/client_golang/prometheus"
@@ -89,6 +91,7 @@ type Config struct {
 	SecurityTransports []Security
 	Insecure           bool
 	PSK                pnet.PSK
+	PSKSet             pnet.PSKSet
 
 	DialTimeout time.Duration
 
@@ -122,21 +125,96 @@ type Config struct {
 	EnableHolePunching  bool
 	HolePunchingOptions []holepunch.Option
 
+	EnableConnectBack  bool
+	ConnectBackOptions []connectback.Option
+
 	DisableMetrics       bool
 	PrometheusRegisterer prometheus.Registerer
 
 	DialRanker network.DialRanker
 
+	// KeySuccessionRecords are the key succession records announcing this node as the successor
+	// of other peers.
+	KeySuccessionRecords []*record.Envelope
+	// HonorKeySuccession makes the connection manager and the connection gater transfer their
+	// state from peers to their successors.
+	HonorKeySuccession bool
+
 	SwarmOpts []swarm.Option
 }
 
+// addKeySuccessionRecords adds our own key succession records to the peerstore.
+// Identify then sends them to other peers.
+func (cfg *Config) addKeySuccessionRecords(pid peer.ID) error {
+	if len(cfg.KeySuccessionRecords) == 0 {
+		return nil
+	}
+	ksb, ok := peerstore.GetKeySuccessionBook(cfg.Peerstore)
+	if !ok {
+		return errors.New("peerstore doesn't support key succession records")
+	}
+	for _, env := range cfg.KeySuccessionRecords {
+		if _, err := ksb.ConsumeKeySuccession(env); err != nil {
+			return fmt.Errorf("invalid key succession record: %w", err)
+		}
+	}
+	if len(ksb.KeySuccessionRecords(pid)) < len(cfg.KeySuccessionRecords) {
+		return fmt.Errorf("key succession records don't lead to %s", pid)
+	}
+	return nil
+}
+
+func (cfg *Config) keySuccessionHandlers() []connmgr.KeySuccessionHandler {
+	if !cfg.HonorKeySuccession {
+		return nil
+	}
+	var handlers []connmgr.KeySuccessionHandler
+	if h, ok := cfg.ConnManager.(connmgr.KeySuccessionHandler); ok {
+		handlers = append(handlers, h)
+	}
+	if h, ok := cfg.ConnectionGater.(connmgr.KeySuccessionHandler); ok {
+		handlers = append(handlers, h)
+	}
+	return handlers
+}
+
+// IsPrivateNetwork says if a PSK or a PSK set was configured.
+func (cfg *Config) IsPrivateNetwork() bool {
+	return len(cfg.PSK) > 0 || len(cfg.PSKSet) > 0
+}
+
+// pskSet returns the configured PSK set. A single PSK is converted to a set.
+func (cfg *Config) pskSet() pnet.PSKSet {
+	if cfg.PSKSet != nil {
+		return cfg.PSKSet
+	}
+	if cfg.PSK != nil {
+		return pnet.NewPSKSet(cfg.PSK)
+	}
+	return nil
+}
+
+// singlePSK returns the PSK for transports that don't support PSK sets.
+// If a PSK set was configured, this is the key currently used to protect connections.
+// It fails if none of the keys in the set is active.
+func (cfg *Config) singlePSK() (pnet.PSK, error) {
+	if cfg.PSK != nil || cfg.PSKSet == nil {
+		return cfg.PSK, nil
+	}
+	psk, err := cfg.PSKSet.Current(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("private network: %w", err)
+	}
+	return psk, nil
+}
+
 func (cfg *Config) makeSwarm(eventBus event.Bus, enableMetrics bool) (*swarm.Swarm, error) {
 	if cfg.Peerstore == nil {
 		return nil, fmt.Errorf("no peerstore specified")
 	}
 
 	// Check this early. Prevents us from even *starting* without verifying this.
-	if pnet.ForcePrivateNetwork && len(cfg.PSK) == 0 {
+	if pnet.ForcePrivateNetwork && !cfg.IsPrivateNetwork() {
 		log.Error("tried to create a libp2p node with no Private" +
 			" Network Protector but usage of Private Networks" +
 			" is forced by the environment")
@@ -144,6 +222,9 @@ func (cfg *Config) makeSwarm(eventBus event.Bus, enableMetrics bool) (*swarm.Swa
 		// enforced even *if* you don't use the libp2p constructor.
 		return nil, pnet.ErrNotInPrivateNetwork
 	}
+	if _, err := cfg.singlePSK(); err != nil {
+		return nil, err
+	}
 
 	if cfg.PeerKey == nil {
 		return nil, fmt.Errorf("no peer key specified")
@@ -161,6 +242,9 @@ func (cfg *Config) makeSwarm(eventBus event.Bus, enableMetrics bool) (*swarm.Swa
 	if err := cfg.Peerstore.AddPubKey(pid, cfg.PeerKey.GetPublic()); err != nil {
 		return nil, err
 	}
+	if err := cfg.addKeySuccessionRecords(pid); err != nil {
+		return nil, err
+	}
 
 	opts := cfg.SwarmOpts
 	if cfg.Reporter != nil {
@@ -199,13 +283,19 @@ func (cfg *Config) addTransports(h host.Host) error {
 
 	fxopts := []fx.Option{
 		fx.WithLogger(func() fxevent.Logger { return getFXLogger() }),
-		fx.Provide(fx.Annotate(tptu.New, fx.ParamTags(`name:"security"`))),
+		fx.Provide(fx.Annotate(
+			func(security []sec.SecureTransport, muxers []tptu.StreamMuxer, psk pnet.PSK, rcmgr network.ResourceManager, gater connmgr.ConnectionGater) (transport.Upgrader, error) {
+				return tptu.New(security, muxers, psk, rcmgr, gater, tptu.WithPSKSet(cfg.PSKSet))
+			},
+			fx.ParamTags(`name:"security"`),
+		)),
 		fx.Supply(cfg.Muxers),
 		fx.Supply(h.ID()),
 		fx.Provide(func() host.Host { return h }),
 		fx.Provide(func() crypto.PrivKey { return h.Peerstore().PrivKey(h.ID()) }),
 		fx.Provide(func() connmgr.ConnectionGater { return cfg.ConnectionGater }),
-		fx.Provide(func() pnet.PSK { return cfg.PSK }),
+		fx.Provide(func() (pnet.PSK, error) { return cfg.singlePSK() }),
+		fx.Provide(func() pnet.PSKSet { return cfg.pskSet() }),
 		fx.Provide(func() network.ResourceManager { return cfg.ResourceManager }),
 		fx.Provide(func() *madns.Resolver { return cfg.MultiaddrResolver }),
 	}
@@ -306,19 +396,22 @@ func (cfg *Config) NewNode() (host.Host, error) {
 	}
 
 	h, err := bhost.NewHost(swrm, &bhost.HostOpts{
-		EventBus:             eventBus,
-		ConnManager:          cfg.ConnManager,
-		AddrsFactory:         cfg.AddrsFactory,
-		NATManager:           cfg.NATManager,
-		EnablePing:           !cfg.DisablePing,
-		UserAgent:            cfg.UserAgent,
-		ProtocolVersion:      cfg.ProtocolVersion,
-		EnableHolePunching:   cfg.EnableHolePunching,
-		HolePunchingOptions:  cfg.HolePunchingOptions,
-		EnableRelayService:   cfg.EnableRelayService,
-		RelayServiceOpts:     cfg.RelayServiceOpts,
-		EnableMetrics:        !cfg.DisableMetrics,
-		PrometheusRegisterer: cfg.PrometheusRegisterer,
+		EventBus:              eventBus,
+		ConnManager:           cfg.ConnManager,
+		AddrsFactory:          cfg.AddrsFactory,
+		NATManager:            cfg.NATManager,
+		EnablePing:            !cfg.DisablePing,
+		UserAgent:             cfg.UserAgent,
+		ProtocolVersion:       cfg.ProtocolVersion,
+		EnableHolePunching:    cfg.EnableHolePunching,
+		HolePunchingOptions:   cfg.HolePunchingOptions,
+		EnableConnectBack:     cfg.EnableConnectBack,
+		ConnectBackOptions:    cfg.ConnectBackOptions,
+		EnableRelayService:    cfg.EnableRelayService,
+		RelayServiceOpts:      cfg.RelayServiceOpts,
+		EnableMetrics:         !cfg.DisableMetrics,
+		PrometheusRegisterer:  cfg.PrometheusRegisterer,
+		KeySuccessionHandlers: cfg.keySuccessionHandlers(),
 	})
 	if err != nil {
 		swrm.Close()
@@ -414,6 +507,7 @@ func (cfg *Config) NewNode() (host.Host, error) {
 			SecurityTransports: cfg.SecurityTransports,
 			Insecure:           cfg.Insecure,
 			PSK:                cfg.PSK,
+			PSKSet:             cfg.PSKSet,
 			ConnectionGater:    cfg.ConnectionGater,
 			Reporter:           cfg.Reporter,
 			PeerKey:            autonatPrivKey,
@@ -15,7 +15,7 @@ const statelessResetKeyInfo = "libp2p quic stateless reset key"
 
 func PrivKeyToStatelessResetKey(key crypto.PrivKey) (quic.StatelessResetKey, error) {
 	var statelessResetKey quic.StatelessResetKey
-	keyBytes, err := key.Raw()
+	keyBytes, err := crypto.KeyMaterial(key)
 	if err != nil {
 		return statelessResetKey, err
 	}
@@ -89,3 +89,13 @@ type TagInfo struct {
 	// Conns maps connection ids (such as remote multiaddr) to their creation time.
 	Conns map[string]time.Time
 }
+
+// KeySuccessionHandler can be implemented by connection managers and connection gaters that carry
+// over their state when a peer replaces its key (see peer.KeySuccessionRecord).
+//
+// Honoring key successions is opt-in: the host only calls PeerSucceeded if configured to do so.
+type KeySuccessionHandler interface {
+	// PeerSucceeded is called after a valid key succession record was received, announcing
+	// that the peer using the successor key replaced p.
+	PeerSucceeded(p, successor peer.ID)
+}
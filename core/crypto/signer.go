@@ -0,0 +1,93 @@
+package crypto
+
+import (
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/crypto/pb"
+)
+
+// ErrKeyNotExportable is returned when trying to access the raw bytes of a private key
+// that is held by a Signer.
+var ErrKeyNotExportable = errors.New("private key is not exportable")
+
+// ErrNoKeyMaterial is returned by KeyMaterial for keys held by a Signer that were created
+// without a seed.
+var ErrNoKeyMaterial = errors.New("no key material available for signer key, see NewSignerPrivKeyWithSeed")
+
+// Signer signs data with a private key that doesn't need to be available in memory, e.g.
+// because it is held by a hardware security module or by a separate signing process.
+type Signer interface {
+	// Sign signs data. The signature must be verifiable using the Verify method of the
+	// public key returned by GetPublic.
+	Sign(data []byte) ([]byte, error)
+	// GetPublic returns the public key.
+	GetPublic() PubKey
+}
+
+// signerKey is a PrivKey that is backed by a Signer.
+type signerKey struct {
+	signer Signer
+	seed   []byte
+}
+
+var _ PrivKey = &signerKey{}
+
+// NewSignerPrivKey returns a PrivKey that signs using s. It can be used everywhere a private
+// key is needed to sign, e.g. as the identity of a host.
+// Its private key can't be exported: Raw returns ErrKeyNotExportable.
+func NewSignerPrivKey(s Signer) PrivKey {
+	return &signerKey{signer: s}
+}
+
+// NewSignerPrivKeyWithSeed is like NewSignerPrivKey, but the returned key also carries a
+// secret seed that KeyMaterial returns in place of the raw private key.
+// This is needed for transports that derive keys and certificates from the host key, like
+// QUIC (stateless reset key) and WebTransport (certificates). The seed must be kept secret,
+// must be the same across restarts, and must be at least 32 bytes long.
+func NewSignerPrivKeyWithSeed(s Signer, seed []byte) (PrivKey, error) {
+	if len(seed) < 32 {
+		return nil, errors.New("seed must be at least 32 bytes long")
+	}
+	return &signerKey{signer: s, seed: append([]byte(nil), seed...)}, nil
+}
+
+func (k *signerKey) Sign(data []byte) ([]byte, error) {
+	return k.signer.Sign(data)
+}
+
+func (k *signerKey) GetPublic() PubKey {
+	return k.signer.GetPublic()
+}
+
+func (k *signerKey) Type() pb.KeyType {
+	return k.signer.GetPublic().Type()
+}
+
+func (k *signerKey) Raw() ([]byte, error) {
+	return nil, ErrKeyNotExportable
+}
+
+// Equals checks if the other key is a private key for the same public key.
+func (k *signerKey) Equals(o Key) bool {
+	other, ok := o.(PrivKey)
+	if !ok {
+		return false
+	}
+	return k.GetPublic().Equals(other.GetPublic())
+}
+
+// KeyMaterial returns secret input keying material for deriving symmetric keys from a
+// private key, for example using HKDF.
+//
+// For keys that can be exported, these are the raw bytes of the private key. For keys held by a
+// Signer, it is the seed passed to NewSignerPrivKeyWithSeed. If the key was created without a
+// seed, ErrNoKeyMaterial is returned.
+func KeyMaterial(k PrivKey) ([]byte, error) {
+	if sk, ok := k.(*signerKey); ok {
+		if sk.seed == nil {
+			return nil, ErrNoKeyMaterial
+		}
+		return sk.seed, nil
+	}
+	return k.Raw()
+}
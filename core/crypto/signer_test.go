@@ -0,0 +1,99 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+// testSigner is a Signer that wraps a private key, without exposing it.
+type testSigner struct {
+	key PrivKey
+}
+
+func (s *testSigner) Sign(data []byte) ([]byte, error) { return s.key.Sign(data) }
+func (s *testSigner) GetPublic() PubKey                { return s.key.GetPublic() }
+
+func TestSignerPrivKey(t *testing.T) {
+	priv, pub, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk := NewSignerPrivKey(&testSigner{key: priv})
+
+	data := []byte("hello world")
+	sig, err := sk.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := pub.Verify(data, sig); err != nil || !ok {
+		t.Fatal("invalid signature")
+	}
+	if !sk.GetPublic().Equals(pub) {
+		t.Fatal("public key mismatch")
+	}
+	if sk.Type() != priv.Type() {
+		t.Fatal("key type mismatch")
+	}
+	if !sk.Equals(priv) {
+		t.Fatal("expected keys to be equal")
+	}
+	other, _, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sk.Equals(other) {
+		t.Fatal("expected keys to differ")
+	}
+
+	if _, err := sk.Raw(); err != ErrKeyNotExportable {
+		t.Fatalf("expected ErrKeyNotExportable, got %v", err)
+	}
+	if _, err := MarshalPrivateKey(sk); err != ErrKeyNotExportable {
+		t.Fatalf("expected ErrKeyNotExportable, got %v", err)
+	}
+}
+
+func TestKeyMaterial(t *testing.T) {
+	priv, _, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := priv.Raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	km, err := KeyMaterial(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(km) != string(raw) {
+		t.Fatal("expected the raw key for exportable keys")
+	}
+
+	sk := NewSignerPrivKey(&testSigner{key: priv})
+	if _, err := KeyMaterial(sk); err != ErrNoKeyMaterial {
+		t.Fatalf("expected ErrNoKeyMaterial, got %v", err)
+	}
+
+	if _, err := NewSignerPrivKeyWithSeed(&testSigner{key: priv}, make([]byte, 16)); err == nil {
+		t.Fatal("expected an error for a short seed")
+	}
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		t.Fatal(err)
+	}
+	sk, err = NewSignerPrivKeyWithSeed(&testSigner{key: priv}, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	km, err = KeyMaterial(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(km) != string(seed) {
+		t.Fatal("expected the seed for signer keys")
+	}
+	if !sk.Equals(priv) {
+		t.Fatal("expected the seeded key to equal the private key")
+	}
+}
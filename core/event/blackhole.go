@@ -0,0 +1,14 @@
+package event
+
+import "github.com/libp2p/go-libp2p/core/network"
+
+// EvtBlackHoleStateChanged is emitted by the swarm when the state of one of its black hole
+// filters changes, either because of the outcome of dials or because the state was overridden.
+type EvtBlackHoleStateChanged struct {
+	// Filter is the name of the black hole filter, "UDP" or "IPv6".
+	Filter string
+	// State is the new state of the filter.
+	State network.BlackHoleState
+	// Overridden is true if State was set manually, and isn't based on the outcome of dials.
+	Overridden bool
+}
@@ -1,6 +1,9 @@
 package event
 
-import "github.com/libp2p/go-libp2p/core/peer"
+import (
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/record"
+)
 
 // EvtPeerIdentificationCompleted is emitted when the initial identification round for a peer is completed.
 type EvtPeerIdentificationCompleted struct {
@@ -15,3 +18,24 @@ type EvtPeerIdentificationFailed struct {
 	// Reason is the reason why identification failed.
 	Reason error
 }
+
+// EvtPeerKeySuccession is emitted when identify receives a new key succession record, announcing
+// that a peer replaced its key (see peer.KeySuccessionRecord). The record has already been
+// validated and stored in the peerstore.
+type EvtPeerKeySuccession struct {
+	// Peer is the ID of the peer that replaced its key.
+	Peer peer.ID
+	// Successor is the ID of the peer using the new key.
+	Successor peer.ID
+}
+
+// EvtPeerSignedRecordUpdated is emitted when identify receives a new signed record from a peer
+// (see peerstore.SignedRecordBook). The record has already been validated and stored in the peerstore.
+type EvtPeerSignedRecordUpdated struct {
+	// Peer is the ID of the peer that signed the record.
+	Peer peer.ID
+	// Domain is the signature domain of the record type.
+	Domain string
+	// Envelope contains the record.
+	Envelope *record.Envelope
+}
@@ -0,0 +1,30 @@
+package network
+
+// BlackHoleState is the state of a black hole filter of the swarm. A black hole filter
+// detects whether dials using a class of addresses (e.g. UDP or IPv6) never succeed in
+// the current network environment, and blocks such dials.
+type BlackHoleState int
+
+const (
+	// BlackHoleStateProbing means that there's not enough information to determine the
+	// state of the black hole yet. All dials are allowed.
+	BlackHoleStateProbing BlackHoleState = iota
+	// BlackHoleStateAllowed means that enough dials succeed to assume there's no black hole.
+	BlackHoleStateAllowed
+	// BlackHoleStateBlocked means that too few dials succeed, and we're probably in a black
+	// holed environment. Only periodic probing dials are allowed.
+	BlackHoleStateBlocked
+)
+
+func (st BlackHoleState) String() string {
+	switch st {
+	case BlackHoleStateProbing:
+		return "Probing"
+	case BlackHoleStateAllowed:
+		return "Allowed"
+	case BlackHoleStateBlocked:
+		return "Blocked"
+	default:
+		return unrecognized
+	}
+}
@@ -3,6 +3,8 @@ package network
 import (
 	"context"
 	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
 )
 
 // DialPeerTimeout is the default timeout for a single call to `DialPeer`. When
@@ -15,6 +17,8 @@ type dialPeerTimeoutCtxKey struct{}
 type forceDirectDialCtxKey struct{}
 type useTransientCtxKey struct{}
 type simConnectCtxKey struct{ isClient bool }
+type connSelectorCtxKey struct{}
+type dialAddrsCtxKey struct{}
 
 var noDial = noDialCtxKey{}
 var forceDirectDial = forceDirectDialCtxKey{}
@@ -108,3 +112,39 @@ func GetUseTransient(ctx context.Context) (usetransient bool, reason string) {
 	}
 	return false, ""
 }
+
+// ConnSelector selects the connection a new stream is opened on. conns are the
+// connections to the peer that are acceptable for the stream, ordered from best to
+// worst according to the network's default policy. If the selector returns nil, or a
+// connection not in conns, the network falls back to its default selection.
+type ConnSelector func(conns []Conn) Conn
+
+// WithConnSelector constructs a new context with an option that instructs the network
+// to use sel to select the connection when opening a new stream.
+// EXPERIMENTAL
+func WithConnSelector(ctx context.Context, sel ConnSelector) context.Context {
+	return context.WithValue(ctx, connSelectorCtxKey{}, sel)
+}
+
+// GetConnSelector returns the ConnSelector set in the context, or nil if none is set.
+// EXPERIMENTAL
+func GetConnSelector(ctx context.Context) ConnSelector {
+	sel, _ := ctx.Value(connSelectorCtxKey{}).(ConnSelector)
+	return sel
+}
+
+// WithDialAddrs constructs a new context with an option that instructs the network to
+// dial the peer on addrs, instead of the addresses in the peerstore. The addresses are
+// not added to the peerstore.
+// EXPERIMENTAL
+func WithDialAddrs(ctx context.Context, addrs []ma.Multiaddr) context.Context {
+	return context.WithValue(ctx, dialAddrsCtxKey{}, addrs)
+}
+
+// GetDialAddrs returns the addresses set in the context by WithDialAddrs. ok is false if
+// no addresses were set.
+// EXPERIMENTAL
+func GetDialAddrs(ctx context.Context) (addrs []ma.Multiaddr, ok bool) {
+	addrs, ok = ctx.Value(dialAddrsCtxKey{}).([]ma.Multiaddr)
+	return addrs, ok
+}
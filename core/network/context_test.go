@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/require"
 )
 
@@ -57,3 +58,13 @@ func TestSimultaneousConnect(t *testing.T) {
 		require.Equal(t, reason, "foo")
 	})
 }
+
+func TestDialAddrs(t *testing.T) {
+	_, ok := GetDialAddrs(context.Background())
+	require.False(t, ok)
+
+	addrs := []ma.Multiaddr{ma.StringCast("/ip4/1.2.3.4/tcp/1234")}
+	got, ok := GetDialAddrs(WithDialAddrs(context.Background(), addrs))
+	require.True(t, ok)
+	require.Equal(t, addrs, got)
+}
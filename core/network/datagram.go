@@ -0,0 +1,54 @@
+package network
+
+import (
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+var (
+	// ErrDatagramsNotSupported is returned when sending a datagram on a connection that
+	// doesn't support datagrams.
+	ErrDatagramsNotSupported = errors.New("datagrams not supported on this connection")
+	// ErrDatagramTooLarge is returned when sending a datagram larger than the connection's
+	// maximum datagram size.
+	ErrDatagramTooLarge = errors.New("datagram too large")
+)
+
+// DatagramConn is implemented by connections that can send unreliable datagrams, for example
+// QUIC connections (RFC 9221). Datagrams may be lost, reordered or duplicated, and are not
+// subject to flow control.
+//
+// Check for support using a type assertion:
+//
+//	if dc, ok := conn.(network.DatagramConn); ok && dc.SupportsDatagrams() {
+//		dc.SendDatagram(proto, msg)
+//	}
+//
+// Every datagram is sent for a protocol. It's only delivered if the receiver has registered a
+// DatagramHandler for that protocol, otherwise it's dropped. Protocols are expected to find
+// out if the remote peer supports them before sending datagrams, e.g. using identify.
+type DatagramConn interface {
+	// SupportsDatagrams says if datagram support was negotiated on this connection.
+	SupportsDatagrams() bool
+
+	// MaxDatagramSize returns the maximum size of a datagram sent for protocol proto.
+	MaxDatagramSize(proto protocol.ID) int
+
+	// SendDatagram sends b as a datagram for protocol proto.
+	SendDatagram(proto protocol.ID, b []byte) error
+}
+
+// DatagramHandler handles datagrams received for a protocol. It's called sequentially for all
+// datagrams received on a connection, and must not block. data is only valid until the
+// handler returns.
+type DatagramHandler func(c Conn, data []byte)
+
+// DatagramNetwork is implemented by Networks that support datagrams.
+type DatagramNetwork interface {
+	// SetDatagramHandler sets the handler for datagrams received for protocol proto.
+	SetDatagramHandler(proto protocol.ID, handler DatagramHandler)
+
+	// RemoveDatagramHandler removes the handler for datagrams received for protocol proto.
+	RemoveDatagramHandler(proto protocol.ID)
+}
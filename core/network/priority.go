@@ -0,0 +1,34 @@
+package network
+
+import "errors"
+
+// ErrPriorityNotSupported is returned by SetPriority when the stream muxer doesn't support
+// stream prioritization.
+var ErrPriorityNotSupported = errors.New("stream muxer doesn't support stream priorities")
+
+// StreamPriority is the priority of a stream, relative to the other streams on the same
+// connection.
+//
+// When multiple streams are writing at the same time, the send bandwidth of the connection is
+// shared between them in proportion to their priorities: a stream with priority 2*p gets twice
+// the share of a stream with priority p. The zero value is not a valid priority.
+type StreamPriority uint8
+
+const (
+	// StreamPriorityLow is meant for bulk transfers that shouldn't delay other streams.
+	StreamPriorityLow StreamPriority = 1
+	// StreamPriorityDefault is the priority of newly opened streams.
+	StreamPriorityDefault StreamPriority = 8
+	// StreamPriorityHigh is meant for small, latency-sensitive messages, like pings.
+	StreamPriorityHigh StreamPriority = 64
+)
+
+// PrioritizedStream is implemented by streams that support prioritization.
+//
+// Streams returned by the swarm always implement this interface, but SetPriority returns
+// ErrPriorityNotSupported if the stream muxer doesn't support it.
+type PrioritizedStream interface {
+	// SetPriority sets the priority of the stream.
+	// It takes effect for all subsequent writes.
+	SetPriority(StreamPriority) error
+}
@@ -0,0 +1,185 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v3.21.12
+// source: pb/key_succession.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// KeySuccessionRecord messages announce that a peer replaced its key, and that the peer
+// using the successor key should be treated as the same entity.
+//
+// KeySuccessionRecords are signed by the successor key, and then placed inside of
+// SignedEnvelopes signed by the key of the peer that is being replaced.
+// See https://github.com/libp2p/go-libp2p/core/record/pb/envelope.proto for
+// the SignedEnvelope definition.
+type KeySuccessionRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// peer_id contains the libp2p peer id of the peer being replaced, in its binary representation.
+	PeerId []byte `protobuf:"bytes,1,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`
+	// successor_public_key contains the public key of the successor.
+	SuccessorPublicKey []byte `protobuf:"bytes,2,opt,name=successor_public_key,json=successorPublicKey,proto3" json:"successor_public_key,omitempty"`
+	// seq contains a monotonically-increasing sequence counter to order KeySuccessionRecords in time.
+	Seq uint64 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	// successor_signature contains the signature of the successor key over the record,
+	// with this field left empty. It proves that the successor agreed to replace the peer.
+	SuccessorSignature []byte `protobuf:"bytes,4,opt,name=successor_signature,json=successorSignature,proto3" json:"successor_signature,omitempty"`
+}
+
+func (x *KeySuccessionRecord) Reset() {
+	*x = KeySuccessionRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_key_succession_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeySuccessionRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeySuccessionRecord) ProtoMessage() {}
+
+func (x *KeySuccessionRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_key_succession_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeySuccessionRecord.ProtoReflect.Descriptor instead.
+func (*KeySuccessionRecord) Descriptor() ([]byte, []int) {
+	return file_pb_key_succession_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *KeySuccessionRecord) GetPeerId() []byte {
+	if x != nil {
+		return x.PeerId
+	}
+	return nil
+}
+
+func (x *KeySuccessionRecord) GetSuccessorPublicKey() []byte {
+	if x != nil {
+		return x.SuccessorPublicKey
+	}
+	return nil
+}
+
+func (x *KeySuccessionRecord) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *KeySuccessionRecord) GetSuccessorSignature() []byte {
+	if x != nil {
+		return x.SuccessorSignature
+	}
+	return nil
+}
+
+var File_pb_key_succession_proto protoreflect.FileDescriptor
+
+var file_pb_key_succession_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x70, 0x62, 0x2f, 0x6b, 0x65, 0x79, 0x5f, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x70, 0x65, 0x65, 0x72, 0x2e,
+	0x70, 0x62, 0x22, 0xa3, 0x01, 0x0a, 0x13, 0x4b, 0x65, 0x79, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x65,
+	0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x65, 0x65,
+	0x72, 0x49, 0x64, 0x12, 0x30, 0x0a, 0x14, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x6f, 0x72,
+	0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x12, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x50, 0x75, 0x62, 0x6c,
+	0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x2f, 0x0a, 0x13, 0x73, 0x75, 0x63, 0x63, 0x65,
+	0x73, 0x73, 0x6f, 0x72, 0x5f, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x12, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x53,
+	0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pb_key_succession_proto_rawDescOnce sync.Once
+	file_pb_key_succession_proto_rawDescData = file_pb_key_succession_proto_rawDesc
+)
+
+func file_pb_key_succession_proto_rawDescGZIP() []byte {
+	file_pb_key_succession_proto_rawDescOnce.Do(func() {
+		file_pb_key_succession_proto_rawDescData = protoimpl.X.CompressGZIP(file_pb_key_succession_proto_rawDescData)
+	})
+	return file_pb_key_succession_proto_rawDescData
+}
+
+var file_pb_key_succession_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_pb_key_succession_proto_goTypes = []interface{}{
+	(*KeySuccessionRecord)(nil), // 0: peer.pb.KeySuccessionRecord
+}
+var file_pb_key_succession_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_pb_key_succession_proto_init() }
+func file_pb_key_succession_proto_init() {
+	if File_pb_key_succession_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pb_key_succession_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeySuccessionRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pb_key_succession_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_pb_key_succession_proto_goTypes,
+		DependencyIndexes: file_pb_key_succession_proto_depIdxs,
+		MessageInfos:      file_pb_key_succession_proto_msgTypes,
+	}.Build()
+	File_pb_key_succession_proto = out.File
+	file_pb_key_succession_proto_rawDesc = nil
+	file_pb_key_succession_proto_goTypes = nil
+	file_pb_key_succession_proto_depIdxs = nil
+}
@@ -16,7 +16,7 @@ import (
 
 //go:generate protoc --proto_path=$PWD:$PWD/../.. --go_out=. --go_opt=Mpb/peer_record.proto=./pb pb/peer_record.proto
 
-var _ record.Record = (*PeerRecord)(nil)
+var _ record.SequencedRecord = (*PeerRecord)(nil)
 
 func init() {
 	record.RegisterType(&PeerRecord{})
@@ -158,6 +158,11 @@ func (r *PeerRecord) Codec() []byte {
 	return PeerRecordEnvelopePayloadType
 }
 
+// SequenceNumber returns the Seq field of the PeerRecord.
+func (r *PeerRecord) SequenceNumber() uint64 {
+	return r.Seq
+}
+
 // UnmarshalRecord parses a PeerRecord from a byte slice.
 // This method is called automatically when consuming a record.Envelope
 // whose PayloadType indicates that it contains a PeerRecord.
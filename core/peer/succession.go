@@ -0,0 +1,233 @@
+package peer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/internal/catch"
+	"github.com/libp2p/go-libp2p/core/peer/pb"
+	"github.com/libp2p/go-libp2p/core/record"
+
+	"google.golang.org/protobuf/proto"
+)
+
+//go:generate protoc --proto_path=$PWD:$PWD/../.. --go_out=. --go_opt=Mpb/key_succession.proto=./pb pb/key_succession.proto
+
+var _ record.Record = (*KeySuccessionRecord)(nil)
+
+func init() {
+	record.RegisterType(&KeySuccessionRecord{})
+}
+
+// KeySuccessionEnvelopeDomain is the domain string used for key succession records contained in an Envelope.
+const KeySuccessionEnvelopeDomain = "libp2p-key-succession"
+
+// KeySuccessionEnvelopePayloadType is the type hint used to identify key succession records in an Envelope.
+// There's no multicodec for key succession records yet, so this is a code from the private use range.
+var KeySuccessionEnvelopePayloadType = []byte{0x80, 0x80, 0xc0, 0x01}
+
+// keySuccessionSignatureDomain is the domain string used for the signature of the successor.
+const keySuccessionSignatureDomain = "libp2p-key-succession-successor"
+
+// KeySuccessionRecord announces that a peer replaced its key. The peer using the successor key
+// should be treated as the same entity as the peer that is being replaced.
+//
+// A valid record is signed by both keys: the successor signs the record, and the record is then
+// sealed in an Envelope signed by the key that is being replaced. Use NewKeySuccession to create
+// such an envelope, and ConsumeKeySuccession to validate one.
+//
+// Note that a key succession record doesn't revoke the old key. Whoever has access to a leaked key
+// can also announce a successor, so applications should only transfer trust based on key
+// succession records if they are prepared to deal with that.
+type KeySuccessionRecord struct {
+	// PeerID is the ID of the peer that is being replaced.
+	PeerID ID
+
+	// Successor is the public key of the successor.
+	Successor crypto.PubKey
+
+	// Seq is a monotonically-increasing sequence counter that's used to order
+	// KeySuccessionRecords in time.
+	Seq uint64
+
+	// SuccessorSignature is the signature of the successor over the record.
+	SuccessorSignature []byte
+}
+
+// SuccessorID returns the ID of the successor.
+func (r *KeySuccessionRecord) SuccessorID() (ID, error) {
+	if r.Successor == nil {
+		return "", errors.New("missing successor key")
+	}
+	return IDFromPublicKey(r.Successor)
+}
+
+// NewKeySuccession creates a key succession record announcing that newKey replaces oldKey,
+// signs it with newKey, and seals it in an Envelope signed by oldKey.
+func NewKeySuccession(oldKey, newKey crypto.PrivKey) (*record.Envelope, error) {
+	id, err := IDFromPrivateKey(oldKey)
+	if err != nil {
+		return nil, err
+	}
+	rec := &KeySuccessionRecord{PeerID: id, Successor: newKey.GetPublic(), Seq: TimestampSeq()}
+	if rec.Successor.Equals(oldKey.GetPublic()) {
+		return nil, errors.New("successor key must be different from the old key")
+	}
+	unsigned, err := rec.unsignedBytes()
+	if err != nil {
+		return nil, err
+	}
+	rec.SuccessorSignature, err = newKey.Sign(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	return record.Seal(rec, oldKey)
+}
+
+// ConsumeKeySuccession validates a key succession record contained in a marshaled Envelope.
+// It checks that the envelope was signed by the key of the peer that is being replaced, and
+// that the record was signed by the successor.
+func ConsumeKeySuccession(data []byte) (*record.Envelope, *KeySuccessionRecord, error) {
+	var rec KeySuccessionRecord
+	env, err := record.ConsumeTypedEnvelope(data, &rec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := rec.verify(env); err != nil {
+		return nil, nil, err
+	}
+	return env, &rec, nil
+}
+
+// VerifyKeySuccession validates an Envelope containing a key succession record.
+// See ConsumeKeySuccession for the checks performed.
+func VerifyKeySuccession(env *record.Envelope) (*KeySuccessionRecord, error) {
+	r, err := env.Record()
+	if err != nil {
+		return nil, err
+	}
+	rec, ok := r.(*KeySuccessionRecord)
+	if !ok {
+		return nil, fmt.Errorf("unexpected record type: %T", r)
+	}
+	if err := rec.verify(env); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (r *KeySuccessionRecord) verify(env *record.Envelope) error {
+	signer, err := IDFromPublicKey(env.PublicKey)
+	if err != nil {
+		return err
+	}
+	if signer != r.PeerID {
+		return errors.New("key succession record not signed by the peer being replaced")
+	}
+	successor, err := r.SuccessorID()
+	if err != nil {
+		return err
+	}
+	if successor == r.PeerID {
+		return errors.New("peer can't be its own successor")
+	}
+	unsigned, err := r.unsignedBytes()
+	if err != nil {
+		return err
+	}
+	ok, err := r.Successor.Verify(unsigned, r.SuccessorSignature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid successor signature")
+	}
+	return nil
+}
+
+// unsignedBytes returns the bytes signed by the successor.
+func (r *KeySuccessionRecord) unsignedBytes() ([]byte, error) {
+	msg, err := r.toProtobuf()
+	if err != nil {
+		return nil, err
+	}
+	msg.SuccessorSignature = nil
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(keySuccessionSignatureDomain), b...), nil
+}
+
+// Domain is used when signing and validating KeySuccessionRecords contained in Envelopes.
+// It is constant for all KeySuccessionRecord instances.
+func (r *KeySuccessionRecord) Domain() string {
+	return KeySuccessionEnvelopeDomain
+}
+
+// Codec is a binary identifier for the KeySuccessionRecord type. It is constant for all KeySuccessionRecord instances.
+func (r *KeySuccessionRecord) Codec() []byte {
+	return KeySuccessionEnvelopePayloadType
+}
+
+// UnmarshalRecord parses a KeySuccessionRecord from a byte slice.
+// It doesn't verify the signature of the successor, use ConsumeKeySuccession for that.
+func (r *KeySuccessionRecord) UnmarshalRecord(bytes []byte) (err error) {
+	if r == nil {
+		return fmt.Errorf("cannot unmarshal KeySuccessionRecord to nil receiver")
+	}
+
+	defer func() { catch.HandlePanic(recover(), &err, "libp2p key succession record unmarshal") }()
+
+	var msg pb.KeySuccessionRecord
+	if err := proto.Unmarshal(bytes, &msg); err != nil {
+		return err
+	}
+	var id ID
+	if err := id.UnmarshalBinary(msg.PeerId); err != nil {
+		return err
+	}
+	successor, err := crypto.UnmarshalPublicKey(msg.SuccessorPublicKey)
+	if err != nil {
+		return err
+	}
+	*r = KeySuccessionRecord{
+		PeerID:             id,
+		Successor:          successor,
+		Seq:                msg.Seq,
+		SuccessorSignature: msg.SuccessorSignature,
+	}
+	return nil
+}
+
+// MarshalRecord serializes a KeySuccessionRecord to a byte slice.
+func (r *KeySuccessionRecord) MarshalRecord() (res []byte, err error) {
+	defer func() { catch.HandlePanic(recover(), &err, "libp2p key succession record marshal") }()
+
+	msg, err := r.toProtobuf()
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(msg)
+}
+
+func (r *KeySuccessionRecord) toProtobuf() (*pb.KeySuccessionRecord, error) {
+	idBytes, err := r.PeerID.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if r.Successor == nil {
+		return nil, errors.New("missing successor key")
+	}
+	successor, err := crypto.MarshalPublicKey(r.Successor)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.KeySuccessionRecord{
+		PeerId:             idBytes,
+		SuccessorPublicKey: successor,
+		Seq:                r.Seq,
+		SuccessorSignature: r.SuccessorSignature,
+	}, nil
+}
@@ -0,0 +1,99 @@
+package peer_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	. "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/record"
+	"github.com/libp2p/go-libp2p/core/test"
+)
+
+func TestKeySuccessionConstants(t *testing.T) {
+	msgf := "Changing the %s may cause key succession records to be incompatible with older versions. " +
+		"If you've already thought that through, please update this test so that it passes with the new values."
+	rec := KeySuccessionRecord{}
+	if rec.Domain() != "libp2p-key-succession" {
+		t.Errorf(msgf, "signing domain")
+	}
+	if !bytes.Equal(rec.Codec(), []byte{0x80, 0x80, 0xc0, 0x01}) {
+		t.Errorf(msgf, "codec value")
+	}
+}
+
+func TestKeySuccession(t *testing.T) {
+	oldKey, _, err := test.RandTestKeyPair(crypto.Ed25519, 256)
+	test.AssertNilError(t, err)
+	newKey, _, err := test.RandTestKeyPair(crypto.Secp256k1, 256)
+	test.AssertNilError(t, err)
+	oldID, err := IDFromPrivateKey(oldKey)
+	test.AssertNilError(t, err)
+	newID, err := IDFromPrivateKey(newKey)
+	test.AssertNilError(t, err)
+
+	env, err := NewKeySuccession(oldKey, newKey)
+	test.AssertNilError(t, err)
+	b, err := env.Marshal()
+	test.AssertNilError(t, err)
+
+	_, rec, err := ConsumeKeySuccession(b)
+	test.AssertNilError(t, err)
+	if rec.PeerID != oldID {
+		t.Fatalf("expected peer ID %s, got %s", oldID, rec.PeerID)
+	}
+	successor, err := rec.SuccessorID()
+	test.AssertNilError(t, err)
+	if successor != newID {
+		t.Fatalf("expected successor %s, got %s", newID, successor)
+	}
+
+	rec2, err := VerifyKeySuccession(env)
+	test.AssertNilError(t, err)
+	if rec2.Seq != rec.Seq {
+		t.Fatal("expected the same record")
+	}
+}
+
+func TestKeySuccessionSameKey(t *testing.T) {
+	key, _, err := test.RandTestKeyPair(crypto.Ed25519, 256)
+	test.AssertNilError(t, err)
+	if _, err := NewKeySuccession(key, key); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestKeySuccessionInvalidSignatures(t *testing.T) {
+	oldKey, _, err := test.RandTestKeyPair(crypto.Ed25519, 256)
+	test.AssertNilError(t, err)
+	newKey, _, err := test.RandTestKeyPair(crypto.Ed25519, 256)
+	test.AssertNilError(t, err)
+	otherKey, _, err := test.RandTestKeyPair(crypto.Ed25519, 256)
+	test.AssertNilError(t, err)
+	oldID, err := IDFromPrivateKey(oldKey)
+	test.AssertNilError(t, err)
+
+	t.Run("not signed by the successor", func(t *testing.T) {
+		rec := &KeySuccessionRecord{PeerID: oldID, Successor: newKey.GetPublic(), Seq: 1, SuccessorSignature: []byte("foobar")}
+		env, err := record.Seal(rec, oldKey)
+		test.AssertNilError(t, err)
+		b, err := env.Marshal()
+		test.AssertNilError(t, err)
+		if _, _, err := ConsumeKeySuccession(b); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("not sealed by the peer being replaced", func(t *testing.T) {
+		env, err := NewKeySuccession(oldKey, newKey)
+		test.AssertNilError(t, err)
+		rec, err := VerifyKeySuccession(env)
+		test.AssertNilError(t, err)
+		// Reseal the record using a different key.
+		env, err = record.Seal(rec, otherKey)
+		test.AssertNilError(t, err)
+		if _, err := VerifyKeySuccession(env); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
@@ -193,6 +193,68 @@ func GetCertifiedAddrBook(ab AddrBook) (cab CertifiedAddrBook, ok bool) {
 	return cab, ok
 }
 
+// KeySuccessionBook stores key succession records, which announce that a peer replaced its
+// key (see peer.KeySuccessionRecord).
+//
+// Most Peerstore implementations include a KeySuccessionBook as part of their KeyBook.
+// To access it, callers should use the GetKeySuccessionBook helper.
+type KeySuccessionBook interface {
+	// ConsumeKeySuccession validates and stores a key succession record contained in an Envelope.
+	//
+	// A peer can only have a single successor. If 'accepted' is false but no error is returned,
+	// the record was ignored, because a record announcing a different successor for the same
+	// peer, or a record with the same or a higher sequence number was stored before.
+	ConsumeKeySuccession(env *record.Envelope) (accepted bool, err error)
+
+	// Successor returns the successor of a peer, if known.
+	Successor(p peer.ID) (successor peer.ID, ok bool)
+
+	// KeySuccessionRecords returns the records proving that p is the successor of other peers:
+	// the records announcing p as successor, followed by the records of their predecessors,
+	// and so on.
+	KeySuccessionRecords(p peer.ID) []*record.Envelope
+}
+
+// GetKeySuccessionBook is a helper to "upcast" a KeyBook to a KeySuccessionBook by using type
+// assertion. Returns (nil, false) if the KeyBook is not a KeySuccessionBook.
+//
+// Note that since Peerstore embeds the KeyBook interface, you can also
+// call GetKeySuccessionBook(myPeerstore).
+func GetKeySuccessionBook(kb KeyBook) (ksb KeySuccessionBook, ok bool) {
+	ksb, ok = kb.(KeySuccessionBook)
+	return ksb, ok
+}
+
+// SignedRecordBook stores signed records of any registered record type (see record.RegisterType).
+// Records are stored per peer and domain: the peer is derived from the key that signed the
+// Envelope, and the domain is the signature domain of the record type.
+//
+// Most Peerstore implementations include a SignedRecordBook.
+// To access it, callers should use the GetSignedRecordBook helper.
+type SignedRecordBook interface {
+	// ConsumeSignedRecord stores a signed record contained in an Envelope.
+	// The record must implement record.SequencedRecord.
+	//
+	// If 'accepted' is false but no error is returned, the record was ignored, because a
+	// record with the same domain and the same or a higher sequence number, signed by the
+	// same peer, was stored before.
+	ConsumeSignedRecord(env *record.Envelope) (accepted bool, err error)
+
+	// GetSignedRecord returns the record of a peer with the given domain,
+	// or nil if there is none.
+	GetSignedRecord(p peer.ID, domain string) *record.Envelope
+
+	// SignedRecords returns all records stored for a peer.
+	SignedRecords(p peer.ID) []*record.Envelope
+}
+
+// GetSignedRecordBook is a helper to "upcast" a Peerstore to a SignedRecordBook by using type
+// assertion. Returns (nil, false) if the Peerstore is not a SignedRecordBook.
+func GetSignedRecordBook(ps Peerstore) (srb SignedRecordBook, ok bool) {
+	srb, ok = ps.(SignedRecordBook)
+	return srb, ok
+}
+
 // KeyBook tracks the keys of Peers.
 type KeyBook interface {
 	// PubKey stores the public key of a peer.
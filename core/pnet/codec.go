@@ -5,12 +5,16 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 )
 
 var (
 	pathPSKv1  = []byte("/key/swarm/psk/1.0.0/")
+	pathPSKv2  = []byte("/key/swarm/psk/2.0.0/")
 	pathBin    = "/bin/"
 	pathBase16 = "/base16/"
 	pathBase64 = "/base64/"
@@ -42,6 +46,10 @@ func DecodeV1PSK(in io.Reader) (PSK, error) {
 	if err := expectHeader(reader, pathPSKv1); err != nil {
 		return nil, err
 	}
+	return decodeV1Key(reader)
+}
+
+func decodeV1Key(reader *bufio.Reader) (PSK, error) {
 	header, err := readHeader(reader)
 	if err != nil {
 		return nil, err
@@ -64,3 +72,104 @@ func DecodeV1PSK(in io.Reader) (PSK, error) {
 	}
 	return out, nil
 }
+
+// DecodeV2PSKSet reads a Multicodec encoded V2 PSK set.
+//
+// A V2 PSK file starts with the same headers as a V1 PSK file, followed by one key per line.
+// Each key can optionally be followed by its validity period, as RFC 3339 timestamps:
+//
+//	/key/swarm/psk/2.0.0/
+//	/base16/
+//	<key> not-after=2023-06-01T00:00:00Z
+//	<key> not-before=2023-05-01T00:00:00Z
+//
+// Only the base16 and base64 encodings are supported. Empty lines and lines starting with # are ignored.
+func DecodeV2PSKSet(in io.Reader) (PSKSet, error) {
+	reader := bufio.NewReader(in)
+	if err := expectHeader(reader, pathPSKv2); err != nil {
+		return nil, err
+	}
+	return decodeV2Keys(reader)
+}
+
+// DecodePSKSet reads a Multicodec encoded V1 PSK or V2 PSK set.
+// A V1 PSK is returned as a set containing a single key that is valid indefinitely.
+func DecodePSKSet(in io.Reader) (PSKSet, error) {
+	reader := bufio.NewReader(in)
+	header, err := readHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.Equal(header, pathPSKv1):
+		psk, err := decodeV1Key(reader)
+		if err != nil {
+			return nil, err
+		}
+		return NewPSKSet(psk), nil
+	case bytes.Equal(header, pathPSKv2):
+		return decodeV2Keys(reader)
+	default:
+		return nil, fmt.Errorf("unknown file header: %s", header)
+	}
+}
+
+func decodeV2Keys(reader *bufio.Reader) (PSKSet, error) {
+	header, err := readHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	var decode func(string) ([]byte, error)
+	switch string(header) {
+	case pathBase16:
+		decode = hex.DecodeString
+	case pathBase64:
+		decode = base64.StdEncoding.DecodeString
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", header)
+	}
+
+	var set PSKSet
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		psk, err := decode(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key: %w", err)
+		}
+		if len(psk) != 32 {
+			return nil, fmt.Errorf("expected 32 byte key, got %d bytes", len(psk))
+		}
+		key := Key{PSK: psk}
+		for _, f := range fields[1:] {
+			name, value, ok := strings.Cut(f, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid key attribute: %s", f)
+			}
+			var t *time.Time
+			switch name {
+			case "not-before":
+				t = &key.NotBefore
+			case "not-after":
+				t = &key.NotAfter
+			default:
+				return nil, fmt.Errorf("unknown key attribute: %s", name)
+			}
+			if *t, err = time.Parse(time.RFC3339, value); err != nil {
+				return nil, fmt.Errorf("invalid %s time: %w", name, err)
+			}
+		}
+		set = append(set, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(set) == 0 {
+		return nil, errors.New("no keys found")
+	}
+	return set, nil
+}
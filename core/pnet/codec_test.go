@@ -3,7 +3,9 @@ package pnet
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/hex"
 	"testing"
+	"time"
 )
 
 func bufWithBase(base string, windows bool) *bytes.Buffer {
@@ -120,3 +122,73 @@ func testDecodeBin(t *testing.T, windows bool) {
 	}
 
 }
+
+func TestDecodeV2PSKSet(t *testing.T) {
+	key1 := bytes.Repeat([]byte{1}, 32)
+	key2 := bytes.Repeat([]byte{2}, 32)
+	b := &bytes.Buffer{}
+	b.Write(pathPSKv2)
+	b.WriteString("\n/base16/\n")
+	b.WriteString("# the old key\n")
+	b.WriteString(hex.EncodeToString(key1) + " not-after=2023-06-01T00:00:00Z\n\n")
+	b.WriteString(hex.EncodeToString(key2) + " not-before=2023-05-01T00:00:00Z\n")
+
+	set, err := DecodeV2PSKSet(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(set))
+	}
+	if !bytes.Equal(set[0].PSK, key1) || !set[0].NotBefore.IsZero() || !set[0].NotAfter.Equal(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("first key decoded incorrectly: %+v", set[0])
+	}
+	if !bytes.Equal(set[1].PSK, key2) || !set[1].NotBefore.Equal(time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)) || !set[1].NotAfter.IsZero() {
+		t.Fatalf("second key decoded incorrectly: %+v", set[1])
+	}
+}
+
+func TestDecodeV2PSKSetBad(t *testing.T) {
+	key := hex.EncodeToString(make([]byte, 32))
+	for _, tc := range []struct{ name, content string }{
+		{"binary encoding", "/bin/\n" + string(make([]byte, 32))},
+		{"short key", "/base16/\nffff\n"},
+		{"unknown attribute", "/base16/\n" + key + " foo=2023-05-01T00:00:00Z\n"},
+		{"invalid time", "/base16/\n" + key + " not-before=yesterday\n"},
+		{"no keys", "/base16/\n# nothing here\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			b.Write(pathPSKv2)
+			b.WriteString("\n" + tc.content)
+			if _, err := DecodeV2PSKSet(b); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestDecodePSKSet(t *testing.T) {
+	b := bufWithBase("/base16/", false)
+	for i := 0; i < 32; i++ {
+		b.WriteString("FF")
+	}
+	set, err := DecodePSKSet(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 1 || !bytes.Equal(set[0].PSK, bytes.Repeat([]byte{0xff}, 32)) {
+		t.Fatalf("V1 PSK decoded incorrectly: %+v", set)
+	}
+
+	b = &bytes.Buffer{}
+	b.Write(pathPSKv2)
+	b.WriteString("\n/base64/\n" + base64.StdEncoding.EncodeToString(make([]byte, 32)) + "\n")
+	set, err = DecodePSKSet(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 1 || !bytes.Equal(set[0].PSK, make([]byte, 32)) {
+		t.Fatalf("V2 PSK set decoded incorrectly: %+v", set)
+	}
+}
@@ -0,0 +1,80 @@
+package pnet
+
+import "time"
+
+// ErrNoActivePSK is returned when a PSKSet doesn't contain any key that can currently be used
+// to protect outgoing connections.
+var ErrNoActivePSK = NewError("no active pre-shared key")
+
+// Key is a PSK with an optional validity period.
+type Key struct {
+	PSK PSK
+	// NotBefore is the time from which the key is used to protect connections.
+	// Before this time, the key is only accepted on connections protected by the remote peer.
+	// The zero value means that the key is valid from the beginning of time.
+	NotBefore time.Time
+	// NotAfter is the time after which the key is no longer accepted.
+	// The zero value means that the key never expires.
+	NotAfter time.Time
+}
+
+// Expired says if the key is expired at the given time.
+func (k Key) Expired(now time.Time) bool {
+	return !k.NotAfter.IsZero() && now.After(k.NotAfter)
+}
+
+// Active says if the key is used to protect connections at the given time.
+func (k Key) Active(now time.Time) bool {
+	return !k.Expired(now) && !now.Before(k.NotBefore)
+}
+
+// A PSKSet is a set of PSKs, which allows rotating the PSK of a private network without
+// restarting all nodes at the same time.
+//
+// All keys that are not expired are accepted on incoming data, while outgoing data is protected
+// with the active key that became valid last. A key rotation is performed by first distributing a
+// set containing both the old key and a new key with a NotBefore time in the future to all nodes.
+// When the NotBefore time is reached, nodes start using the new key, and the old key can be
+// removed, or expired using its NotAfter time.
+type PSKSet []Key
+
+// NewPSKSet creates a PSKSet from PSKs that are valid indefinitely.
+// The first PSK is used to protect connections.
+func NewPSKSet(psks ...PSK) PSKSet {
+	set := make(PSKSet, 0, len(psks))
+	for _, psk := range psks {
+		set = append(set, Key{PSK: psk})
+	}
+	return set
+}
+
+// Current returns the PSK used to protect connections at the given time.
+// If multiple keys are active, the key with the latest NotBefore time is used.
+// It returns ErrNoActivePSK if none of the keys are active.
+func (s PSKSet) Current(now time.Time) (PSK, error) {
+	var current *Key
+	for i, k := range s {
+		if !k.Active(now) {
+			continue
+		}
+		if current == nil || k.NotBefore.After(current.NotBefore) {
+			current = &s[i]
+		}
+	}
+	if current == nil {
+		return nil, ErrNoActivePSK
+	}
+	return current.PSK, nil
+}
+
+// Accepted returns the PSKs that are accepted at the given time, i.e. all keys that are not expired.
+func (s PSKSet) Accepted(now time.Time) []PSK {
+	psks := make([]PSK, 0, len(s))
+	for _, k := range s {
+		if k.Expired(now) {
+			continue
+		}
+		psks = append(psks, k.PSK)
+	}
+	return psks
+}
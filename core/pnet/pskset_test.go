@@ -0,0 +1,59 @@
+package pnet
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPSKSet(t *testing.T) {
+	now := time.Now()
+	oldKey := PSK(bytes.Repeat([]byte{1}, 32))
+	newKey := PSK(bytes.Repeat([]byte{2}, 32))
+	expiredKey := PSK(bytes.Repeat([]byte{3}, 32))
+	set := PSKSet{
+		{PSK: oldKey, NotAfter: now.Add(2 * time.Hour)},
+		{PSK: newKey, NotBefore: now.Add(time.Hour)},
+		{PSK: expiredKey, NotAfter: now.Add(-time.Hour)},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		at       time.Time
+		current  PSK
+		accepted []PSK
+	}{
+		{"before rotation", now, oldKey, []PSK{oldKey, newKey}},
+		{"during rotation", now.Add(90 * time.Minute), newKey, []PSK{oldKey, newKey}},
+		{"after rotation", now.Add(3 * time.Hour), newKey, []PSK{newKey}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			current, err := set.Current(tc.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(current, tc.current) {
+				t.Fatalf("unexpected current key: %x", current)
+			}
+			accepted := set.Accepted(tc.at)
+			if len(accepted) != len(tc.accepted) {
+				t.Fatalf("expected %d accepted keys, got %d", len(tc.accepted), len(accepted))
+			}
+			for i := range accepted {
+				if !bytes.Equal(accepted[i], tc.accepted[i]) {
+					t.Fatalf("unexpected accepted key: %x", accepted[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPSKSetNoActiveKey(t *testing.T) {
+	set := PSKSet{{PSK: make([]byte, 32), NotBefore: time.Now().Add(time.Hour)}}
+	if _, err := set.Current(time.Now()); err != ErrNoActivePSK {
+		t.Fatalf("expected ErrNoActivePSK, got %v", err)
+	}
+	if len(set.Accepted(time.Now())) != 1 {
+		t.Fatal("expected key to be accepted before it becomes active")
+	}
+}
@@ -127,6 +127,32 @@ func ConsumeEnvelope(data []byte, domain string) (envelope *Envelope, rec Record
 	return e, rec, nil
 }
 
+// ConsumeRegisteredEnvelope unmarshals a serialized Envelope and validates its signature,
+// using the domain of the Record type registered for the Envelope's PayloadType.
+// This allows consuming Envelopes containing any registered Record type, without
+// knowing the type in advance.
+//
+// Unlike ConsumeEnvelope, ConsumeRegisteredEnvelope returns ErrPayloadTypeNotRegistered
+// (and no Envelope) if no Record type is registered for the PayloadType, since the
+// signature can't be validated without knowing the domain.
+func ConsumeRegisteredEnvelope(data []byte) (envelope *Envelope, rec Record, err error) {
+	e, err := UnmarshalEnvelope(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed when unmarshalling the envelope: %w", err)
+	}
+
+	rec, err = e.Record()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal envelope payload: %w", err)
+	}
+
+	err = e.validate(rec.Domain())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to validate envelope: %w", err)
+	}
+	return e, rec, nil
+}
+
 // ConsumeTypedEnvelope unmarshals a serialized Envelope and validates its
 // signature. If validation fails, an error is returned, along with the unmarshalled
 // envelope, so it can be inspected.
@@ -110,6 +110,40 @@ func TestConsumeTypedEnvelope(t *testing.T) {
 	}
 }
 
+func TestConsumeRegisteredEnvelope(t *testing.T) {
+	var (
+		rec          = &simpleRecord{message: "hello world!"}
+		priv, _, err = test.RandTestKeyPair(crypto.Ed25519, 256)
+	)
+	test.AssertNilError(t, err)
+
+	envelope, err := Seal(rec, priv)
+	test.AssertNilError(t, err)
+	serialized, err := envelope.Marshal()
+	test.AssertNilError(t, err)
+
+	RegisterType(&simpleRecord{})
+	_, rec2, err := ConsumeRegisteredEnvelope(serialized)
+	test.AssertNilError(t, err)
+	if rec2.(*simpleRecord).message != "hello world!" {
+		t.Error("unexpected alteration of record")
+	}
+
+	serialized = alterMessageAndMarshal(t, envelope, func(msg *pb.Envelope) {
+		msg.Payload = []byte("totally legit, trust me")
+	})
+	_, _, err = ConsumeRegisteredEnvelope(serialized)
+	test.ExpectError(t, err, "should not be able to open envelope with modified payload")
+
+	serialized = alterMessageAndMarshal(t, envelope, func(msg *pb.Envelope) {
+		msg.PayloadType = []byte("foo")
+	})
+	_, _, err = ConsumeRegisteredEnvelope(serialized)
+	if !errors.Is(err, ErrPayloadTypeNotRegistered) {
+		t.Errorf("expected ErrPayloadTypeNotRegistered, got %v", err)
+	}
+}
+
 func TestMakeEnvelopeFailsWithEmptyDomain(t *testing.T) {
 	var (
 		rec          = simpleRecord{message: "hello world!"}
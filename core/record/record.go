@@ -50,6 +50,19 @@ type Record interface {
 	UnmarshalRecord([]byte) error
 }
 
+// SequencedRecord is a Record that carries a sequence number, which is used to order
+// instances of the Record type that were signed by the same key in time. Newer records
+// must have a greater sequence number than older records.
+//
+// Record types must implement SequencedRecord in order to be stored in a
+// peerstore.SignedRecordBook.
+type SequencedRecord interface {
+	Record
+
+	// SequenceNumber returns the sequence number of the record.
+	SequenceNumber() uint64
+}
+
 // RegisterType associates a binary payload type identifier with a concrete
 // Record type. This is used to automatically unmarshal Record payloads from Envelopes
 // when using ConsumeEnvelope, and to automatically marshal Records and determine the
@@ -0,0 +1,38 @@
+package sec
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// HandshakeCredentials exchanges application-defined credentials with the remote peer during the
+// security handshake, e.g. to prove membership in a private network.
+//
+// Security transports that support it (Noise and TLS) look for HandshakeCredentials in the context
+// passed to SecureInbound and SecureOutbound (see WithHandshakeCredentials). The upgrader adds
+// the connection gater to the context if it implements HandshakeCredentials.
+type HandshakeCredentials interface {
+	// LocalCredential returns the credential sent to the remote peer.
+	// If it returns nil, no credential is sent.
+	LocalCredential() []byte
+
+	// HandleRemoteCredential is called with the credential sent by the remote peer, once the
+	// remote peer's identity has been authenticated. If the peer didn't send a credential,
+	// it is called with a nil credential. Returning an error aborts the handshake.
+	HandleRemoteCredential(p peer.ID, credential []byte) error
+}
+
+type handshakeCredentialsCtxKey struct{}
+
+// WithHandshakeCredentials returns a context that instructs security transports to exchange
+// handshake credentials using hc.
+func WithHandshakeCredentials(ctx context.Context, hc HandshakeCredentials) context.Context {
+	return context.WithValue(ctx, handshakeCredentialsCtxKey{}, hc)
+}
+
+// GetHandshakeCredentials returns the HandshakeCredentials set in the context, if any.
+func GetHandshakeCredentials(ctx context.Context) (HandshakeCredentials, bool) {
+	hc, ok := ctx.Value(handshakeCredentialsCtxKey{}).(HandshakeCredentials)
+	return hc, ok
+}
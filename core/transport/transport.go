@@ -35,6 +35,26 @@ type CapableConn interface {
 	Transport() Transport
 }
 
+// DatagramConn is implemented by CapableConns that can send unreliable datagrams,
+// for example QUIC connections (RFC 9221).
+//
+// Transports don't interpret datagrams, the swarm uses them to implement network.DatagramConn.
+type DatagramConn interface {
+	// SupportsDatagrams says if datagram support was negotiated on this connection.
+	SupportsDatagrams() bool
+
+	// MaxDatagramSize returns the maximum size of a datagram.
+	MaxDatagramSize() int
+
+	// SendDatagram sends b as a datagram.
+	// It returns network.ErrDatagramTooLarge if b is larger than MaxDatagramSize.
+	SendDatagram(b []byte) error
+
+	// ReceiveDatagram blocks until a datagram is received. It returns an error once the
+	// connection is closed.
+	ReceiveDatagram(ctx context.Context) ([]byte, error)
+}
+
 // Transport represents any device by which you can connect to and accept
 // connections from other peers.
 //
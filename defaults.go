@@ -4,6 +4,7 @@ package libp2p
 
 import (
 	"crypto/rand"
+	"errors"
 
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
@@ -48,6 +49,24 @@ var DefaultTransports = ChainOptions(
 	Transport(webtransport.New),
 )
 
+// defaultSignerTransports are the default transports when the identity is held by a crypto.Signer
+// that was created without a seed. QUIC and WebTransport derive keys from the host key, which
+// requires key material (see crypto.KeyMaterial).
+var defaultSignerTransports = ChainOptions(
+	Transport(tcp.NewTCPTransport),
+	Transport(ws.New),
+)
+
+// hasKeyMaterial says if symmetric keys can be derived from the configured identity.
+// If no identity was configured, a random key is generated, which has key material.
+func hasKeyMaterial(cfg *Config) bool {
+	if cfg.PeerKey == nil {
+		return true
+	}
+	_, err := crypto.KeyMaterial(cfg.PeerKey)
+	return !errors.Is(err, crypto.ErrNoKeyMaterial)
+}
+
 // DefaultPrivateTransports are the default libp2p transports when a PSK is supplied.
 //
 // Use this option when you want to *extend* the set of transports used by
@@ -146,11 +165,19 @@ var defaults = []struct {
 		opt:      DefaultListenAddrs,
 	},
 	{
-		fallback: func(cfg *Config) bool { return cfg.Transports == nil && cfg.PSK == nil },
-		opt:      DefaultTransports,
+		fallback: func(cfg *Config) bool {
+			return cfg.Transports == nil && !cfg.IsPrivateNetwork() && hasKeyMaterial(cfg)
+		},
+		opt: DefaultTransports,
+	},
+	{
+		fallback: func(cfg *Config) bool {
+			return cfg.Transports == nil && !cfg.IsPrivateNetwork() && !hasKeyMaterial(cfg)
+		},
+		opt: defaultSignerTransports,
 	},
 	{
-		fallback: func(cfg *Config) bool { return cfg.Transports == nil && cfg.PSK != nil },
+		fallback: func(cfg *Config) bool { return cfg.Transports == nil && cfg.IsPrivateNetwork() },
 		opt:      DefaultPrivateTransports,
 	},
 	{
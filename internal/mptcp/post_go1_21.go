@@ -0,0 +1,22 @@
+//go:build go1.21
+
+// This package use build tags to enable Multipath TCP (RFC 8684) on go1.21 and above,
+// where net.Dialer and net.ListenConfig gained the SetMultipathTCP method.
+// See https://go.dev/issue/56539.
+// TODO: Once go1.22 releases remove this package and call SetMultipathTCP directly.
+package mptcp
+
+import "net"
+
+// Available says if Multipath TCP can be enabled.
+const Available = true
+
+// EnableDialer enables Multipath TCP for connections dialed by d.
+func EnableDialer(d *net.Dialer) {
+	d.SetMultipathTCP(true)
+}
+
+// EnableListenConfig enables Multipath TCP for listeners created by lc.
+func EnableListenConfig(lc *net.ListenConfig) {
+	lc.SetMultipathTCP(true)
+}
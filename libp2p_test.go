@@ -1,20 +1,30 @@
 package libp2p
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"net"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/connmgr"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/pnet"
 	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/p2p/keystore"
+	bconnmgr "github.com/libp2p/go-libp2p/p2p/net/connmgr"
+	"github.com/libp2p/go-libp2p/p2p/net/membership"
 	"github.com/libp2p/go-libp2p/p2p/net/swarm"
 	"github.com/libp2p/go-libp2p/p2p/security/noise"
 	tls "github.com/libp2p/go-libp2p/p2p/security/tls"
+	"github.com/libp2p/go-libp2p/p2p/signer"
 	quic "github.com/libp2p/go-libp2p/p2p/transport/quic"
 	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
 	webtransport "github.com/libp2p/go-libp2p/p2p/transport/webtransport"
@@ -249,7 +259,7 @@ func TestTransportConstructorWithWrongOpts(t *testing.T) {
 		Transport(quic.NewTransport, tcp.DisableReuseport()),
 		DisableRelay(),
 	)
-	require.EqualError(t, err, "transport constructor doesn't take any options")
+	require.EqualError(t, err, "transport option of type tcp.Option not assignable to libp2pquic.Option")
 }
 
 func TestSecurityConstructor(t *testing.T) {
@@ -291,6 +301,177 @@ func TestSecurityConstructor(t *testing.T) {
 	require.NoError(t, h2.Connect(context.Background(), ai))
 }
 
+func TestIdentitySigner(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	server := signer.NewServer(priv)
+	go server.Serve(l)
+	defer server.Close()
+	s, err := signer.Dial("unix", path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	for _, sec := range []Option{Security(noise.ID, noise.New), Security(tls.ID, tls.New)} {
+		h1, err := New(
+			IdentitySigner(s),
+			ListenAddrStrings("/ip4/127.0.0.1/tcp/0"),
+			Transport(tcp.NewTCPTransport),
+			sec,
+			DisableRelay(),
+		)
+		require.NoError(t, err)
+		defer h1.Close()
+		id, err := peer.IDFromPrivateKey(priv)
+		require.NoError(t, err)
+		require.Equal(t, id, h1.ID())
+
+		h2, err := New(NoListenAddrs, Transport(tcp.NewTCPTransport), sec, DisableRelay())
+		require.NoError(t, err)
+		defer h2.Close()
+		require.NoError(t, h2.Connect(context.Background(), peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+
+		// h1 sealed its own peer record using the signer.
+		cab, ok := peerstore.GetCertifiedAddrBook(h1.Peerstore())
+		require.True(t, ok)
+		require.NotNil(t, cab.GetPeerRecord(h1.ID()))
+	}
+}
+
+func TestIdentitySignerDefaultTransports(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	server := signer.NewServer(priv)
+	go server.Serve(l)
+	defer server.Close()
+	s, err := signer.Dial("unix", path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	// QUIC and WebTransport are left out, as they require key material.
+	h1, err := New(IdentitySigner(s))
+	require.NoError(t, err)
+	defer h1.Close()
+	require.NotEmpty(t, h1.Addrs())
+	for _, a := range h1.Addrs() {
+		_, err := a.ValueForProtocol(ma.P_TCP)
+		require.NoError(t, err, "unexpected address: %s", a)
+	}
+
+	h2, err := New(NoListenAddrs)
+	require.NoError(t, err)
+	defer h2.Close()
+	require.NoError(t, h2.Connect(context.Background(), peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+
+	// With a seed, the regular default transports are used.
+	sk, err := crypto.NewSignerPrivKeyWithSeed(s, bytes.Repeat([]byte{42}, 32))
+	require.NoError(t, err)
+	h3, err := New(Identity(sk))
+	require.NoError(t, err)
+	defer h3.Close()
+	var hasQUIC bool
+	for _, a := range h3.Addrs() {
+		if _, err := a.ValueForProtocol(ma.P_QUIC_V1); err == nil {
+			hasQUIC = true
+		}
+	}
+	require.True(t, hasQUIC)
+}
+
+func TestIdentitySignerQUIC(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	server := signer.NewServer(priv)
+	go server.Serve(l)
+	defer server.Close()
+	s, err := signer.Dial("unix", path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	// Without a seed, there's no key material to derive the stateless reset key from.
+	_, err = New(IdentitySigner(s), ListenAddrStrings("/ip4/127.0.0.1/udp/0/quic-v1"), Transport(quic.NewTransport))
+	require.ErrorContains(t, err, crypto.ErrNoKeyMaterial.Error())
+
+	sk, err := crypto.NewSignerPrivKeyWithSeed(s, bytes.Repeat([]byte{42}, 32))
+	require.NoError(t, err)
+	h1, err := New(Identity(sk), ListenAddrStrings("/ip4/127.0.0.1/udp/0/quic-v1"), Transport(quic.NewTransport))
+	require.NoError(t, err)
+	defer h1.Close()
+
+	h2, err := New(NoListenAddrs, Transport(quic.NewTransport))
+	require.NoError(t, err)
+	defer h2.Close()
+	require.NoError(t, h2.Connect(context.Background(), peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+}
+
+func TestIdentityFromKeystore(t *testing.T) {
+	ks, err := keystore.Open(t.TempDir(), []byte("foobar"), keystore.WithArgon2id(1, 64, 1))
+	require.NoError(t, err)
+	h, err := New(IdentityFromKeystore(ks, "host"), NoListenAddrs)
+	require.NoError(t, err)
+	id := h.ID()
+	h.Close()
+
+	// The key is persisted.
+	infos, err := ks.Info("host")
+	require.NoError(t, err)
+	require.Equal(t, id, infos[0].ID)
+	h, err = New(IdentityFromKeystore(ks, "host"), NoListenAddrs)
+	require.NoError(t, err)
+	defer h.Close()
+	require.Equal(t, id, h.ID())
+}
+
+func TestKeySuccession(t *testing.T) {
+	oldKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	newKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	oldID, err := peer.IDFromPrivateKey(oldKey)
+	require.NoError(t, err)
+	env, err := peer.NewKeySuccession(oldKey, newKey)
+	require.NoError(t, err)
+
+	// The records must lead to the host's ID.
+	_, err = New(KeySuccession(env), NoListenAddrs)
+	require.Error(t, err)
+
+	h1, err := New(
+		Identity(newKey),
+		KeySuccession(env),
+		ListenAddrStrings("/ip4/127.0.0.1/tcp/0"),
+		Transport(tcp.NewTCPTransport),
+		DisableRelay(),
+	)
+	require.NoError(t, err)
+	defer h1.Close()
+
+	cm, err := bconnmgr.NewConnManager(10, 20)
+	require.NoError(t, err)
+	defer cm.Close()
+	cm.Protect(oldID, "trusted")
+	h2, err := New(
+		ConnectionManager(cm),
+		HonorKeySuccession(),
+		NoListenAddrs,
+		Transport(tcp.NewTCPTransport),
+		DisableRelay(),
+	)
+	require.NoError(t, err)
+	defer h2.Close()
+
+	require.NoError(t, h2.Connect(context.Background(), peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+	require.Eventually(t, func() bool { return cm.IsProtected(h1.ID(), "trusted") }, 5*time.Second, 10*time.Millisecond)
+}
+
 func TestTransportConstructorWebTransport(t *testing.T) {
 	h, err := New(
 		Transport(webtransport.New),
@@ -356,3 +537,111 @@ func TestTransportCustomAddressWebTransportDoesNotStall(t *testing.T) {
 	// We did not add the certhash to the multiaddr
 	require.Equal(t, addrs[0], customAddr)
 }
+
+func TestPrivateNetworkPSKSet(t *testing.T) {
+	oldKey := pnet.PSK(bytes.Repeat([]byte{1}, 32))
+	newKey := pnet.PSK(bytes.Repeat([]byte{2}, 32))
+	now := time.Now()
+
+	for _, addr := range []string{"/ip4/127.0.0.1/tcp/0", "/ip4/127.0.0.1/udp/0/quic-v1"} {
+		t.Run(addr, func(t *testing.T) {
+			newHost := func(psks pnet.PSKSet) host.Host {
+				opts := []Option{ListenAddrs(ma.StringCast(addr)), PrivateNetworkPSKSet(psks), DisableRelay()}
+				if strings.Contains(addr, "quic") {
+					// QUIC is not enabled by default in private networks.
+					opts = append(opts, Transport(quic.NewTransport, quic.WithPSKSet(psks)))
+				}
+				h, err := New(opts...)
+				require.NoError(t, err)
+				t.Cleanup(func() { h.Close() })
+				return h
+			}
+			// h1 accepts the new key, but still uses the old key
+			h1 := newHost(pnet.PSKSet{{PSK: oldKey}, {PSK: newKey, NotBefore: now.Add(time.Hour)}})
+			// h2 already uses the new key
+			h2 := newHost(pnet.PSKSet{{PSK: oldKey}, {PSK: newKey, NotBefore: now.Add(-time.Hour)}})
+			// h3 only knows the new key
+			h3 := newHost(pnet.NewPSKSet(newKey))
+			outsider := newHost(pnet.NewPSKSet(bytes.Repeat([]byte{3}, 32)))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			require.NoError(t, h2.Connect(ctx, peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+			require.NoError(t, h3.Connect(ctx, peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+			require.Error(t, outsider.Connect(ctx, peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+		})
+	}
+}
+
+func TestPrivateNetworkNoQUICByDefault(t *testing.T) {
+	psks := pnet.NewPSKSet(bytes.Repeat([]byte{1}, 32))
+	_, err := New(ListenAddrs(ma.StringCast("/ip4/127.0.0.1/udp/0/quic-v1")), PrivateNetworkPSKSet(psks))
+	require.Error(t, err)
+}
+
+func TestPrivateNetworkPSKSetNoActiveKey(t *testing.T) {
+	psks := pnet.PSKSet{{PSK: bytes.Repeat([]byte{1}, 32), NotAfter: time.Now().Add(-time.Hour)}}
+	_, err := New(PrivateNetworkPSKSet(psks))
+	require.ErrorIs(t, err, pnet.ErrNoActivePSK)
+}
+
+func TestMembershipGater(t *testing.T) {
+	caKey, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	const networkName = "test-network"
+
+	for _, addr := range []string{"/ip4/127.0.0.1/tcp/0", "/ip4/127.0.0.1/udp/0/quic-v1"} {
+		t.Run(addr, func(t *testing.T) {
+			newHost := func(ca crypto.PrivKey, serial uint64) (host.Host, *membership.Gater) {
+				priv, _, err := crypto.GenerateEd25519Key(nil)
+				require.NoError(t, err)
+				id, err := peer.IDFromPrivateKey(priv)
+				require.NoError(t, err)
+				cert, err := membership.Issue(ca, &membership.Certificate{
+					Network:   networkName,
+					Peer:      id,
+					Serial:    serial,
+					NotBefore: time.Now().Add(-time.Hour),
+					NotAfter:  time.Now().Add(time.Hour),
+				})
+				require.NoError(t, err)
+				gater, err := membership.NewGater(ca.GetPublic(), networkName, cert)
+				require.NoError(t, err)
+				h, err := New(Identity(priv), ListenAddrs(ma.StringCast(addr)), ConnectionGater(gater), DisableRelay())
+				require.NoError(t, err)
+				t.Cleanup(func() { h.Close() })
+				return h, gater
+			}
+
+			h1, gater1 := newHost(caKey, 1)
+			h2, _ := newHost(caKey, 2)
+			h3, _ := newHost(caKey, 3)
+			otherCA, _, err := crypto.GenerateEd25519Key(nil)
+			require.NoError(t, err)
+			outsider, _ := newHost(otherCA, 1)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			require.NoError(t, h2.Connect(ctx, peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+			require.NoError(t, h3.Connect(ctx, peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+			require.Error(t, outsider.Connect(ctx, peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+
+			// revoke the certificate of h3
+			rl, err := membership.IssueRevocationList(caKey, &membership.RevocationList{Network: networkName, Seq: 1, Revoked: []uint64{3}})
+			require.NoError(t, err)
+			revoked, err := gater1.UpdateRevocationList(rl)
+			require.NoError(t, err)
+			require.Equal(t, []peer.ID{h3.ID()}, revoked)
+			for _, p := range revoked {
+				require.NoError(t, h1.Network().ClosePeer(p))
+			}
+			require.Eventually(t, func() bool { return len(h3.Network().ConnsToPeer(h1.ID())) == 0 }, 5*time.Second, 10*time.Millisecond)
+			require.Error(t, h1.Connect(ctx, peer.AddrInfo{ID: h3.ID(), Addrs: h3.Addrs()}))
+			// h3 doesn't know that its certificate was revoked, so it might consider the dial successful
+			// before h1 closes the connection.
+			_ = h3.Connect(ctx, peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()})
+			require.Eventually(t, func() bool { return len(h3.Network().ConnsToPeer(h1.ID())) == 0 }, 5*time.Second, 10*time.Millisecond)
+			require.Empty(t, h1.Network().ConnsToPeer(h3.ID()))
+		})
+	}
+}
@@ -20,12 +20,15 @@ import (
 	"github.com/libp2p/go-libp2p/core/peerstore"
 	"github.com/libp2p/go-libp2p/core/pnet"
 	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/core/record"
 	"github.com/libp2p/go-libp2p/core/transport"
 	"github.com/libp2p/go-libp2p/p2p/host/autorelay"
 	bhost "github.com/libp2p/go-libp2p/p2p/host/basic"
+	"github.com/libp2p/go-libp2p/p2p/keystore"
 	"github.com/libp2p/go-libp2p/p2p/net/swarm"
 	tptu "github.com/libp2p/go-libp2p/p2p/net/upgrader"
 	relayv2 "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/relay"
+	"github.com/libp2p/go-libp2p/p2p/protocol/connectback"
 	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
 	"github.com/libp2p/go-libp2p/p2p/transport/quicreuse"
 	"github.com/prometheus/client_golang/prometheus"
@@ -210,7 +213,7 @@ func Peerstore(ps peerstore.Peerstore) Option {
 // PrivateNetwork configures libp2p to use the given private network protector.
 func PrivateNetwork(psk pnet.PSK) Option {
 	return func(cfg *Config) error {
-		if cfg.PSK != nil {
+		if cfg.IsPrivateNetwork() {
 			return fmt.Errorf("cannot specify multiple private network options")
 		}
 
@@ -219,6 +222,33 @@ func PrivateNetwork(psk pnet.PSK) Option {
 	}
 }
 
+// PrivateNetworkPSKSet configures libp2p to use a set of PSKs for the private network, allowing
+// the PSK to be rotated without restarting all nodes at once (see pnet.PSKSet).
+//
+// The default private transports (TCP and WebSocket) accept all keys of the set. QUIC is not
+// enabled by default, as it can't encrypt the handshake using the PSK (see quic.WithPSKSet). To use
+// it, pass the set explicitly:
+//
+//	libp2p.New(
+//		libp2p.PrivateNetworkPSKSet(psks),
+//		libp2p.ChainOptions(libp2p.DefaultPrivateTransports, libp2p.Transport(quic.NewTransport, quic.WithPSKSet(psks))),
+//	)
+//
+// NewNode fails if none of the keys in the set is active.
+func PrivateNetworkPSKSet(psks pnet.PSKSet) Option {
+	return func(cfg *Config) error {
+		if cfg.IsPrivateNetwork() {
+			return fmt.Errorf("cannot specify multiple private network options")
+		}
+		if len(psks) == 0 {
+			return errors.New("empty PSK set")
+		}
+
+		cfg.PSKSet = psks
+		return nil
+	}
+}
+
 // BandwidthReporter configures libp2p to use the given bandwidth reporter.
 func BandwidthReporter(rep metrics.Reporter) Option {
 	return func(cfg *Config) error {
@@ -243,6 +273,53 @@ func Identity(sk crypto.PrivKey) Option {
 	}
 }
 
+// IdentitySigner configures libp2p to identify itself using a private key held by signer.
+// This allows keeping the private key outside of the process, see crypto.NewSignerPrivKey.
+//
+// QUIC and WebTransport derive keys and certificates from the host key, and can't be used
+// with this option. They are left out of the default transports, and configuring them explicitly
+// fails. To use them, pass a secret seed: Identity(crypto.NewSignerPrivKeyWithSeed(signer, seed)).
+func IdentitySigner(signer crypto.Signer) Option {
+	return Identity(crypto.NewSignerPrivKey(signer))
+}
+
+// IdentityFromKeystore configures libp2p to use the current version of the key stored under name
+// in ks as its identity. If there is no such key, an Ed25519 key is generated and stored.
+func IdentityFromKeystore(ks *keystore.Keystore, name string) Option {
+	return func(cfg *Config) error {
+		sk, err := ks.LoadOrGenerate(name)
+		if err != nil {
+			return fmt.Errorf("failed to load identity from keystore: %w", err)
+		}
+		return Identity(sk)(cfg)
+	}
+}
+
+// KeySuccession configures the key succession records announcing that this node replaced the keys
+// of other peers (see peer.NewKeySuccession). The records are sent to other peers using identify.
+//
+// Every record must lead to this node's peer ID: either by naming it as the successor, or by
+// naming the predecessor of another record.
+func KeySuccession(records ...*record.Envelope) Option {
+	return func(cfg *Config) error {
+		cfg.KeySuccessionRecords = append(cfg.KeySuccessionRecords, records...)
+		return nil
+	}
+}
+
+// HonorKeySuccession makes the connection manager and the connection gater transfer their state
+// from a peer to its successor, when the successor announces that it replaced the peer's key.
+// See connmgr.KeySuccessionHandler for details.
+//
+// Whoever has access to a peer's key can announce a successor, so this should only be enabled if
+// the keys of trusted peers are unlikely to be compromised.
+func HonorKeySuccession() Option {
+	return func(cfg *Config) error {
+		cfg.HonorKeySuccession = true
+		return nil
+	}
+}
+
 // ConnectionManager configures libp2p to use the given connection manager.
 //
 // The current "standard" connection manager lives in github.com/libp2p/go-libp2p-connmgr. See
@@ -541,6 +618,22 @@ func EnableHolePunching(opts ...holepunch.Option) Option {
 	}
 }
 
+// Experimental
+// EnableConnectBack enables the connect-back protocol. (default: disabled)
+//
+// When a direct dial to a peer fails, but we have a relayed connection to it, we ask the peer
+// over the relayed connection to dial us on our public addresses. This allows establishing a
+// direct connection if only we are reachable, complementing hole punching.
+// We also dial back peers that ask us to do so, but only on addresses whose IP we have
+// already observed for that peer (see connectback.WithDialBackAllowlist).
+func EnableConnectBack(opts ...connectback.Option) Option {
+	return func(cfg *Config) error {
+		cfg.EnableConnectBack = true
+		cfg.ConnectBackOptions = opts
+		return nil
+	}
+}
+
 func WithDialTimeout(t time.Duration) Option {
 	return func(cfg *Config) error {
 		if t <= 0 {
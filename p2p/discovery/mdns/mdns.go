@@ -2,12 +2,18 @@ package mdns
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base32"
 	"errors"
 	"io"
 	"math/rand"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/libp2p/go-libp2p/core/discovery"
+	"github.com/libp2p/go-libp2p/core/event"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 
@@ -22,13 +28,28 @@ const (
 	ServiceName   = "_p2p._udp"
 	mdnsDomain    = "local"
 	dnsaddrPrefix = "dnsaddr="
+
+	// defaultAdvertiseTTL is the TTL of advertisements made using Advertise, if no TTL is
+	// passed using discovery.TTL.
+	defaultAdvertiseTTL = time.Hour
+	// expiryInterval is the interval in which we check for expired advertisements.
+	expiryInterval = time.Minute
 )
 
 var log = logging.Logger("mdns")
 
+var errClosed = errors.New("mdns service closed")
+
+// Service is an mDNS service.
+//
+// Besides announcing the host under the service name, and notifying the Notifee about peers
+// found under that service name, it implements the discovery.Discovery interface. Every
+// namespace is announced under a separate service name derived from the namespace. The empty
+// namespace corresponds to the service name itself.
 type Service interface {
 	Start() error
 	io.Closer
+	discovery.Discovery
 }
 
 type Notifee interface {
@@ -44,43 +65,168 @@ type mdnsService struct {
 	ctx       context.Context
 	ctxCancel context.CancelFunc
 
-	resolverWG sync.WaitGroup
-	server     *zeroconf.Server
+	refCount sync.WaitGroup
+
+	mx sync.Mutex
+	// The records we're currently announcing.
+	txts []string
+	ips  []string
+	// One server for the service name, and one for every namespace we advertise,
+	// keyed by the service name.
+	servers map[string]*zeroconf.Server
+	// The namespaces advertised using Advertise, and when the advertisements expire.
+	namespaces map[string]time.Time
+	// addrsChanged is closed (and replaced) when our addresses changed.
+	// Browsers then restart, so that they query on new interfaces.
+	addrsChanged chan struct{}
 
 	notifee Notifee
 }
 
+var _ Service = &mdnsService{}
+
+// NewMdnsService creates a new mDNS service. If serviceName is empty, ServiceName is used.
+// The notifee is notified about peers found under the service name. It may be nil, if the
+// service is only used via the discovery.Discovery interface.
 func NewMdnsService(host host.Host, serviceName string, notifee Notifee) *mdnsService {
 	if serviceName == "" {
 		serviceName = ServiceName
 	}
 	s := &mdnsService{
-		host:        host,
-		serviceName: serviceName,
-		peerName:    randomString(32 + rand.Intn(32)), // generate a random string between 32 and 63 characters long
-		notifee:     notifee,
+		host:         host,
+		serviceName:  serviceName,
+		peerName:     randomString(32 + rand.Intn(32)), // generate a random string between 32 and 63 characters long
+		servers:      make(map[string]*zeroconf.Server),
+		namespaces:   make(map[string]time.Time),
+		addrsChanged: make(chan struct{}),
+		notifee:      notifee,
 	}
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
 	return s
 }
 
 func (s *mdnsService) Start() error {
+	sub, err := s.host.EventBus().Subscribe(new(event.EvtLocalAddressesUpdated))
+	if err != nil {
+		return err
+	}
 	if err := s.startServer(); err != nil {
+		sub.Close()
 		return err
 	}
-	s.startResolver(s.ctx)
+	s.refCount.Add(1)
+	go s.background(sub)
+	if s.notifee != nil {
+		s.startResolver(s.ctx)
+	}
 	return nil
 }
 
 func (s *mdnsService) Close() error {
+	s.mx.Lock()
 	s.ctxCancel()
-	if s.server != nil {
-		s.server.Shutdown()
+	for name, server := range s.servers {
+		server.Shutdown()
+		delete(s.servers, name)
 	}
-	s.resolverWG.Wait()
+	s.mx.Unlock()
+	s.refCount.Wait()
 	return nil
 }
 
+// Advertise announces the host under the service name derived from ns.
+// The advertisement is valid until the TTL passed using discovery.TTL expires.
+func (s *mdnsService) Advertise(_ context.Context, ns string, opts ...discovery.Option) (time.Duration, error) {
+	options := discovery.Options{Ttl: defaultAdvertiseTTL}
+	if err := options.Apply(opts...); err != nil {
+		return 0, err
+	}
+	if ns == "" {
+		// We always announce the service name itself.
+		return options.Ttl, nil
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.ctx.Err() != nil {
+		return 0, errClosed
+	}
+	s.namespaces[ns] = time.Now().Add(options.Ttl)
+	// If the service wasn't started yet, the server is registered when it is.
+	service := s.serviceForNamespace(ns)
+	if _, ok := s.servers[service]; !ok && s.txts != nil {
+		server, err := s.register(service)
+		if err != nil {
+			delete(s.namespaces, ns)
+			return 0, err
+		}
+		s.servers[service] = server
+	}
+	return options.Ttl, nil
+}
+
+// FindPeers finds peers announced under the service name derived from ns.
+// The returned channel is closed when ctx is canceled, when discovery.Limit peers were found,
+// or when the service is closed.
+func (s *mdnsService) FindPeers(ctx context.Context, ns string, opts ...discovery.Option) (<-chan peer.AddrInfo, error) {
+	var options discovery.Options
+	if err := options.Apply(opts...); err != nil {
+		return nil, err
+	}
+
+	s.mx.Lock()
+	if s.ctx.Err() != nil {
+		s.mx.Unlock()
+		return nil, errClosed
+	}
+	s.refCount.Add(1)
+	s.mx.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	ch := make(chan peer.AddrInfo, 32)
+	go func() {
+		defer s.refCount.Done()
+		defer close(ch)
+		defer cancel()
+
+		go func() {
+			select {
+			case <-s.ctx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		found := make(map[peer.ID]struct{})
+		s.browse(ctx, s.serviceForNamespace(ns), func(info peer.AddrInfo) {
+			if _, ok := found[info.ID]; ok {
+				return
+			}
+			found[info.ID] = struct{}{}
+			select {
+			case ch <- info:
+			case <-ctx.Done():
+				return
+			}
+			if options.Limit > 0 && len(found) >= options.Limit {
+				cancel()
+			}
+		})
+	}()
+	return ch, nil
+}
+
+// serviceForNamespace returns the service name used for namespace ns.
+// Namespaces can contain arbitrary characters, so we use a hash of the namespace.
+func (s *mdnsService) serviceForNamespace(ns string) string {
+	if ns == "" {
+		return s.serviceName
+	}
+	h := sha256.Sum256([]byte(s.serviceName + "/" + ns))
+	label := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(h[:8]))
+	return "_" + label + "._udp"
+}
+
 // We don't really care about the IP addresses, but the spec (and various routers / firewalls) require us
 // to send A and AAAA records.
 func (s *mdnsService) getIPs(addrs []ma.Multiaddr) ([]string, error) {
@@ -109,87 +255,247 @@ func (s *mdnsService) getIPs(addrs []ma.Multiaddr) ([]string, error) {
 	return ips, nil
 }
 
-func (s *mdnsService) startServer() error {
+// getRecords returns the TXT records and the IP addresses that we announce.
+func (s *mdnsService) getRecords() (txts []string, ips []string, err error) {
 	interfaceAddrs, err := s.host.Network().InterfaceListenAddresses()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{
 		ID:    s.host.ID(),
 		Addrs: interfaceAddrs,
 	})
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	var txts []string
 	for _, addr := range addrs {
 		if manet.IsThinWaist(addr) { // don't announce circuit addresses
 			txts = append(txts, dnsaddrPrefix+addr.String())
 		}
 	}
+	sort.Strings(txts)
 
-	ips, err := s.getIPs(addrs)
+	ips, err = s.getIPs(addrs)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	return txts, ips, nil
+}
 
-	server, err := zeroconf.RegisterProxy(
+// register registers a server for the service name, announcing the current records.
+// It must be called with s.mx held.
+func (s *mdnsService) register(service string) (*zeroconf.Server, error) {
+	return zeroconf.RegisterProxy(
 		s.peerName,
-		s.serviceName,
+		service,
 		mdnsDomain,
 		4001, // we have to pass in a port number here, but libp2p only uses the TXT records
 		s.peerName,
-		ips,
-		txts,
+		s.ips,
+		s.txts,
 		nil,
 	)
+}
+
+func (s *mdnsService) startServer() error {
+	txts, ips, err := s.getRecords()
 	if err != nil {
 		return err
 	}
-	s.server = server
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.txts, s.ips = txts, ips
+	services := []string{s.serviceName}
+	for ns := range s.namespaces {
+		services = append(services, s.serviceForNamespace(ns))
+	}
+	for _, service := range services {
+		server, err := s.register(service)
+		if err != nil {
+			for _, server := range s.servers {
+				server.Shutdown()
+			}
+			s.servers = make(map[string]*zeroconf.Server)
+			return err
+		}
+		s.servers[service] = server
+	}
 	return nil
 }
 
-func (s *mdnsService) startResolver(ctx context.Context) {
-	s.resolverWG.Add(2)
-	entryChan := make(chan *zeroconf.ServiceEntry, 1000)
-	go func() {
-		defer s.resolverWG.Done()
-		for entry := range entryChan {
-			// We only care about the TXT records.
-			// Ignore A, AAAA and PTR.
-			addrs := make([]ma.Multiaddr, 0, len(entry.Text)) // assume that all TXT records are dnsaddrs
-			for _, s := range entry.Text {
-				if !strings.HasPrefix(s, dnsaddrPrefix) {
-					log.Debug("missing dnsaddr prefix")
-					continue
-				}
-				addr, err := ma.NewMultiaddr(s[len(dnsaddrPrefix):])
-				if err != nil {
-					log.Debugf("failed to parse multiaddr: %s", err)
-					continue
-				}
-				addrs = append(addrs, addr)
-			}
-			infos, err := peer.AddrInfosFromP2pAddrs(addrs...)
+// updateRecords re-registers all servers if our addresses changed.
+// It returns true if the records changed.
+func (s *mdnsService) updateRecords() (bool, error) {
+	txts, ips, err := s.getRecords()
+	if err != nil {
+		return false, err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.ctx.Err() != nil {
+		return false, errClosed
+	}
+	if equalStrings(txts, s.txts) && equalStrings(ips, s.ips) {
+		return false, nil
+	}
+	s.txts, s.ips = txts, ips
+	// zeroconf clients only report a service instance once, until its records expire.
+	// Use a new instance name, so that the new records are reported immediately.
+	s.peerName = randomString(32 + rand.Intn(32))
+	for service, server := range s.servers {
+		server.Shutdown()
+		delete(s.servers, service)
+		server, err := s.register(service)
+		if err != nil {
+			log.Warnw("failed to register mDNS service", "service", service, "error", err)
+			continue
+		}
+		s.servers[service] = server
+	}
+	close(s.addrsChanged)
+	s.addrsChanged = make(chan struct{})
+	return true, nil
+}
+
+// expireNamespaces removes the servers for namespaces whose advertisement expired.
+func (s *mdnsService) expireNamespaces(now time.Time) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for ns, expiry := range s.namespaces {
+		if expiry.After(now) {
+			continue
+		}
+		delete(s.namespaces, ns)
+		service := s.serviceForNamespace(ns)
+		if server, ok := s.servers[service]; ok {
+			server.Shutdown()
+			delete(s.servers, service)
+		}
+	}
+}
+
+func (s *mdnsService) background(sub event.Subscription) {
+	defer s.refCount.Done()
+	defer sub.Close()
+
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sub.Out():
+			changed, err := s.updateRecords()
 			if err != nil {
-				log.Debugf("failed to get peer info: %s", err)
+				log.Debugw("failed to update mDNS records", "error", err)
 				continue
 			}
-			for _, info := range infos {
-				if info.ID == s.host.ID() {
-					continue
-				}
-				go s.notifee.HandlePeerFound(info)
+			if changed {
+				log.Debug("addresses changed, updated mDNS records")
 			}
+		case now := <-ticker.C:
+			s.expireNamespaces(now)
+		case <-s.ctx.Done():
+			return
 		}
+	}
+}
+
+func (s *mdnsService) startResolver(ctx context.Context) {
+	s.refCount.Add(1)
+	go func() {
+		defer s.refCount.Done()
+		s.browse(ctx, s.serviceName, func(info peer.AddrInfo) {
+			go s.notifee.HandlePeerFound(info)
+		})
 	}()
+}
+
+// browse browses for peers announced under service, until ctx is canceled.
+// It restarts browsing when our addresses change, so that queries are sent on new interfaces.
+// handle is called sequentially for every peer found.
+func (s *mdnsService) browse(ctx context.Context, service string, handle func(peer.AddrInfo)) {
+	for {
+		s.mx.Lock()
+		addrsChanged := s.addrsChanged
+		s.mx.Unlock()
+
+		bctx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-addrsChanged:
+				cancel()
+			case <-bctx.Done():
+			}
+		}()
+		s.browseOnce(bctx, service, handle)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (s *mdnsService) browseOnce(ctx context.Context, service string, handle func(peer.AddrInfo)) {
+	entryChan := make(chan *zeroconf.ServiceEntry, 1000)
+	done := make(chan struct{})
 	go func() {
-		defer s.resolverWG.Done()
-		if err := zeroconf.Browse(ctx, s.serviceName, mdnsDomain, entryChan); err != nil {
+		defer close(done)
+		if err := zeroconf.Browse(ctx, service, mdnsDomain, entryChan); err != nil {
 			log.Debugf("zeroconf browsing failed: %s", err)
 		}
 	}()
+	for entry := range entryChan {
+		for _, info := range s.peersFromEntry(entry) {
+			handle(info)
+		}
+	}
+	<-done
+}
+
+func (s *mdnsService) peersFromEntry(entry *zeroconf.ServiceEntry) []peer.AddrInfo {
+	// We only care about the TXT records.
+	// Ignore A, AAAA and PTR.
+	addrs := make([]ma.Multiaddr, 0, len(entry.Text)) // assume that all TXT records are dnsaddrs
+	for _, s := range entry.Text {
+		if !strings.HasPrefix(s, dnsaddrPrefix) {
+			log.Debug("missing dnsaddr prefix")
+			continue
+		}
+		addr, err := ma.NewMultiaddr(s[len(dnsaddrPrefix):])
+		if err != nil {
+			log.Debugf("failed to parse multiaddr: %s", err)
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	infos, err := peer.AddrInfosFromP2pAddrs(addrs...)
+	if err != nil {
+		log.Debugf("failed to get peer info: %s", err)
+		return nil
+	}
+	peers := infos[:0]
+	for _, info := range infos {
+		if info.ID == s.host.ID() {
+			continue
+		}
+		peers = append(peers, info)
+	}
+	return peers
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 func randomString(l int) string {
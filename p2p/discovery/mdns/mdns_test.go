@@ -1,13 +1,17 @@
 package mdns
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/discovery"
+	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -96,3 +100,82 @@ func TestOtherDiscovery(t *testing.T) {
 		"expected peers to find each other",
 	)
 }
+
+func setupMDNSService(t *testing.T, notifee Notifee) (host.Host, Service) {
+	t.Helper()
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	s := NewMdnsService(h, "", notifee)
+	require.NoError(t, s.Start())
+	t.Cleanup(func() {
+		h.Close()
+		s.Close()
+	})
+	return h, s
+}
+
+func TestDiscovery(t *testing.T) {
+	h1, s1 := setupMDNSService(t, nil)
+	h2, s2 := setupMDNSService(t, nil)
+	_, s3 := setupMDNSService(t, nil)
+
+	ttl, err := s1.Advertise(context.Background(), "foo", discovery.TTL(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, ttl)
+	_, err = s2.Advertise(context.Background(), "bar")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+	defer cancel()
+	peerChan, err := s3.FindPeers(ctx, "foo")
+	require.NoError(t, err)
+	select {
+	case info := <-peerChan:
+		require.Equal(t, h1.ID(), info.ID)
+		require.ElementsMatch(t, h1.Addrs(), info.Addrs)
+	case <-ctx.Done():
+		t.Fatal("didn't find peer")
+	}
+
+	// All peers are announced under the service name, regardless of namespaces.
+	peerChan, err = s3.FindPeers(ctx, "", discovery.Limit(2))
+	require.NoError(t, err)
+	var found []peer.ID
+	for info := range peerChan {
+		found = append(found, info.ID)
+	}
+	require.ElementsMatch(t, []peer.ID{h1.ID(), h2.ID()}, found)
+
+	require.NoError(t, s3.Close())
+	_, err = s3.FindPeers(ctx, "foo")
+	require.Error(t, err)
+}
+
+func TestAddressUpdates(t *testing.T) {
+	h1, _ := setupMDNSService(t, nil)
+	notif := &notif{}
+	setupMDNSService(t, notif)
+
+	require.NoError(t, h1.Network().Listen(ma.StringCast("/ip4/127.0.0.1/udp/0/quic-v1")))
+	var quicAddr ma.Multiaddr
+	for _, a := range h1.Network().ListenAddresses() {
+		if _, err := a.ValueForProtocol(ma.P_QUIC_V1); err == nil {
+			quicAddr = a
+		}
+	}
+	require.NotNil(t, quicAddr)
+
+	require.Eventually(t, func() bool {
+		for _, info := range notif.GetPeers() {
+			if info.ID != h1.ID() {
+				continue
+			}
+			for _, a := range info.Addrs {
+				if a.Equal(quicAddr) {
+					return true
+				}
+			}
+		}
+		return false
+	}, 25*time.Second, 50*time.Millisecond)
+}
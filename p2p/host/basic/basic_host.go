@@ -24,6 +24,7 @@ import (
 	"github.com/libp2p/go-libp2p/p2p/host/pstoremanager"
 	"github.com/libp2p/go-libp2p/p2p/host/relaysvc"
 	relayv2 "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/relay"
+	"github.com/libp2p/go-libp2p/p2p/protocol/connectback"
 	"github.com/libp2p/go-libp2p/p2p/protocol/holepunch"
 	"github.com/libp2p/go-libp2p/p2p/protocol/identify"
 	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
@@ -74,6 +75,7 @@ type BasicHost struct {
 	mux          *msmux.MultistreamMuxer[protocol.ID]
 	ids          identify.IDService
 	hps          *holepunch.Service
+	cbs          *connectback.Service
 	pings        *ping.PingService
 	natmgr       NATManager
 	maResolver   *madns.Resolver
@@ -101,6 +103,9 @@ type BasicHost struct {
 	caBook                  peerstore.CertifiedAddrBook
 
 	autoNat autonat.AutoNAT
+
+	keySuccessionHandlers []connmgr.KeySuccessionHandler
+	keySuccessionSub      event.Subscription
 }
 
 var _ host.Host = (*BasicHost)(nil)
@@ -156,6 +161,16 @@ type HostOpts struct {
 	// HolePunchingOptions are options for the hole punching service
 	HolePunchingOptions []holepunch.Option
 
+	// EnableConnectBack enables the peer to ask peers it's connected to via a relay to dial it,
+	// and to respond to such requests.
+	EnableConnectBack bool
+	// ConnectBackOptions are options for the connect-back service
+	ConnectBackOptions []connectback.Option
+
+	// KeySuccessionHandlers are notified when a peer replaced its key, and announced its
+	// successor using a key succession record.
+	KeySuccessionHandlers []connmgr.KeySuccessionHandler
+
 	// EnableMetrics enables the metrics subsystems
 	EnableMetrics bool
 	// PrometheusRegisterer is the PrometheusRegisterer used for metrics
@@ -189,6 +204,7 @@ func NewHost(n network.Network, opts *HostOpts) (*BasicHost, error) {
 		ctx:                     hostCtx,
 		ctxCancel:               cancel,
 		disableSignedPeerRecord: opts.DisableSignedPeerRecord,
+		keySuccessionHandlers:   opts.KeySuccessionHandlers,
 	}
 
 	h.updateLocalIpAddr()
@@ -199,6 +215,12 @@ func NewHost(n network.Network, opts *HostOpts) (*BasicHost, error) {
 	if h.emitters.evtLocalAddrsUpdated, err = h.eventbus.Emitter(&event.EvtLocalAddressesUpdated{}, eventbus.Stateful); err != nil {
 		return nil, err
 	}
+	if len(h.keySuccessionHandlers) > 0 {
+		h.keySuccessionSub, err = h.eventbus.Subscribe(new(event.EvtPeerKeySuccession), eventbus.Name("key succession"))
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if !h.disableSignedPeerRecord {
 		cab, ok := peerstore.GetCertifiedAddrBook(n.Peerstore())
@@ -263,6 +285,13 @@ func NewHost(n network.Network, opts *HostOpts) (*BasicHost, error) {
 		}
 	}
 
+	if opts.EnableConnectBack {
+		h.cbs, err = connectback.NewService(h, opts.ConnectBackOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create connect-back service: %w", err)
+		}
+	}
+
 	if uint64(opts.NegotiationTimeout) != 0 {
 		h.negtimeout = opts.NegotiationTimeout
 	}
@@ -390,6 +419,22 @@ func (h *BasicHost) Start() {
 	h.refCount.Add(1)
 	h.ids.Start()
 	go h.background()
+	if h.keySuccessionSub != nil {
+		h.refCount.Add(1)
+		go h.handleKeySuccessions()
+	}
+}
+
+// handleKeySuccessions notifies the key succession handlers when a peer announced its successor.
+func (h *BasicHost) handleKeySuccessions() {
+	defer h.refCount.Done()
+	for e := range h.keySuccessionSub.Out() {
+		evt := e.(event.EvtPeerKeySuccession)
+		log.Debugw("peer replaced its key", "peer", evt.Peer, "successor", evt.Successor)
+		for _, handler := range h.keySuccessionHandlers {
+			handler.PeerSucceeded(evt.Peer, evt.Successor)
+		}
+	}
 }
 
 // newStreamHandler is the remote-opened stream handler for network.Network
@@ -1025,9 +1070,15 @@ func (h *BasicHost) Close() error {
 		if h.hps != nil {
 			h.hps.Close()
 		}
+		if h.cbs != nil {
+			h.cbs.Close()
+		}
 
 		_ = h.emitters.evtLocalProtocolsUpdated.Close()
 		_ = h.emitters.evtLocalAddrsUpdated.Close()
+		if h.keySuccessionSub != nil {
+			h.keySuccessionSub.Close()
+		}
 		h.Network().Close()
 
 		h.psManager.Close()
@@ -1062,6 +1113,14 @@ func (s *streamWrapper) Close() error {
 	return s.rw.Close()
 }
 
+func (s *streamWrapper) SetPriority(p network.StreamPriority) error {
+	ps, ok := s.Stream.(network.PrioritizedStream)
+	if !ok {
+		return network.ErrPriorityNotSupported
+	}
+	return ps.SetPriority(p)
+}
+
 func (s *streamWrapper) CloseWrite() error {
 	// Flush the handshake before closing, but ignore the error. The other
 	// end may have closed their side for reading.
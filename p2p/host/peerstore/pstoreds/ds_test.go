@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
 	pstore "github.com/libp2p/go-libp2p/core/peerstore"
 	pt "github.com/libp2p/go-libp2p/p2p/host/peerstore/test"
 
@@ -75,6 +77,37 @@ func TestDsKeyBook(t *testing.T) {
 	}
 }
 
+type testSigner struct {
+	key crypto.PrivKey
+}
+
+func (s *testSigner) Sign(data []byte) ([]byte, error) { return s.key.Sign(data) }
+func (s *testSigner) GetPublic() crypto.PubKey         { return s.key.GetPublic() }
+
+func TestDsKeyBookSignerKey(t *testing.T) {
+	store, closeStore := leveldbStore(t)
+	defer closeStore()
+	kb, err := NewKeyBook(context.Background(), store, DefaultOpts())
+	require.NoError(t, err)
+
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	sk := crypto.NewSignerPrivKey(&testSigner{key: priv})
+	id, err := peer.IDFromPrivateKey(sk)
+	require.NoError(t, err)
+
+	// The key is kept in memory, it is not written to the datastore.
+	require.NoError(t, kb.AddPrivKey(id, sk))
+	require.Equal(t, sk, kb.PrivKey(id))
+	require.Contains(t, kb.PeersWithKeys(), id)
+	_, err = store.Get(context.Background(), peerToKey(id, privSuffix))
+	require.ErrorIs(t, err, ds.ErrNotFound)
+
+	kb.RemovePeer(id)
+	require.Nil(t, kb.PrivKey(id))
+	require.NotContains(t, kb.PeersWithKeys(), id)
+}
+
 func BenchmarkDsKeyBook(b *testing.B) {
 	for name, dsFactory := range dstores {
 		b.Run(name, func(b *testing.B) {
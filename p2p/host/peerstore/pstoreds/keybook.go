@@ -3,6 +3,7 @@ package pstoreds
 import (
 	"context"
 	"errors"
+	"sync"
 
 	ic "github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -23,12 +24,16 @@ var (
 
 type dsKeyBook struct {
 	ds ds.Datastore
+
+	// Private keys that can't be exported (see crypto.NewSignerPrivKey) are kept in memory.
+	mx          sync.RWMutex
+	signingKeys map[peer.ID]ic.PrivKey
 }
 
 var _ pstore.KeyBook = (*dsKeyBook)(nil)
 
 func NewKeyBook(_ context.Context, store ds.Datastore, _ Options) (*dsKeyBook, error) {
-	return &dsKeyBook{store}, nil
+	return &dsKeyBook{ds: store, signingKeys: make(map[peer.ID]ic.PrivKey)}, nil
 }
 
 func (kb *dsKeyBook) PubKey(p peer.ID) ic.PubKey {
@@ -85,11 +90,18 @@ func (kb *dsKeyBook) AddPubKey(p peer.ID, pk ic.PubKey) error {
 }
 
 func (kb *dsKeyBook) PrivKey(p peer.ID) ic.PrivKey {
+	kb.mx.RLock()
+	sk, ok := kb.signingKeys[p]
+	kb.mx.RUnlock()
+	if ok {
+		return sk
+	}
+
 	value, err := kb.ds.Get(context.TODO(), peerToKey(p, privSuffix))
 	if err != nil {
 		return nil
 	}
-	sk, err := ic.UnmarshalPrivateKey(value)
+	sk, err = ic.UnmarshalPrivateKey(value)
 	if err != nil {
 		return nil
 	}
@@ -106,6 +118,12 @@ func (kb *dsKeyBook) AddPrivKey(p peer.ID, sk ic.PrivKey) error {
 	}
 
 	val, err := ic.MarshalPrivateKey(sk)
+	if errors.Is(err, ic.ErrKeyNotExportable) {
+		kb.mx.Lock()
+		kb.signingKeys[p] = sk
+		kb.mx.Unlock()
+		return nil
+	}
 	if err != nil {
 		log.Errorf("error while converting privkey byte string for peer %s: %s\n", p.Pretty(), err)
 		return err
@@ -123,10 +141,25 @@ func (kb *dsKeyBook) PeersWithKeys() peer.IDSlice {
 	if err != nil {
 		log.Errorf("error while retrieving peers with keys: %v", err)
 	}
+	kb.mx.RLock()
+	defer kb.mx.RUnlock()
+	for p := range kb.signingKeys {
+		if !containsPeer(ids, p) {
+			ids = append(ids, p)
+		}
+	}
 	return ids
 }
 
+// RemovePeer removes the keys of p, as well as the key succession records of p and its predecessors.
 func (kb *dsKeyBook) RemovePeer(p peer.ID) {
+	kb.mx.Lock()
+	delete(kb.signingKeys, p)
+	kb.removeSuccession(p)
+	for _, pred := range kb.predecessors(p) {
+		kb.removeSuccession(pred)
+	}
+	kb.mx.Unlock()
 	kb.ds.Delete(context.TODO(), peerToKey(p, privSuffix))
 	kb.ds.Delete(context.TODO(), peerToKey(p, pubSuffix))
 }
@@ -134,3 +167,12 @@ func (kb *dsKeyBook) RemovePeer(p peer.ID) {
 func peerToKey(p peer.ID, suffix ds.Key) ds.Key {
 	return kbBase.ChildString(base32.RawStdEncoding.EncodeToString([]byte(p))).Child(suffix)
 }
+
+func containsPeer(ids peer.IDSlice, p peer.ID) bool {
+	for _, id := range ids {
+		if id == p {
+			return true
+		}
+	}
+	return false
+}
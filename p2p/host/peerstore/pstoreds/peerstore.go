@@ -65,6 +65,7 @@ type pstoreds struct {
 	*dsAddrBook
 	*dsProtoBook
 	*dsPeerMetadata
+	*dsSignedRecordBook
 }
 
 var _ peerstore.Peerstore = &pstoreds{}
@@ -93,12 +94,18 @@ func NewPeerstore(ctx context.Context, store ds.Batching, opts Options) (*pstore
 		return nil, err
 	}
 
+	signedRecordBook, err := NewSignedRecordBook(ctx, store, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	return &pstoreds{
-		Metrics:        pstore.NewMetrics(),
-		dsKeyBook:      keyBook,
-		dsAddrBook:     addrBook,
-		dsPeerMetadata: peerMetadata,
-		dsProtoBook:    protoBook,
+		Metrics:            pstore.NewMetrics(),
+		dsKeyBook:          keyBook,
+		dsAddrBook:         addrBook,
+		dsPeerMetadata:     peerMetadata,
+		dsProtoBook:        protoBook,
+		dsSignedRecordBook: signedRecordBook,
 	}, nil
 }
 
@@ -183,11 +190,13 @@ func (ps *pstoreds) PeerInfo(p peer.ID) peer.AddrInfo {
 // * the KeyBook
 // * the ProtoBook
 // * the PeerMetadata
+// * the SignedRecordBook
 // * the Metrics
 // It DOES NOT remove the peer from the AddrBook.
 func (ps *pstoreds) RemovePeer(p peer.ID) {
 	ps.dsKeyBook.RemovePeer(p)
 	ps.dsProtoBook.RemovePeer(p)
 	ps.dsPeerMetadata.RemovePeer(p)
+	ps.dsSignedRecordBook.RemovePeer(p)
 	ps.Metrics.RemovePeer(p)
 }
@@ -0,0 +1,143 @@
+package pstoreds
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	pstore "github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/record"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/multiformats/go-base32"
+)
+
+// Signed records are stored under the following db key pattern:
+// /peers/records/<b32 peer id no padding>/<b32 domain no padding>
+var srbBase = ds.NewKey("/peers/records")
+
+type dsSignedRecordBook struct {
+	ds ds.Datastore
+	mx sync.RWMutex
+}
+
+var _ pstore.SignedRecordBook = (*dsSignedRecordBook)(nil)
+
+func NewSignedRecordBook(_ context.Context, store ds.Datastore, _ Options) (*dsSignedRecordBook, error) {
+	return &dsSignedRecordBook{ds: store}, nil
+}
+
+func (srb *dsSignedRecordBook) ConsumeSignedRecord(env *record.Envelope) (accepted bool, err error) {
+	r, err := env.Record()
+	if err != nil {
+		return false, err
+	}
+	rec, ok := r.(record.SequencedRecord)
+	if !ok {
+		return false, errors.New("record doesn't have a sequence number")
+	}
+	p, err := peer.IDFromPublicKey(env.PublicKey)
+	if err != nil {
+		return false, err
+	}
+	b, err := env.Marshal()
+	if err != nil {
+		return false, err
+	}
+
+	srb.mx.Lock()
+	defer srb.mx.Unlock()
+	key := signedRecordKey(p, rec.Domain())
+	if _, existing, ok := srb.load(key); ok && existing.SequenceNumber() >= rec.SequenceNumber() {
+		return false, nil
+	}
+	if err := srb.ds.Put(context.TODO(), key, b); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (srb *dsSignedRecordBook) GetSignedRecord(p peer.ID, domain string) *record.Envelope {
+	srb.mx.RLock()
+	defer srb.mx.RUnlock()
+	env, _, _ := srb.load(signedRecordKey(p, domain))
+	return env
+}
+
+func (srb *dsSignedRecordBook) SignedRecords(p peer.ID) []*record.Envelope {
+	srb.mx.RLock()
+	defer srb.mx.RUnlock()
+
+	results, err := srb.ds.Query(context.TODO(), query.Query{Prefix: signedRecordPrefix(p).String(), KeysOnly: true})
+	if err != nil {
+		log.Errorf("error while querying signed records of peer %s: %s", p, err)
+		return nil
+	}
+	defer results.Close()
+
+	var envs []*record.Envelope
+	for result := range results.Next() {
+		if result.Error != nil {
+			log.Errorf("error while querying signed records of peer %s: %s", p, result.Error)
+			return envs
+		}
+		if env, _, ok := srb.load(ds.RawKey(result.Key)); ok {
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
+
+func (srb *dsSignedRecordBook) RemovePeer(p peer.ID) {
+	srb.mx.Lock()
+	defer srb.mx.Unlock()
+
+	results, err := srb.ds.Query(context.TODO(), query.Query{Prefix: signedRecordPrefix(p).String(), KeysOnly: true})
+	if err != nil {
+		log.Errorf("error while querying signed records of peer %s: %s", p, err)
+		return
+	}
+	var keys []ds.Key
+	for result := range results.Next() {
+		if result.Error != nil {
+			log.Errorf("error while querying signed records of peer %s: %s", p, result.Error)
+			break
+		}
+		keys = append(keys, ds.RawKey(result.Key))
+	}
+	results.Close()
+	for _, key := range keys {
+		srb.ds.Delete(context.TODO(), key)
+	}
+}
+
+// load loads and validates the record stored under key. The lock must be held.
+func (srb *dsSignedRecordBook) load(key ds.Key) (*record.Envelope, record.SequencedRecord, bool) {
+	b, err := srb.ds.Get(context.TODO(), key)
+	if err != nil {
+		if err != ds.ErrNotFound {
+			log.Errorf("error while loading signed record %s: %s", key, err)
+		}
+		return nil, nil, false
+	}
+	env, r, err := record.ConsumeRegisteredEnvelope(b)
+	if err != nil {
+		log.Errorf("invalid signed record %s in datastore: %s", key, err)
+		return nil, nil, false
+	}
+	rec, ok := r.(record.SequencedRecord)
+	if !ok {
+		return nil, nil, false
+	}
+	return env, rec, true
+}
+
+func signedRecordPrefix(p peer.ID) ds.Key {
+	return srbBase.ChildString(base32.RawStdEncoding.EncodeToString([]byte(p)))
+}
+
+func signedRecordKey(p peer.ID, domain string) ds.Key {
+	return signedRecordPrefix(p).ChildString(base32.RawStdEncoding.EncodeToString([]byte(domain)))
+}
@@ -0,0 +1,171 @@
+package pstoreds
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	pstore "github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/record"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/multiformats/go-base32"
+)
+
+// Key succession records are stored under the following db key pattern:
+// /peers/succession/<b32 peer id no padding>
+// For every successor, its predecessors are indexed under:
+// /peers/successors/<b32 successor peer id no padding>/<b32 peer id no padding>
+var (
+	succBase = ds.NewKey("/peers/succession")
+	predBase = ds.NewKey("/peers/successors")
+)
+
+var _ pstore.KeySuccessionBook = (*dsKeyBook)(nil)
+
+func (kb *dsKeyBook) ConsumeKeySuccession(env *record.Envelope) (accepted bool, err error) {
+	rec, err := peer.VerifyKeySuccession(env)
+	if err != nil {
+		return false, err
+	}
+	successor, err := rec.SuccessorID()
+	if err != nil {
+		return false, err
+	}
+	b, err := env.Marshal()
+	if err != nil {
+		return false, err
+	}
+
+	kb.mx.Lock()
+	defer kb.mx.Unlock()
+	if existing, ok := kb.getSuccession(rec.PeerID); ok {
+		s, err := existing.SuccessorID()
+		if err != nil || s != successor || existing.Seq >= rec.Seq {
+			return false, nil
+		}
+	}
+	if err := kb.ds.Put(context.TODO(), predKey(successor, rec.PeerID), []byte{}); err != nil {
+		return false, err
+	}
+	if err := kb.ds.Put(context.TODO(), succKey(rec.PeerID), b); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (kb *dsKeyBook) Successor(p peer.ID) (peer.ID, bool) {
+	kb.mx.RLock()
+	defer kb.mx.RUnlock()
+	rec, ok := kb.getSuccession(p)
+	if !ok {
+		return "", false
+	}
+	successor, err := rec.SuccessorID()
+	if err != nil {
+		return "", false
+	}
+	return successor, true
+}
+
+func (kb *dsKeyBook) KeySuccessionRecords(p peer.ID) []*record.Envelope {
+	kb.mx.RLock()
+	defer kb.mx.RUnlock()
+
+	var envs []*record.Envelope
+	// Walk the predecessors breadth-first. Peers can succeed each other, so we need to
+	// remember which peers we already visited.
+	visited := map[peer.ID]struct{}{p: {}}
+	queue := []peer.ID{p}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, pred := range kb.predecessors(next) {
+			if _, ok := visited[pred]; ok {
+				continue
+			}
+			visited[pred] = struct{}{}
+			b, err := kb.ds.Get(context.TODO(), succKey(pred))
+			if err != nil {
+				log.Errorf("error while loading key succession record for peer %s: %s", pred, err)
+				continue
+			}
+			env, _, err := peer.ConsumeKeySuccession(b)
+			if err != nil {
+				log.Errorf("invalid key succession record for peer %s in datastore: %s", pred, err)
+				continue
+			}
+			envs = append(envs, env)
+			queue = append(queue, pred)
+		}
+	}
+	return envs
+}
+
+// getSuccession loads the key succession record of p. The lock must be held.
+func (kb *dsKeyBook) getSuccession(p peer.ID) (*peer.KeySuccessionRecord, bool) {
+	b, err := kb.ds.Get(context.TODO(), succKey(p))
+	if err != nil {
+		if err != ds.ErrNotFound {
+			log.Errorf("error while loading key succession record for peer %s: %s", p, err)
+		}
+		return nil, false
+	}
+	_, rec, err := peer.ConsumeKeySuccession(b)
+	if err != nil {
+		log.Errorf("invalid key succession record for peer %s in datastore: %s", p, err)
+		return nil, false
+	}
+	return rec, true
+}
+
+// predecessors returns the peers that announced p as their successor.
+func (kb *dsKeyBook) predecessors(p peer.ID) []peer.ID {
+	prefix := predBase.ChildString(base32.RawStdEncoding.EncodeToString([]byte(p)))
+	results, err := kb.ds.Query(context.TODO(), query.Query{Prefix: prefix.String(), KeysOnly: true})
+	if err != nil {
+		log.Errorf("error while querying predecessors of peer %s: %s", p, err)
+		return nil
+	}
+	defer results.Close()
+
+	var preds []peer.ID
+	for result := range results.Next() {
+		if result.Error != nil {
+			log.Errorf("error while querying predecessors of peer %s: %s", p, result.Error)
+			return preds
+		}
+		b, err := base32.RawStdEncoding.DecodeString(ds.RawKey(result.Key).Name())
+		if err != nil {
+			continue
+		}
+		pred, err := peer.IDFromBytes(b)
+		if err != nil {
+			continue
+		}
+		preds = append(preds, pred)
+	}
+	return preds
+}
+
+// removeSuccession removes the key succession record of p. The lock must be held.
+func (kb *dsKeyBook) removeSuccession(p peer.ID) {
+	rec, ok := kb.getSuccession(p)
+	if !ok {
+		return
+	}
+	if successor, err := rec.SuccessorID(); err == nil {
+		kb.ds.Delete(context.TODO(), predKey(successor, p))
+	}
+	kb.ds.Delete(context.TODO(), succKey(p))
+}
+
+func succKey(p peer.ID) ds.Key {
+	return succBase.ChildString(base32.RawStdEncoding.EncodeToString([]byte(p)))
+}
+
+func predKey(successor, p peer.ID) ds.Key {
+	return predBase.
+		ChildString(base32.RawStdEncoding.EncodeToString([]byte(successor))).
+		ChildString(base32.RawStdEncoding.EncodeToString([]byte(p)))
+}
@@ -13,14 +13,24 @@ type memoryKeyBook struct {
 	sync.RWMutex // same lock. wont happen a ton.
 	pks          map[peer.ID]ic.PubKey
 	sks          map[peer.ID]ic.PrivKey
+
+	// key succession records, indexed by the peer being replaced
+	successions map[peer.ID]succession
+	// predecessors of every successor
+	predecessors map[peer.ID]map[peer.ID]struct{}
 }
 
-var _ pstore.KeyBook = (*memoryKeyBook)(nil)
+var (
+	_ pstore.KeyBook           = (*memoryKeyBook)(nil)
+	_ pstore.KeySuccessionBook = (*memoryKeyBook)(nil)
+)
 
 func NewKeyBook() *memoryKeyBook {
 	return &memoryKeyBook{
-		pks: map[peer.ID]ic.PubKey{},
-		sks: map[peer.ID]ic.PrivKey{},
+		pks:          map[peer.ID]ic.PubKey{},
+		sks:          map[peer.ID]ic.PrivKey{},
+		successions:  map[peer.ID]succession{},
+		predecessors: map[peer.ID]map[peer.ID]struct{}{},
 	}
 }
 
@@ -89,9 +99,14 @@ func (mkb *memoryKeyBook) AddPrivKey(p peer.ID, sk ic.PrivKey) error {
 	return nil
 }
 
+// RemovePeer removes the keys of p, as well as the key succession records of p and its predecessors.
 func (mkb *memoryKeyBook) RemovePeer(p peer.ID) {
 	mkb.Lock()
 	delete(mkb.sks, p)
 	delete(mkb.pks, p)
+	mkb.removeSuccession(p)
+	for pred := range mkb.predecessors[p] {
+		mkb.removeSuccession(pred)
+	}
 	mkb.Unlock()
 }
@@ -16,6 +16,7 @@ type pstoremem struct {
 	*memoryAddrBook
 	*memoryProtoBook
 	*memoryPeerMetadata
+	*memorySignedRecordBook
 }
 
 var _ peerstore.Peerstore = &pstoremem{}
@@ -49,11 +50,12 @@ func NewPeerstore(opts ...Option) (ps *pstoremem, err error) {
 		return nil, err
 	}
 	return &pstoremem{
-		Metrics:            pstore.NewMetrics(),
-		memoryKeyBook:      NewKeyBook(),
-		memoryAddrBook:     ab,
-		memoryProtoBook:    pb,
-		memoryPeerMetadata: NewPeerMetadata(),
+		Metrics:                pstore.NewMetrics(),
+		memoryKeyBook:          NewKeyBook(),
+		memoryAddrBook:         ab,
+		memoryProtoBook:        pb,
+		memoryPeerMetadata:     NewPeerMetadata(),
+		memorySignedRecordBook: NewSignedRecordBook(),
 	}, nil
 }
 
@@ -104,11 +106,13 @@ func (ps *pstoremem) PeerInfo(p peer.ID) peer.AddrInfo {
 // * the KeyBook
 // * the ProtoBook
 // * the PeerMetadata
+// * the SignedRecordBook
 // * the Metrics
 // It DOES NOT remove the peer from the AddrBook.
 func (ps *pstoremem) RemovePeer(p peer.ID) {
 	ps.memoryKeyBook.RemovePeer(p)
 	ps.memoryProtoBook.RemovePeer(p)
 	ps.memoryPeerMetadata.RemovePeer(p)
+	ps.memorySignedRecordBook.RemovePeer(p)
 	ps.Metrics.RemovePeer(p)
 }
@@ -0,0 +1,84 @@
+package pstoremem
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	pstore "github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/record"
+)
+
+type signedRecord struct {
+	seq      uint64
+	envelope *record.Envelope
+}
+
+type memorySignedRecordBook struct {
+	sync.RWMutex
+	// records maps peers to their records, by domain.
+	// The number of domains is bounded by the number of registered record types.
+	records map[peer.ID]map[string]signedRecord
+}
+
+var _ pstore.SignedRecordBook = (*memorySignedRecordBook)(nil)
+
+func NewSignedRecordBook() *memorySignedRecordBook {
+	return &memorySignedRecordBook{
+		records: make(map[peer.ID]map[string]signedRecord),
+	}
+}
+
+func (srb *memorySignedRecordBook) ConsumeSignedRecord(env *record.Envelope) (accepted bool, err error) {
+	r, err := env.Record()
+	if err != nil {
+		return false, err
+	}
+	rec, ok := r.(record.SequencedRecord)
+	if !ok {
+		return false, errors.New("record doesn't have a sequence number")
+	}
+	p, err := peer.IDFromPublicKey(env.PublicKey)
+	if err != nil {
+		return false, err
+	}
+
+	srb.Lock()
+	defer srb.Unlock()
+	recs, ok := srb.records[p]
+	if !ok {
+		recs = make(map[string]signedRecord)
+		srb.records[p] = recs
+	}
+	if existing, ok := recs[rec.Domain()]; ok && existing.seq >= rec.SequenceNumber() {
+		return false, nil
+	}
+	recs[rec.Domain()] = signedRecord{seq: rec.SequenceNumber(), envelope: env}
+	return true, nil
+}
+
+func (srb *memorySignedRecordBook) GetSignedRecord(p peer.ID, domain string) *record.Envelope {
+	srb.RLock()
+	defer srb.RUnlock()
+	return srb.records[p][domain].envelope
+}
+
+func (srb *memorySignedRecordBook) SignedRecords(p peer.ID) []*record.Envelope {
+	srb.RLock()
+	defer srb.RUnlock()
+	recs := srb.records[p]
+	if len(recs) == 0 {
+		return nil
+	}
+	envs := make([]*record.Envelope, 0, len(recs))
+	for _, r := range recs {
+		envs = append(envs, r.envelope)
+	}
+	return envs
+}
+
+func (srb *memorySignedRecordBook) RemovePeer(p peer.ID) {
+	srb.Lock()
+	delete(srb.records, p)
+	srb.Unlock()
+}
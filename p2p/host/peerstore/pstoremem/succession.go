@@ -0,0 +1,83 @@
+package pstoremem
+
+import (
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/record"
+)
+
+type succession struct {
+	successor peer.ID
+	seq       uint64
+	envelope  *record.Envelope
+}
+
+func (mkb *memoryKeyBook) ConsumeKeySuccession(env *record.Envelope) (accepted bool, err error) {
+	rec, err := peer.VerifyKeySuccession(env)
+	if err != nil {
+		return false, err
+	}
+	successor, err := rec.SuccessorID()
+	if err != nil {
+		return false, err
+	}
+
+	mkb.Lock()
+	defer mkb.Unlock()
+	if s, ok := mkb.successions[rec.PeerID]; ok && (s.successor != successor || s.seq >= rec.Seq) {
+		return false, nil
+	}
+	mkb.successions[rec.PeerID] = succession{successor: successor, seq: rec.Seq, envelope: env}
+	preds, ok := mkb.predecessors[successor]
+	if !ok {
+		preds = make(map[peer.ID]struct{})
+		mkb.predecessors[successor] = preds
+	}
+	preds[rec.PeerID] = struct{}{}
+	return true, nil
+}
+
+func (mkb *memoryKeyBook) Successor(p peer.ID) (peer.ID, bool) {
+	mkb.RLock()
+	defer mkb.RUnlock()
+	s, ok := mkb.successions[p]
+	return s.successor, ok
+}
+
+func (mkb *memoryKeyBook) KeySuccessionRecords(p peer.ID) []*record.Envelope {
+	mkb.RLock()
+	defer mkb.RUnlock()
+
+	var envs []*record.Envelope
+	// Walk the predecessors breadth-first. Peers can succeed each other, so we need to
+	// remember which peers we already visited.
+	visited := map[peer.ID]struct{}{p: {}}
+	queue := []peer.ID{p}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for pred := range mkb.predecessors[next] {
+			if _, ok := visited[pred]; ok {
+				continue
+			}
+			visited[pred] = struct{}{}
+			envs = append(envs, mkb.successions[pred].envelope)
+			queue = append(queue, pred)
+		}
+	}
+	return envs
+}
+
+// removeSuccession removes the key succession record of p. The lock must be held.
+func (mkb *memoryKeyBook) removeSuccession(p peer.ID) {
+	s, ok := mkb.successions[p]
+	if !ok {
+		return
+	}
+	delete(mkb.successions, p)
+	if preds := mkb.predecessors[s.successor]; preds != nil {
+		delete(preds, p)
+		if len(preds) == 0 {
+			delete(mkb.predecessors, s.successor)
+		}
+	}
+}
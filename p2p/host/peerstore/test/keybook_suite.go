@@ -7,6 +7,7 @@ import (
 	ic "github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
 	pstore "github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/record"
 	pt "github.com/libp2p/go-libp2p/core/test"
 
 	"github.com/stretchr/testify/require"
@@ -18,6 +19,7 @@ var keyBookSuite = map[string]func(kb pstore.KeyBook) func(*testing.T){
 	"PeersWithKeys":         testKeyBookPeers,
 	"PubKeyAddedOnRetrieve": testInlinedPubKeyAddedOnRetrieve,
 	"Delete":                testKeyBookDelete,
+	"KeySuccession":         testKeyBookKeySuccession,
 }
 
 type KeyBookFactory func() (pstore.KeyBook, func())
@@ -203,6 +205,81 @@ var keybookBenchmarkSuite = map[string]func(kb pstore.KeyBook) func(*testing.B){
 	"PeersWithKeys": benchmarkPeersWithKeys,
 }
 
+func testKeyBookKeySuccession(kb pstore.KeyBook) func(t *testing.T) {
+	return func(t *testing.T) {
+		ksb, ok := pstore.GetKeySuccessionBook(kb)
+		if !ok {
+			t.Skip("key book doesn't store key succession records")
+		}
+
+		keys := make([]ic.PrivKey, 4)
+		ids := make([]peer.ID, len(keys))
+		for i := range keys {
+			var err error
+			keys[i], _, err = pt.RandTestKeyPair(ic.Ed25519, 0)
+			require.NoError(t, err)
+			ids[i], err = peer.IDFromPrivateKey(keys[i])
+			require.NoError(t, err)
+		}
+
+		// 0 -> 1 -> 2
+		env01, err := peer.NewKeySuccession(keys[0], keys[1])
+		require.NoError(t, err)
+		env12, err := peer.NewKeySuccession(keys[1], keys[2])
+		require.NoError(t, err)
+		for _, env := range []*record.Envelope{env01, env12} {
+			accepted, err := ksb.ConsumeKeySuccession(env)
+			require.NoError(t, err)
+			require.True(t, accepted)
+		}
+		// Consuming the same record again is a no-op.
+		accepted, err := ksb.ConsumeKeySuccession(env01)
+		require.NoError(t, err)
+		require.False(t, accepted)
+
+		s, ok := ksb.Successor(ids[0])
+		require.True(t, ok)
+		require.Equal(t, ids[1], s)
+		_, ok = ksb.Successor(ids[2])
+		require.False(t, ok)
+
+		envs := ksb.KeySuccessionRecords(ids[2])
+		require.Len(t, envs, 2)
+		require.True(t, envs[0].Equal(env12))
+		require.True(t, envs[1].Equal(env01))
+		require.Empty(t, ksb.KeySuccessionRecords(ids[0]))
+
+		// A peer can only have a single successor.
+		env03, err := peer.NewKeySuccession(keys[0], keys[3])
+		require.NoError(t, err)
+		accepted, err = ksb.ConsumeKeySuccession(env03)
+		require.NoError(t, err)
+		require.False(t, accepted)
+		// But newer records announcing the same successor replace older ones.
+		env01, err = peer.NewKeySuccession(keys[0], keys[1])
+		require.NoError(t, err)
+		accepted, err = ksb.ConsumeKeySuccession(env01)
+		require.NoError(t, err)
+		require.True(t, accepted)
+
+		// Records that weren't signed by the peer being replaced are rejected.
+		rec, err := peer.VerifyKeySuccession(env03)
+		require.NoError(t, err)
+		forged, err := record.Seal(rec, keys[3])
+		require.NoError(t, err)
+		_, err = ksb.ConsumeKeySuccession(forged)
+		require.Error(t, err)
+
+		// Removing a peer removes the records of its predecessors.
+		kb.RemovePeer(ids[1])
+		_, ok = ksb.Successor(ids[0])
+		require.False(t, ok)
+		_, ok = ksb.Successor(ids[1])
+		require.False(t, ok)
+		require.Empty(t, ksb.KeySuccessionRecords(ids[2]))
+	}
+}
+
 func BenchmarkKeyBook(b *testing.B, factory KeyBookFactory) {
 	ordernames := make([]string, 0, len(keybookBenchmarkSuite))
 	for name := range keybookBenchmarkSuite {
@@ -13,6 +13,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	pstore "github.com/libp2p/go-libp2p/core/peerstore"
 	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/core/record"
 
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/require"
@@ -26,6 +27,7 @@ var peerstoreSuite = map[string]func(pstore.Peerstore) func(*testing.T){
 	"BasicPeerstore":           testBasicPeerstore,
 	"Metadata":                 testMetadata,
 	"CertifiedAddrBook":        testCertifiedAddrBook,
+	"SignedRecordBook":         testSignedRecordBook,
 }
 
 type PeerstoreFactory func() (pstore.Peerstore, func())
@@ -373,6 +375,63 @@ func testCertifiedAddrBook(ps pstore.Peerstore) func(*testing.T) {
 	}
 }
 
+func testSignedRecordBook(ps pstore.Peerstore) func(*testing.T) {
+	return func(t *testing.T) {
+		srb, ok := pstore.GetSignedRecordBook(ps)
+		if !ok {
+			t.Skip("peerstore doesn't store signed records")
+		}
+
+		priv, _, err := crypto.GenerateEd25519Key(nil)
+		require.NoError(t, err)
+		p, err := peer.IDFromPrivateKey(priv)
+		require.NoError(t, err)
+		seal := func(seq uint64) *record.Envelope {
+			t.Helper()
+			env, err := record.Seal(&peer.PeerRecord{PeerID: p, Addrs: getAddrs(t, 1), Seq: seq}, priv)
+			require.NoError(t, err)
+			return env
+		}
+
+		require.Nil(t, srb.GetSignedRecord(p, peer.PeerRecordEnvelopeDomain))
+		require.Empty(t, srb.SignedRecords(p))
+
+		env := seal(2)
+		accepted, err := srb.ConsumeSignedRecord(env)
+		require.NoError(t, err)
+		require.True(t, accepted)
+		require.True(t, env.Equal(srb.GetSignedRecord(p, peer.PeerRecordEnvelopeDomain)))
+		require.Len(t, srb.SignedRecords(p), 1)
+
+		// Records with the same or a lower sequence number are ignored.
+		for _, seq := range []uint64{1, 2} {
+			accepted, err = srb.ConsumeSignedRecord(seal(seq))
+			require.NoError(t, err)
+			require.False(t, accepted)
+		}
+		require.True(t, env.Equal(srb.GetSignedRecord(p, peer.PeerRecordEnvelopeDomain)))
+
+		newer := seal(3)
+		accepted, err = srb.ConsumeSignedRecord(newer)
+		require.NoError(t, err)
+		require.True(t, accepted)
+		require.True(t, newer.Equal(srb.GetSignedRecord(p, peer.PeerRecordEnvelopeDomain)))
+		require.Len(t, srb.SignedRecords(p), 1)
+
+		// Records without a sequence number can't be stored.
+		other, _, err := crypto.GenerateEd25519Key(nil)
+		require.NoError(t, err)
+		ks, err := peer.NewKeySuccession(priv, other)
+		require.NoError(t, err)
+		_, err = srb.ConsumeSignedRecord(ks)
+		require.Error(t, err)
+
+		ps.RemovePeer(p)
+		require.Nil(t, srb.GetSignedRecord(p, peer.PeerRecordEnvelopeDomain))
+		require.Empty(t, srb.SignedRecords(p))
+	}
+}
+
 func getAddrs(t *testing.T, n int) []ma.Multiaddr {
 	var addrs []ma.Multiaddr
 	for i := 0; i < n; i++ {
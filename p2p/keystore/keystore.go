@@ -0,0 +1,551 @@
+// Package keystore stores private keys on disk, encrypted with a passphrase.
+//
+// Every key is stored under a name, in its own file in the keystore directory. A key can be
+// rotated: the new key becomes the current version, while the previous versions are kept,
+// so that they can still be used to prove that the new key belongs to the same owner.
+//
+// The private keys are encrypted using XChaCha20-Poly1305, with a key derived from the
+// passphrase using argon2id (the default) or scrypt. The public keys are stored unencrypted, so
+// that the keys in a keystore can be listed without knowing the passphrase.
+package keystore
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	// ErrNoSuchKey is returned when a key doesn't exist.
+	ErrNoSuchKey = errors.New("no such key")
+	// ErrKeyExists is returned when adding a key under a name that's already in use.
+	ErrKeyExists = errors.New("key already exists")
+	// ErrInvalidName is returned for names that can't be used as key names.
+	ErrInvalidName = errors.New("invalid key name")
+	// ErrDecryptionFailed is returned when a key can't be decrypted, usually because the
+	// passphrase is wrong.
+	ErrDecryptionFailed = errors.New("decryption failed: wrong passphrase or corrupted key file")
+)
+
+const (
+	fileVersion   = 1
+	fileExtension = ".key"
+	keySize       = chacha20poly1305.KeySize
+	saltSize      = 16
+)
+
+// Names may only contain letters, digits, dashes and underscores, so that they can be used as
+// file names on all platforms.
+var nameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// KDF is a key derivation function used to derive the encryption key from the passphrase.
+type KDF string
+
+const (
+	// Argon2id is the argon2id key derivation function (RFC 9106).
+	Argon2id KDF = "argon2id"
+	// Scrypt is the scrypt key derivation function (RFC 7914).
+	Scrypt KDF = "scrypt"
+)
+
+// kdfParams are the parameters of the key derivation function. They are stored along with every
+// encrypted key, so that keys stay readable when the defaults change.
+type kdfParams struct {
+	Name KDF    `json:"name"`
+	Salt []byte `json:"salt"`
+	// argon2id
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+	// scrypt
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+}
+
+func (p *kdfParams) deriveKey(passphrase []byte) ([]byte, error) {
+	switch p.Name {
+	case Argon2id:
+		if p.Time == 0 || p.Memory == 0 || p.Threads == 0 {
+			return nil, errors.New("invalid argon2id parameters")
+		}
+		return argon2.IDKey(passphrase, p.Salt, p.Time, p.Memory, p.Threads, keySize), nil
+	case Scrypt:
+		return scrypt.Key(passphrase, p.Salt, p.N, p.R, p.P, keySize)
+	default:
+		return nil, fmt.Errorf("unknown key derivation function: %q", p.Name)
+	}
+}
+
+type config struct {
+	kdf kdfParams
+}
+
+// Option is an option for the keystore.
+type Option func(*config) error
+
+// WithArgon2id configures the keystore to encrypt keys using a key derived with argon2id.
+// Memory is in KiB. The default is 1 iteration with 64 MiB and 4 threads.
+func WithArgon2id(time, memory uint32, threads uint8) Option {
+	return func(cfg *config) error {
+		if time == 0 || memory == 0 || threads == 0 {
+			return errors.New("argon2id parameters must not be zero")
+		}
+		cfg.kdf = kdfParams{Name: Argon2id, Time: time, Memory: memory, Threads: threads}
+		return nil
+	}
+}
+
+// WithScrypt configures the keystore to encrypt keys using a key derived with scrypt.
+// The recommended parameters are N=32768, r=8, p=1.
+func WithScrypt(n, r, p int) Option {
+	return func(cfg *config) error {
+		// Let scrypt check the parameters.
+		if _, err := scrypt.Key(nil, nil, n, r, p, keySize); err != nil {
+			return err
+		}
+		cfg.kdf = kdfParams{Name: Scrypt, N: n, R: r, P: p}
+		return nil
+	}
+}
+
+// keyFile is the JSON-encoded content of a key file.
+type keyFile struct {
+	Version  int          `json:"version"`
+	Name     string       `json:"name"`
+	Versions []keyVersion `json:"keys"`
+}
+
+type keyVersion struct {
+	Created    time.Time `json:"created"`
+	PublicKey  []byte    `json:"public_key"`
+	KDF        kdfParams `json:"kdf"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+// KeyInfo describes a version of a key.
+type KeyInfo struct {
+	Name string
+	// Version is the version of the key, starting at 0. It is increased every time the key is rotated.
+	Version   int
+	Created   time.Time
+	PublicKey crypto.PubKey
+	ID        peer.ID
+}
+
+// Keystore stores encrypted private keys in a directory.
+// It is safe for concurrent use, but not for concurrent use by multiple processes.
+type Keystore struct {
+	dir string
+	cfg config
+
+	mx         sync.Mutex
+	passphrase []byte
+}
+
+// Open opens the keystore in dir, creating the directory if it doesn't exist.
+// New keys are encrypted using passphrase. Keys that were encrypted using a different
+// passphrase can't be read.
+func Open(dir string, passphrase []byte, opts ...Option) (*Keystore, error) {
+	cfg := config{kdf: kdfParams{Name: Argon2id, Time: 1, Memory: 64 * 1024, Threads: 4}}
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Keystore{
+		dir:        dir,
+		cfg:        cfg,
+		passphrase: append([]byte(nil), passphrase...),
+	}, nil
+}
+
+// Put stores key under name. It fails with ErrKeyExists if the name is already in use.
+func (ks *Keystore) Put(name string, key crypto.PrivKey) error {
+	ks.mx.Lock()
+	defer ks.mx.Unlock()
+
+	if _, err := ks.readFile(name); err == nil {
+		return ErrKeyExists
+	} else if !errors.Is(err, ErrNoSuchKey) {
+		return err
+	}
+	v, err := ks.encrypt(name, key, ks.passphrase)
+	if err != nil {
+		return err
+	}
+	return ks.writeFile(&keyFile{Version: fileVersion, Name: name, Versions: []keyVersion{*v}})
+}
+
+// Generate generates a new key and stores it under name.
+// typ and bits are passed to crypto.GenerateKeyPair.
+func (ks *Keystore) Generate(name string, typ, bits int) (crypto.PrivKey, error) {
+	key, _, err := crypto.GenerateKeyPair(typ, bits)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.Put(name, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// LoadOrGenerate returns the current version of the key stored under name. If there is no such
+// key, it generates an Ed25519 key and stores it.
+func (ks *Keystore) LoadOrGenerate(name string) (crypto.PrivKey, error) {
+	key, err := ks.Get(name)
+	if errors.Is(err, ErrNoSuchKey) {
+		key, err = ks.Generate(name, crypto.Ed25519, -1)
+		if errors.Is(err, ErrKeyExists) {
+			// Someone else generated the key in the meantime.
+			return ks.Get(name)
+		}
+	}
+	return key, err
+}
+
+// Get returns the current version of the key stored under name.
+func (ks *Keystore) Get(name string) (crypto.PrivKey, error) {
+	ks.mx.Lock()
+	defer ks.mx.Unlock()
+
+	f, err := ks.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return ks.decrypt(name, &f.Versions[len(f.Versions)-1])
+}
+
+// GetVersion returns a version of the key stored under name.
+func (ks *Keystore) GetVersion(name string, version int) (crypto.PrivKey, error) {
+	ks.mx.Lock()
+	defer ks.mx.Unlock()
+
+	f, err := ks.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if version < 0 || version >= len(f.Versions) {
+		return nil, fmt.Errorf("%w: %s version %d", ErrNoSuchKey, name, version)
+	}
+	return ks.decrypt(name, &f.Versions[version])
+}
+
+// Info returns information about all versions of the key stored under name, oldest first.
+// It doesn't need the passphrase.
+func (ks *Keystore) Info(name string) ([]KeyInfo, error) {
+	ks.mx.Lock()
+	defer ks.mx.Unlock()
+
+	f, err := ks.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]KeyInfo, 0, len(f.Versions))
+	for i, v := range f.Versions {
+		pub, err := crypto.UnmarshalPublicKey(v.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		id, err := peer.IDFromPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, KeyInfo{Name: name, Version: i, Created: v.Created, PublicKey: pub, ID: id})
+	}
+	return infos, nil
+}
+
+// Has checks if a key is stored under name.
+func (ks *Keystore) Has(name string) (bool, error) {
+	ks.mx.Lock()
+	defer ks.mx.Unlock()
+
+	if _, err := ks.readFile(name); err != nil {
+		if errors.Is(err, ErrNoSuchKey) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns the names of all keys, sorted alphabetically.
+func (ks *Keystore) List() ([]string, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), fileExtension)
+		if !ok || e.IsDir() || !nameRegexp.MatchString(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete deletes all versions of the key stored under name.
+func (ks *Keystore) Delete(name string) error {
+	if !nameRegexp.MatchString(name) {
+		return ErrInvalidName
+	}
+	ks.mx.Lock()
+	defer ks.mx.Unlock()
+
+	if err := os.Remove(ks.path(name)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrNoSuchKey, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Rotate makes key the current version of the key stored under name.
+// The previous versions are kept, and can be accessed using GetVersion.
+func (ks *Keystore) Rotate(name string, key crypto.PrivKey) error {
+	ks.mx.Lock()
+	defer ks.mx.Unlock()
+
+	f, err := ks.readFile(name)
+	if err != nil {
+		return err
+	}
+	// Make sure that we're able to decrypt the current key. Otherwise we'd end up with versions
+	// encrypted using different passphrases.
+	if _, err := ks.decrypt(name, &f.Versions[len(f.Versions)-1]); err != nil {
+		return err
+	}
+	v, err := ks.encrypt(name, key, ks.passphrase)
+	if err != nil {
+		return err
+	}
+	f.Versions = append(f.Versions, *v)
+	return ks.writeFile(f)
+}
+
+// ChangePassphrase re-encrypts all keys using a new passphrase.
+//
+// All keys are decrypted before any of them is written, so that the passphrase isn't changed if
+// it is wrong for any of the keys. Every key file is replaced atomically, but if writing fails
+// halfway through, some keys may remain encrypted with the old passphrase.
+func (ks *Keystore) ChangePassphrase(passphrase []byte) error {
+	names, err := ks.List()
+	if err != nil {
+		return err
+	}
+
+	ks.mx.Lock()
+	defer ks.mx.Unlock()
+
+	files := make([]*keyFile, 0, len(names))
+	for _, name := range names {
+		f, err := ks.readFile(name)
+		if err != nil {
+			return err
+		}
+		for i := range f.Versions {
+			key, err := ks.decrypt(name, &f.Versions[i])
+			if err != nil {
+				return err
+			}
+			v, err := ks.encrypt(name, key, passphrase)
+			if err != nil {
+				return err
+			}
+			v.Created = f.Versions[i].Created
+			f.Versions[i] = *v
+		}
+		files = append(files, f)
+	}
+	for _, f := range files {
+		if err := ks.writeFile(f); err != nil {
+			return err
+		}
+	}
+	ks.passphrase = append([]byte(nil), passphrase...)
+	return nil
+}
+
+// Import stores a PEM-encoded private key under name. See UnmarshalPEM for the supported formats.
+func (ks *Keystore) Import(name string, data []byte) (crypto.PrivKey, error) {
+	key, err := UnmarshalPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.Put(name, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Export returns the current version of the key stored under name as an unencrypted PEM-encoded
+// PKCS #8 private key.
+func (ks *Keystore) Export(name string) ([]byte, error) {
+	key, err := ks.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalPEM(key)
+}
+
+func (ks *Keystore) path(name string) string {
+	return filepath.Join(ks.dir, name+fileExtension)
+}
+
+func (ks *Keystore) readFile(name string) (*keyFile, error) {
+	if !nameRegexp.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	b, err := os.ReadFile(ks.path(name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNoSuchKey, name)
+		}
+		return nil, err
+	}
+	var f keyFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse key file for %s: %w", name, err)
+	}
+	if f.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported key file version: %d", f.Version)
+	}
+	if f.Name != name {
+		return nil, fmt.Errorf("key file for %s contains key %s", name, f.Name)
+	}
+	if len(f.Versions) == 0 {
+		return nil, fmt.Errorf("key file for %s is empty", name)
+	}
+	return &f, nil
+}
+
+// writeFile atomically replaces the key file.
+func (ks *Keystore) writeFile(f *keyFile) error {
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(ks.dir, "."+f.Name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), ks.path(f.Name))
+}
+
+func (ks *Keystore) encrypt(name string, key crypto.PrivKey, passphrase []byte) (*keyVersion, error) {
+	if !nameRegexp.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	plaintext, err := crypto.MarshalPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := crypto.MarshalPublicKey(key.GetPublic())
+	if err != nil {
+		return nil, err
+	}
+	params := ks.cfg.kdf
+	params.Salt = make([]byte, saltSize)
+	if _, err := rand.Read(params.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(&params, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &keyVersion{
+		Created:    time.Now().UTC().Truncate(time.Second),
+		PublicKey:  pub,
+		KDF:        params,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, additionalData(name, pub)),
+	}, nil
+}
+
+func (ks *Keystore) decrypt(name string, v *keyVersion) (crypto.PrivKey, error) {
+	aead, err := newAEAD(&v.KDF, ks.passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Nonce) != aead.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+	plaintext, err := aead.Open(nil, v.Nonce, v.Ciphertext, additionalData(name, v.PublicKey))
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	key, err := crypto.UnmarshalPrivateKey(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := crypto.UnmarshalPublicKey(v.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !key.GetPublic().Equals(pub) {
+		return nil, errors.New("private key doesn't match public key")
+	}
+	return key, nil
+}
+
+func newAEAD(params *kdfParams, passphrase []byte) (cipher.AEAD, error) {
+	k, err := params.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(k)
+}
+
+// additionalData binds the ciphertext to the name and the public key, so that encrypted keys
+// can't be swapped between key files without being noticed.
+func additionalData(name string, pub []byte) []byte {
+	ad := make([]byte, 0, len(name)+1+len(pub))
+	ad = append(ad, name...)
+	ad = append(ad, 0)
+	return append(ad, pub...)
+}
@@ -0,0 +1,200 @@
+package keystore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Use cheap parameters, so that the tests run fast.
+var testKDF = WithArgon2id(1, 64, 1)
+
+func newKeystore(t *testing.T, passphrase string, opts ...Option) (*Keystore, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "keystore")
+	ks, err := Open(dir, []byte(passphrase), append([]Option{testKDF}, opts...)...)
+	require.NoError(t, err)
+	return ks, dir
+}
+
+func TestPutGet(t *testing.T) {
+	ks, dir := newKeystore(t, "foobar")
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	require.NoError(t, ks.Put("host", key))
+	require.ErrorIs(t, ks.Put("host", key), ErrKeyExists)
+
+	k, err := ks.Get("host")
+	require.NoError(t, err)
+	require.True(t, key.Equals(k))
+	has, err := ks.Has("host")
+	require.NoError(t, err)
+	require.True(t, has)
+
+	fi, err := os.Stat(filepath.Join(dir, "host.key"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+	b, err := os.ReadFile(filepath.Join(dir, "host.key"))
+	require.NoError(t, err)
+	raw, err := key.Raw()
+	require.NoError(t, err)
+	require.NotContains(t, string(b), string(raw))
+
+	// Reopen the keystore.
+	ks, err = Open(dir, []byte("foobar"), testKDF)
+	require.NoError(t, err)
+	k, err = ks.Get("host")
+	require.NoError(t, err)
+	require.True(t, key.Equals(k))
+}
+
+func TestWrongPassphrase(t *testing.T) {
+	ks, dir := newKeystore(t, "foobar")
+	_, err := ks.Generate("host", crypto.Ed25519, -1)
+	require.NoError(t, err)
+
+	ks, err = Open(dir, []byte("wrong"), testKDF)
+	require.NoError(t, err)
+	_, err = ks.Get("host")
+	require.ErrorIs(t, err, ErrDecryptionFailed)
+	// The public key can be read without the passphrase.
+	infos, err := ks.Info("host")
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+}
+
+func TestScrypt(t *testing.T) {
+	ks, dir := newKeystore(t, "foobar", WithScrypt(1024, 8, 1))
+	key, err := ks.Generate("host", crypto.ECDSA, -1)
+	require.NoError(t, err)
+	// Keys remain readable when the parameters change.
+	ks, err = Open(dir, []byte("foobar"))
+	require.NoError(t, err)
+	k, err := ks.Get("host")
+	require.NoError(t, err)
+	require.True(t, key.Equals(k))
+
+	_, err = Open(dir, nil, WithScrypt(1000, 8, 1))
+	require.Error(t, err)
+}
+
+func TestNames(t *testing.T) {
+	ks, _ := newKeystore(t, "foobar")
+	for _, name := range []string{"", "../foo", "foo.bar", "foo/bar"} {
+		_, err := ks.Generate(name, crypto.Ed25519, -1)
+		require.ErrorIs(t, err, ErrInvalidName, name)
+	}
+	for _, name := range []string{"b", "a", "c-d_e"} {
+		_, err := ks.Generate(name, crypto.Ed25519, -1)
+		require.NoError(t, err)
+	}
+	names, err := ks.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c-d_e"}, names)
+
+	require.NoError(t, ks.Delete("b"))
+	require.ErrorIs(t, ks.Delete("b"), ErrNoSuchKey)
+	_, err = ks.Get("b")
+	require.ErrorIs(t, err, ErrNoSuchKey)
+	names, err = ks.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "c-d_e"}, names)
+}
+
+func TestSwappedKeyFiles(t *testing.T) {
+	ks, dir := newKeystore(t, "foobar")
+	_, err := ks.Generate("a", crypto.Ed25519, -1)
+	require.NoError(t, err)
+	_, err = ks.Generate("b", crypto.Ed25519, -1)
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(filepath.Join(dir, "a.key"), filepath.Join(dir, "b.key")))
+	_, err = ks.Get("b")
+	require.Error(t, err)
+}
+
+func TestRotate(t *testing.T) {
+	ks, _ := newKeystore(t, "foobar")
+	first, err := ks.LoadOrGenerate("host")
+	require.NoError(t, err)
+	k, err := ks.LoadOrGenerate("host")
+	require.NoError(t, err)
+	require.True(t, first.Equals(k))
+
+	second, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	require.NoError(t, ks.Rotate("host", second))
+	require.ErrorIs(t, ks.Rotate("unknown", second), ErrNoSuchKey)
+
+	k, err = ks.Get("host")
+	require.NoError(t, err)
+	require.True(t, second.Equals(k))
+	k, err = ks.GetVersion("host", 0)
+	require.NoError(t, err)
+	require.True(t, first.Equals(k))
+	_, err = ks.GetVersion("host", 2)
+	require.ErrorIs(t, err, ErrNoSuchKey)
+
+	infos, err := ks.Info("host")
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	for i, key := range []crypto.PrivKey{first, second} {
+		id, err := peer.IDFromPrivateKey(key)
+		require.NoError(t, err)
+		require.Equal(t, i, infos[i].Version)
+		require.Equal(t, id, infos[i].ID)
+		require.False(t, infos[i].Created.IsZero())
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	ks, dir := newKeystore(t, "foobar")
+	key, err := ks.Generate("a", crypto.Ed25519, -1)
+	require.NoError(t, err)
+	_, err = ks.Generate("b", crypto.Secp256k1, -1)
+	require.NoError(t, err)
+	require.NoError(t, ks.Rotate("b", key))
+
+	require.NoError(t, ks.ChangePassphrase([]byte("new")))
+	k, err := ks.Get("a")
+	require.NoError(t, err)
+	require.True(t, key.Equals(k))
+
+	old, err := Open(dir, []byte("foobar"), testKDF)
+	require.NoError(t, err)
+	_, err = old.Get("a")
+	require.ErrorIs(t, err, ErrDecryptionFailed)
+	// Changing the passphrase fails if the old passphrase is wrong.
+	require.ErrorIs(t, old.ChangePassphrase([]byte("foobar")), ErrDecryptionFailed)
+
+	ks, err = Open(dir, []byte("new"), testKDF)
+	require.NoError(t, err)
+	for _, name := range []string{"a", "b"} {
+		_, err := ks.GetVersion(name, 0)
+		require.NoError(t, err)
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	ks, _ := newKeystore(t, "foobar")
+	key, err := ks.Generate("host", crypto.Secp256k1, -1)
+	require.NoError(t, err)
+	pemKey, err := ks.Export("host")
+	require.NoError(t, err)
+	k, err := ks.Import("imported", pemKey)
+	require.NoError(t, err)
+	require.True(t, key.Equals(k))
+	_, err = ks.Import("host", pemKey)
+	require.ErrorIs(t, err, ErrKeyExists)
+}
+
+func TestSignerKeyNotStored(t *testing.T) {
+	ks, _ := newKeystore(t, "foobar")
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	require.ErrorIs(t, ks.Put("host", crypto.NewSignerPrivKey(key)), crypto.ErrKeyNotExportable)
+}
@@ -0,0 +1,182 @@
+package keystore
+
+import (
+	stdcrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+var (
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	// secp256k1 isn't supported by crypto/x509.
+	oidNamedCurveSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// pkcs8 is a PKCS #8 private key (RFC 5208).
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// ecPrivateKey is a SEC 1 elliptic curve private key (RFC 5915).
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// MarshalPEM encodes key as an unencrypted PEM-encoded PKCS #8 private key.
+// Ed25519, ECDSA, RSA and Secp256k1 keys are supported.
+func MarshalPEM(key crypto.PrivKey) ([]byte, error) {
+	var (
+		der []byte
+		err error
+	)
+	if k, ok := key.(*crypto.Secp256k1PrivateKey); ok {
+		der, err = marshalSecp256k1PKCS8((*secp256k1.PrivateKey)(k))
+	} else {
+		var std stdcrypto.PrivateKey
+		std, err = crypto.PrivKeyToStdKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if k, ok := std.(*ed25519.PrivateKey); ok {
+			std = *k
+		}
+		der, err = x509.MarshalPKCS8PrivateKey(std)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// UnmarshalPEM decodes a PEM-encoded private key.
+// It supports unencrypted PKCS #8 keys ("PRIVATE KEY") containing Ed25519, ECDSA, RSA or
+// Secp256k1 keys, SEC 1 keys ("EC PRIVATE KEY") and PKCS #1 keys ("RSA PRIVATE KEY").
+func UnmarshalPEM(data []byte) (crypto.PrivKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var (
+		std any
+		err error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		std, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			if k, err2 := parseSecp256k1PKCS8(block.Bytes); err2 == nil {
+				std, err = k, nil
+			}
+		}
+	case "EC PRIVATE KEY":
+		std, err = x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			if k, err2 := parseSecp256k1SEC1(block.Bytes); err2 == nil {
+				std, err = k, nil
+			}
+		}
+	case "RSA PRIVATE KEY":
+		std, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "ENCRYPTED PRIVATE KEY":
+		return nil, errors.New("encrypted PEM keys are not supported")
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := std.(type) {
+	case ed25519.PrivateKey:
+		std = &k
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < crypto.MinRsaKeyBits {
+			return nil, crypto.ErrRsaKeyTooSmall
+		}
+	case *ecdsa.PrivateKey, *secp256k1.PrivateKey:
+	default:
+		return nil, crypto.ErrBadKeyType
+	}
+	priv, _, err := crypto.KeyPairFromStdKey(std)
+	return priv, err
+}
+
+func marshalSecp256k1PKCS8(k *secp256k1.PrivateKey) ([]byte, error) {
+	params, err := asn1.Marshal(oidNamedCurveSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+	// As crypto/x509 does, omit the curve from the SEC 1 key, since it is in the algorithm identifier.
+	pub := k.PubKey().SerializeUncompressed()
+	sec1, err := asn1.Marshal(ecPrivateKey{
+		Version:    1,
+		PrivateKey: k.Serialize(),
+		PublicKey:  asn1.BitString{Bytes: pub, BitLength: 8 * len(pub)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs8{
+		Algo: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		PrivateKey: sec1,
+	})
+}
+
+func parseSecp256k1PKCS8(der []byte) (*secp256k1.PrivateKey, error) {
+	var p pkcs8
+	if rest, err := asn1.Unmarshal(der, &p); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("trailing data after PKCS #8 key")
+	}
+	if !p.Algo.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, errors.New("not an elliptic curve key")
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(p.Algo.Parameters.FullBytes, &curve); err != nil {
+		return nil, err
+	}
+	if !curve.Equal(oidNamedCurveSecp256k1) {
+		return nil, errors.New("not a secp256k1 key")
+	}
+	return parseSecp256k1SEC1(p.PrivateKey)
+}
+
+func parseSecp256k1SEC1(der []byte) (*secp256k1.PrivateKey, error) {
+	var k ecPrivateKey
+	if rest, err := asn1.Unmarshal(der, &k); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("trailing data after SEC 1 key")
+	}
+	if k.Version != 1 {
+		return nil, fmt.Errorf("unknown SEC 1 key version: %d", k.Version)
+	}
+	if k.NamedCurveOID != nil && !k.NamedCurveOID.Equal(oidNamedCurveSecp256k1) {
+		return nil, errors.New("not a secp256k1 key")
+	}
+	if len(k.PrivateKey) != secp256k1.PrivKeyBytesLen {
+		return nil, errors.New("invalid secp256k1 private key length")
+	}
+	return secp256k1.PrivKeyFromBytes(k.PrivateKey), nil
+}
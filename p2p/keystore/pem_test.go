@@ -0,0 +1,76 @@
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPEMRoundtrip(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		typ  int
+		bits int
+	}{
+		{"Ed25519", crypto.Ed25519, -1},
+		{"ECDSA", crypto.ECDSA, -1},
+		{"RSA", crypto.RSA, 2048},
+		{"Secp256k1", crypto.Secp256k1, -1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			key, _, err := crypto.GenerateKeyPair(tc.typ, tc.bits)
+			require.NoError(t, err)
+			b, err := MarshalPEM(key)
+			require.NoError(t, err)
+			block, _ := pem.Decode(b)
+			require.NotNil(t, block)
+			require.Equal(t, "PRIVATE KEY", block.Type)
+			k, err := UnmarshalPEM(b)
+			require.NoError(t, err)
+			require.True(t, key.Equals(k))
+		})
+	}
+}
+
+func TestPEMStdlibInterop(t *testing.T) {
+	// Keys marshaled by us can be parsed by crypto/x509.
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	b, err := MarshalPEM(key)
+	require.NoError(t, err)
+	block, _ := pem.Decode(b)
+	_, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	require.NoError(t, err)
+
+	// SEC 1 and PKCS #1 keys are supported.
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+	k, err := UnmarshalPEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	require.Equal(t, crypto.ECDSA, int(k.Type()))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	k, err = UnmarshalPEM(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+	require.NoError(t, err)
+	require.Equal(t, crypto.RSA, int(k.Type()))
+}
+
+func TestPEMErrors(t *testing.T) {
+	_, err := UnmarshalPEM([]byte("foobar"))
+	require.Error(t, err)
+	_, err = UnmarshalPEM(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("foo")}))
+	require.Error(t, err)
+	_, err = UnmarshalPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("foo")}))
+	require.Error(t, err)
+}
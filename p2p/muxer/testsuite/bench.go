@@ -0,0 +1,358 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/netem"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Links are the emulated links used by BenchmarkAll if no links are passed.
+var Links = []netem.Config{
+	// data center
+	{RTT: time.Millisecond, Bandwidth: 1.25e9},
+	// broadband
+	{RTT: 20 * time.Millisecond, Bandwidth: 12.5e6},
+	// intercontinental
+	{RTT: 150 * time.Millisecond, Jitter: 10 * time.Millisecond, Bandwidth: 12.5e6, Loss: 0.001},
+	// lossy mobile
+	{RTT: 80 * time.Millisecond, Jitter: 30 * time.Millisecond, Bandwidth: 2.5e6, Loss: 0.02},
+}
+
+// BenchmarkAll runs all benchmarks for tr over every link.
+//
+// Besides the time per operation, the benchmarks report custom metrics (see testing.B.ReportMetric),
+// so that the results of different muxers and settings can be compared using benchstat.
+func BenchmarkAll(b *testing.B, tr network.Multiplexer, links ...netem.Config) {
+	if len(links) == 0 {
+		links = Links
+	}
+	for _, link := range links {
+		b.Run(link.String(), func(b *testing.B) {
+			b.Run("Throughput", func(b *testing.B) {
+				client, server, scope := connPair(b, tr, link)
+				BenchmarkThroughput(b, client, server)
+				b.ReportMetric(float64(scope.peak()), "peak-mem-B")
+			})
+			b.Run("StreamOpen", func(b *testing.B) {
+				client, server, _ := connPair(b, tr, link)
+				BenchmarkStreamOpen(b, client, server, link.RTT)
+			})
+			b.Run("Fairness", func(b *testing.B) {
+				client, server, _ := connPair(b, tr, link)
+				BenchmarkFairness(b, client, server)
+			})
+		})
+	}
+}
+
+// memoryScope is a network.PeerScope that keeps track of the peak memory reserved by all spans.
+type memoryScope struct {
+	mx         sync.Mutex
+	memory     int
+	peakMemory int
+}
+
+var _ network.PeerScope = &memoryScope{}
+
+func (s *memoryScope) reserve(size int) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.memory += size
+	if s.memory > s.peakMemory {
+		s.peakMemory = s.memory
+	}
+}
+
+func (s *memoryScope) release(size int) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.memory -= size
+}
+
+func (s *memoryScope) peak() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.peakMemory
+}
+
+func (s *memoryScope) current() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.memory
+}
+
+func (s *memoryScope) ReserveMemory(size int, _ uint8) error {
+	s.reserve(size)
+	return nil
+}
+
+func (s *memoryScope) ReleaseMemory(size int)  { s.release(size) }
+func (s *memoryScope) Stat() network.ScopeStat { return network.ScopeStat{} }
+func (s *memoryScope) BeginSpan() (network.ResourceScopeSpan, error) {
+	return &memorySpan{scope: s}, nil
+}
+func (s *memoryScope) Peer() peer.ID { return "" }
+
+type memorySpan struct {
+	scope *memoryScope
+
+	mx     sync.Mutex
+	memory int
+}
+
+func (s *memorySpan) ReserveMemory(size int, _ uint8) error {
+	s.mx.Lock()
+	s.memory += size
+	s.mx.Unlock()
+	s.scope.reserve(size)
+	return nil
+}
+
+func (s *memorySpan) ReleaseMemory(size int) {
+	s.mx.Lock()
+	s.memory -= size
+	s.mx.Unlock()
+	s.scope.release(size)
+}
+
+func (s *memorySpan) Done() {
+	s.mx.Lock()
+	memory := s.memory
+	s.memory = 0
+	s.mx.Unlock()
+	s.scope.release(memory)
+}
+
+func (s *memorySpan) Stat() network.ScopeStat { return network.ScopeStat{} }
+func (s *memorySpan) BeginSpan() (network.ResourceScopeSpan, error) {
+	return &memorySpan{scope: s.scope}, nil
+}
+
+// connPair creates a client and a server connection over an emulated link.
+// The server scope tracks the memory reserved by the server.
+func connPair(tb testing.TB, tr network.Multiplexer, link netem.Config) (client, server network.MuxedConn, serverScope *memoryScope) {
+	tb.Helper()
+	c1, c2, err := netem.Pipe(link)
+	require.NoError(tb, err)
+	serverScope = &memoryScope{}
+	var serverErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server, serverErr = tr.NewConn(c2, true, serverScope)
+	}()
+	client, err = tr.NewConn(c1, false, &memoryScope{})
+	require.NoError(tb, err)
+	<-done
+	require.NoError(tb, serverErr)
+	tb.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server, serverScope
+}
+
+// BenchmarkThroughput measures the throughput of a single stream from client to server.
+// BenchmarkAll also reports the peak memory reserved by the server as peak-mem-B.
+func BenchmarkThroughput(b *testing.B, client, server network.MuxedConn) {
+	const chunkSize = 64 << 10
+
+	received := make(chan error, 1)
+	go func() {
+		str, err := server.AcceptStream()
+		if err != nil {
+			received <- err
+			return
+		}
+		defer str.Close()
+		_, err = io.Copy(io.Discard, str)
+		received <- err
+	}()
+
+	str, err := client.OpenStream(context.Background())
+	require.NoError(b, err)
+	buf := make([]byte, chunkSize)
+	b.SetBytes(chunkSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := str.Write(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+	require.NoError(b, str.CloseWrite())
+	require.NoError(b, <-received)
+}
+
+// BenchmarkStreamOpen measures the time it takes to open a stream and receive the first byte
+// sent by the peer. If rtt is set, it also reports this time in multiples of the RTT as rtt/op.
+func BenchmarkStreamOpen(b *testing.B, client, server network.MuxedConn, rtt time.Duration) {
+	go func() {
+		for {
+			str, err := server.AcceptStream()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer str.Close()
+				b := make([]byte, 1)
+				if _, err := io.ReadFull(str, b); err != nil {
+					str.Reset()
+					return
+				}
+				str.Write(b)
+			}()
+		}
+	}()
+
+	buf := make([]byte, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		str, err := client.OpenStream(context.Background())
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := str.Write(buf); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.ReadFull(str, buf); err != nil {
+			b.Fatal(err)
+		}
+		str.Close()
+	}
+	b.StopTimer()
+	if rtt > 0 {
+		b.ReportMetric(float64(b.Elapsed())/float64(b.N)/float64(rtt), "rtt/op")
+	}
+}
+
+// BenchmarkFairness measures how evenly the bandwidth is shared between streams that are
+// sending at the same time. Every operation sends 1 MiB on each of 4 streams.
+//
+// When the first stream finishes, the share of every stream is recorded. The reported fairness
+// is Jain's fairness index of these shares, averaged over all operations: 1 means that all
+// streams got the same share, 1/4 means that one stream got all of the bandwidth.
+func BenchmarkFairness(b *testing.B, client, server network.MuxedConn) {
+	const (
+		numStreams = 4
+		size       = 1 << 20
+	)
+
+	b.SetBytes(numStreams * size)
+	var fairness float64
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f, err := fairnessRound(client, server, numStreams, size)
+		if err != nil {
+			b.Fatal(err)
+		}
+		fairness += f
+	}
+	b.StopTimer()
+	b.ReportMetric(fairness/float64(b.N), "fairness")
+}
+
+func fairnessRound(client, server network.MuxedConn, numStreams, size int) (float64, error) {
+	var (
+		mx       sync.Mutex
+		received = make([]int, numStreams)
+		shares   []int
+		wg       sync.WaitGroup
+		errs     = make(chan error, 2*numStreams)
+		// All streams start sending at the same time, once the server accepted all of them.
+		ready sync.WaitGroup
+		start = make(chan struct{})
+	)
+	wg.Add(2 * numStreams)
+	ready.Add(numStreams)
+	go func() {
+		ready.Wait()
+		close(start)
+	}()
+	for i := 0; i < numStreams; i++ {
+		go func() {
+			defer wg.Done()
+			str, err := server.AcceptStream()
+			if err != nil {
+				ready.Done()
+				errs <- err
+				return
+			}
+			defer str.Close()
+			// The first byte is the index of the stream.
+			buf := make([]byte, 16<<10)
+			_, err = io.ReadFull(str, buf[:1])
+			ready.Done()
+			if err != nil {
+				errs <- err
+				return
+			}
+			idx := int(buf[0])
+			for {
+				n, err := str.Read(buf)
+				mx.Lock()
+				received[idx] += n
+				if received[idx] == size && shares == nil {
+					shares = append([]int(nil), received...)
+				}
+				mx.Unlock()
+				if err == io.EOF {
+					return
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < numStreams; i++ {
+		go func(idx int) {
+			defer wg.Done()
+			str, err := client.OpenStream(context.Background())
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer str.Close()
+			if _, err := str.Write([]byte{byte(idx)}); err != nil {
+				errs <- err
+				return
+			}
+			<-start
+			if _, err := str.Write(make([]byte, size)); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return 0, err
+	}
+	if shares == nil {
+		return 0, fmt.Errorf("no stream received %d bytes", size)
+	}
+	return jainIndex(shares), nil
+}
+
+// jainIndex calculates Jain's fairness index: (sum x)^2 / (n * sum x^2).
+func jainIndex(x []int) float64 {
+	var sum, sumSquares float64
+	for _, v := range x {
+		sum += float64(v)
+		sumSquares += float64(v) * float64(v)
+	}
+	if sumSquares == 0 {
+		return 0
+	}
+	return sum * sum / (float64(len(x)) * sumSquares)
+}
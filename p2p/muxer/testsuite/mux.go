@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	crand "crypto/rand"
+	"errors"
 	"fmt"
 	"io"
 	mrand "math/rand"
@@ -19,6 +20,7 @@ import (
 	"github.com/libp2p/go-libp2p-testing/ci"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/netem"
 
 	"github.com/stretchr/testify/require"
 )
@@ -648,6 +650,63 @@ var subtests = []TransportTest{
 
 // SubtestAll runs all the stream multiplexer tests against the target
 // transport.
+// SubtestEmulatedLink checks that data is transferred correctly on concurrent streams over
+// an emulated link, and that resetting a stream doesn't affect the other streams.
+func SubtestEmulatedLink(t *testing.T, tr network.Multiplexer, link netem.Config) {
+	client, server, scope := connPair(t, tr, link)
+	go func() {
+		for {
+			str, err := server.AcceptStream()
+			if err != nil {
+				return
+			}
+			go echoStream(str)
+		}
+	}()
+
+	reset, err := client.OpenStream(context.Background())
+	require.NoError(t, err)
+	_, err = reset.Write(randBuf(1024))
+	require.NoError(t, err)
+
+	const numStreams = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, numStreams)
+	for i := 0; i < numStreams; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			str, err := client.OpenStream(context.Background())
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer str.Close()
+			data := randBuf(16<<10 + mrand.Intn(64<<10))
+			go str.Write(data)
+			buf := make([]byte, len(data))
+			if _, err := io.ReadFull(str, buf); err != nil {
+				errs <- err
+				return
+			}
+			if !bytes.Equal(data, buf) {
+				errs <- errors.New("data corrupted")
+			}
+		}()
+	}
+	require.NoError(t, reset.Reset())
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	client.Close()
+	server.Close()
+	require.Eventually(t, func() bool { return scope.current() == 0 }, 5*time.Second, 10*time.Millisecond,
+		"expected all reserved memory to have been released")
+}
+
 func SubtestAll(t *testing.T, tr network.Multiplexer) {
 	for name, f := range Subtests {
 		t.Run(name, func(t *testing.T) {
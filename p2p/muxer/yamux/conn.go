@@ -2,6 +2,8 @@ package yamux
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 
 	"github.com/libp2p/go-libp2p/core/network"
 
@@ -9,13 +11,23 @@ import (
 )
 
 // conn implements mux.MuxedConn over yamux.Session.
-type conn yamux.Session
+type conn struct {
+	session *yamux.Session
+	sched   *writeScheduler
+	// windows is nil unless the conn was constructed by a WindowedTransport
+	windows *WindowedTransport
+
+	// growMx is held while a stream reads and yamux might grow its window, see stream.Read.
+	growMx sync.Mutex
+	// growing is the stream that holds growMx
+	growing atomic.Pointer[stream]
+}
 
 var _ network.MuxedConn = &conn{}
 
 // NewMuxedConn constructs a new MuxedConn from a yamux.Session.
 func NewMuxedConn(m *yamux.Session) network.MuxedConn {
-	return (*conn)(m)
+	return &conn{session: m, sched: newWriteScheduler()}
 }
 
 // Close closes underlying yamux
@@ -35,15 +47,18 @@ func (c *conn) OpenStream(ctx context.Context) (network.MuxedStream, error) {
 		return nil, err
 	}
 
-	return (*stream)(s), nil
+	return newStream(s, c), nil
 }
 
 // AcceptStream accepts a stream opened by the other side.
 func (c *conn) AcceptStream() (network.MuxedStream, error) {
 	s, err := c.yamux().AcceptStream()
-	return (*stream)(s), err
+	if err != nil {
+		return nil, err
+	}
+	return newStream(s, c), nil
 }
 
 func (c *conn) yamux() *yamux.Session {
-	return (*yamux.Session)(c)
+	return c.session
 }
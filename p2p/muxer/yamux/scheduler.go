@@ -0,0 +1,240 @@
+package yamux
+
+import (
+	"container/heap"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+
+	"github.com/libp2p/go-yamux/v4"
+)
+
+const (
+	// writeChunkSize is the maximum number of bytes a stream writes per turn when other streams
+	// are waiting. It bounds the time a stream has to wait for the write of another stream.
+	writeChunkSize = 16 * 1024
+	// maxWriteChunkSize is the maximum number of bytes a stream writes per turn when no other
+	// streams are waiting. This is the maximum size of a yamux frame.
+	maxWriteChunkSize = 64 * 1024
+
+	// turnTimeout is the time after which a turn is considered finished, even if the write
+	// hasn't returned yet. Writes block when the stream's flow control window is exhausted,
+	// and a stream waiting for a window update must not prevent other streams from writing.
+	turnTimeout = 50 * time.Millisecond
+)
+
+// writeScheduler schedules the writes of all streams on a connection.
+//
+// Writes are split into chunks, and streams take turns writing a chunk. When multiple streams
+// are waiting, the turn goes to the chunk with the smallest virtual finish time (self-clocked
+// weighted fair queueing), so that every stream gets a share of the connection's send
+// bandwidth that is proportional to its priority.
+//
+// While only a single stream is writing, there's nothing to schedule, and streams bypass the
+// scheduler, see writers.
+type writeScheduler struct {
+	// writers is the number of streams that are currently writing. Streams only use the
+	// scheduler if there's more than one. When a second stream starts writing, the first
+	// stream may still be writing a chunk without holding a turn.
+	writers atomic.Int32
+
+	mx sync.Mutex
+	// busy is set while a turn is granted
+	busy bool
+	// turn identifies the current turn, so that releases of timed out turns are ignored
+	turn uint64
+	// timer ends the current turn at turnDeadline. It is created on the first turn and
+	// reset for every following turn.
+	timer        *time.Timer
+	turnDeadline time.Time
+	// vtime is the virtual time, i.e. the finish time of the chunk of the current turn
+	vtime   uint64
+	seq     uint64
+	waiting waiterQueue
+}
+
+func newWriteScheduler() *writeScheduler {
+	return &writeScheduler{}
+}
+
+type waiter struct {
+	finish uint64
+	seq    uint64 // breaks ties in the order of arrival
+	index  int
+	ch     chan uint64 // receives the turn
+}
+
+// acquire waits for the turn of a stream to write a chunk of up to n bytes.
+//
+// If the stream holds a turn, it passes it as prev. The turn is released when the chunk was
+// queued, so that the stream's next chunk competes with the chunks of the other streams.
+// lastFinish is the virtual finish time of the stream's previous chunk, and is updated. It must
+// only be accessed by the scheduler.
+// It returns the turn, which must be passed to release or to the next call to acquire, and the
+// number of bytes that may be written.
+func (ws *writeScheduler) acquire(prev uint64, lastFinish *uint64, prio network.StreamPriority, n int, closed <-chan struct{}, deadline time.Time) (uint64, int, error) {
+	ws.mx.Lock()
+	start := ws.vtime
+	if *lastFinish > start {
+		start = *lastFinish
+	}
+
+	// Fast path: no other stream is waiting.
+	if len(ws.waiting) == 0 && (!ws.busy || (prev != 0 && ws.turn == prev)) {
+		if n > maxWriteChunkSize {
+			n = maxWriteChunkSize
+		}
+		*lastFinish = start + uint64(n)*256/uint64(prio)
+		ws.vtime = *lastFinish
+		turn := prev
+		if ws.busy {
+			ws.resetTimerLocked()
+		} else {
+			turn = ws.grantLocked()
+		}
+		ws.mx.Unlock()
+		return turn, n, nil
+	}
+
+	if n > writeChunkSize {
+		n = writeChunkSize
+	}
+	finish := start + uint64(n)*256/uint64(prio)
+	*lastFinish = finish
+
+	w := &waiter{finish: finish, seq: ws.seq, ch: make(chan uint64, 1)}
+	ws.seq++
+	heap.Push(&ws.waiting, w)
+	if prev != 0 {
+		ws.releaseLocked(prev)
+	} else {
+		ws.grantNextLocked()
+	}
+	ws.mx.Unlock()
+
+	select {
+	case turn := <-w.ch:
+		return turn, n, nil
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		t := time.NewTimer(time.Until(deadline))
+		defer t.Stop()
+		timeout = t.C
+	}
+	var err error
+	select {
+	case turn := <-w.ch:
+		return turn, n, nil
+	case <-closed:
+		err = yamux.ErrSessionShutdown
+	case <-timeout:
+		err = yamux.ErrTimeout
+	}
+
+	ws.mx.Lock()
+	if w.index >= 0 {
+		heap.Remove(&ws.waiting, w.index)
+		ws.mx.Unlock()
+		return 0, 0, err
+	}
+	ws.mx.Unlock()
+	// We were granted the turn concurrently. Pass it on.
+	ws.release(<-w.ch)
+	return 0, 0, err
+}
+
+// release ends a turn.
+func (ws *writeScheduler) release(turn uint64) {
+	ws.mx.Lock()
+	defer ws.mx.Unlock()
+	ws.releaseLocked(turn)
+}
+
+func (ws *writeScheduler) releaseLocked(turn uint64) {
+	// If the turn doesn't match, it already timed out.
+	if ws.busy && ws.turn == turn {
+		ws.busy = false
+		ws.timer.Stop()
+	}
+	ws.grantNextLocked()
+}
+
+// grantNextLocked grants the turn to the waiting chunk with the smallest virtual finish time,
+// unless a turn is currently granted.
+func (ws *writeScheduler) grantNextLocked() {
+	if ws.busy || len(ws.waiting) == 0 {
+		return
+	}
+	w := heap.Pop(&ws.waiting).(*waiter)
+	ws.vtime = w.finish
+	w.ch <- ws.grantLocked()
+}
+
+func (ws *writeScheduler) grantLocked() uint64 {
+	ws.busy = true
+	ws.turn++
+	ws.resetTimerLocked()
+	return ws.turn
+}
+
+func (ws *writeScheduler) resetTimerLocked() {
+	ws.turnDeadline = time.Now().Add(turnTimeout)
+	if ws.timer == nil {
+		ws.timer = time.AfterFunc(turnTimeout, ws.expire)
+		return
+	}
+	ws.timer.Reset(turnTimeout)
+}
+
+// expire is called by the timer, and ends the current turn.
+func (ws *writeScheduler) expire() {
+	ws.mx.Lock()
+	defer ws.mx.Unlock()
+	// The timer might have been reset or stopped while this function was waiting for the lock.
+	if !ws.busy || time.Now().Before(ws.turnDeadline) {
+		return
+	}
+	ws.busy = false
+	ws.grantNextLocked()
+}
+
+// waiterQueue is a priority queue of waiters, ordered by their virtual finish time.
+type waiterQueue []*waiter
+
+var _ heap.Interface = &waiterQueue{}
+
+func (q waiterQueue) Len() int { return len(q) }
+
+func (q waiterQueue) Less(i, j int) bool {
+	if q[i].finish != q[j].finish {
+		return q[i].finish < q[j].finish
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waiterQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waiterQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waiterQueue) Pop() any {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*q = old[:n-1]
+	return w
+}
@@ -0,0 +1,198 @@
+package yamux
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+
+	"github.com/libp2p/go-yamux/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerWeightedShares(t *testing.T) {
+	ws := newWriteScheduler()
+	// Hold a turn, so that both streams start out waiting.
+	turn, _, err := ws.acquire(0, new(uint64), network.StreamPriorityDefault, 1, nil, time.Time{})
+	require.NoError(t, err)
+
+	const total = 1000
+	var mx sync.Mutex
+	counts := make(map[network.StreamPriority]int)
+	var wg sync.WaitGroup
+	for _, prio := range []network.StreamPriority{network.StreamPriorityDefault, 4 * network.StreamPriorityDefault} {
+		wg.Add(1)
+		go func(prio network.StreamPriority) {
+			defer wg.Done()
+			// Simulate a stream that always has data to write.
+			var turn, lastFinish uint64
+			for {
+				var err error
+				turn, _, err = ws.acquire(turn, &lastFinish, prio, writeChunkSize, nil, time.Time{})
+				require.NoError(t, err)
+				mx.Lock()
+				done := counts[network.StreamPriorityDefault]+counts[4*network.StreamPriorityDefault] >= total
+				if !done {
+					counts[prio]++
+				}
+				mx.Unlock()
+				if done {
+					ws.release(turn)
+					return
+				}
+			}
+		}(prio)
+	}
+	time.Sleep(10 * time.Millisecond)
+	ws.release(turn)
+	wg.Wait()
+
+	ratio := float64(counts[4*network.StreamPriorityDefault]) / float64(counts[network.StreamPriorityDefault])
+	require.InDelta(t, 4, ratio, 0.5, "counts: %v", counts)
+}
+
+func TestSchedulerTurnTimeout(t *testing.T) {
+	ws := newWriteScheduler()
+	_, _, err := ws.acquire(0, new(uint64), network.StreamPriorityDefault, 1, nil, time.Time{})
+	require.NoError(t, err)
+
+	// The turn isn't released, but times out.
+	start := time.Now()
+	turn, _, err := ws.acquire(0, new(uint64), network.StreamPriorityDefault, 1, nil, time.Time{})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), turnTimeout)
+	ws.release(turn)
+}
+
+func TestSchedulerDeadline(t *testing.T) {
+	ws := newWriteScheduler()
+	turn, _, err := ws.acquire(0, new(uint64), network.StreamPriorityDefault, 1, nil, time.Time{})
+	require.NoError(t, err)
+
+	_, _, err = ws.acquire(0, new(uint64), network.StreamPriorityDefault, 1, nil, time.Now().Add(-time.Second))
+	require.ErrorIs(t, err, yamux.ErrTimeout)
+	closed := make(chan struct{})
+	close(closed)
+	_, _, err = ws.acquire(0, new(uint64), network.StreamPriorityDefault, 1, closed, time.Time{})
+	require.ErrorIs(t, err, yamux.ErrSessionShutdown)
+
+	// The waiters were removed, so the next turn is granted immediately.
+	ws.release(turn)
+	turn, _, err = ws.acquire(0, new(uint64), network.StreamPriorityDefault, 1, nil, time.Now().Add(time.Millisecond))
+	require.NoError(t, err)
+	ws.release(turn)
+}
+
+func TestSchedulerReusesTimer(t *testing.T) {
+	ws := newWriteScheduler()
+	turn, _, err := ws.acquire(0, new(uint64), network.StreamPriorityDefault, 1, nil, time.Time{})
+	require.NoError(t, err)
+	timer := ws.timer
+	ws.release(turn)
+	for i := 0; i < 3; i++ {
+		turn, _, err = ws.acquire(0, new(uint64), network.StreamPriorityDefault, 1, nil, time.Time{})
+		require.NoError(t, err)
+		require.Same(t, timer, ws.timer)
+		if i < 2 {
+			ws.release(turn)
+		}
+	}
+
+	// Stale timer callbacks don't end a turn that was just granted.
+	ws.expire()
+	ws.mx.Lock()
+	require.True(t, ws.busy)
+	ws.mx.Unlock()
+	ws.release(turn)
+}
+
+func newConnPair(t *testing.T) (network.MuxedConn, network.MuxedConn) {
+	t.Helper()
+	c1, c2 := net.Pipe()
+	client, err := DefaultTransport.NewConn(c1, false, nil)
+	require.NoError(t, err)
+	server, err := DefaultTransport.NewConn(c2, true, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+func TestBlockedStreamDoesntBlockOthers(t *testing.T) {
+	client, server := newConnPair(t)
+	go func() {
+		for {
+			str, err := server.AcceptStream()
+			if err != nil {
+				return
+			}
+			if str.(*stream).yamux().StreamID()%2 == 1 { // only read from the second stream
+				go func() {
+					b := make([]byte, 1024)
+					for {
+						if _, err := str.Read(b); err != nil {
+							return
+						}
+					}
+				}()
+			}
+		}
+	}()
+
+	// The server never reads from the first stream, so this write blocks once the flow
+	// control window is exhausted.
+	bulk, err := client.OpenStream(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, bulk.(network.PrioritizedStream).SetPriority(network.StreamPriorityLow))
+	go bulk.Write(make([]byte, 32<<20))
+	time.Sleep(100 * time.Millisecond)
+
+	str, err := client.OpenStream(context.Background())
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() {
+		_, err := str.Write(make([]byte, 100))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("write blocked")
+	}
+}
+
+func TestSingleWriterBypassesScheduler(t *testing.T) {
+	client, server := newConnPair(t)
+	go func() {
+		str, err := server.AcceptStream()
+		if err != nil {
+			return
+		}
+		io.Copy(io.Discard, str)
+	}()
+
+	str, err := client.OpenStream(context.Background())
+	require.NoError(t, err)
+	_, err = str.Write(make([]byte, 1<<20))
+	require.NoError(t, err)
+	sched := client.(*conn).sched
+	sched.mx.Lock()
+	defer sched.mx.Unlock()
+	require.Zero(t, sched.turn)
+	require.Zero(t, sched.writers.Load())
+}
+
+func TestSetPriority(t *testing.T) {
+	client, _ := newConnPair(t)
+	str, err := client.OpenStream(context.Background())
+	require.NoError(t, err)
+	require.Error(t, str.(network.PrioritizedStream).SetPriority(0))
+	require.NoError(t, str.(network.PrioritizedStream).SetPriority(network.StreamPriorityHigh))
+}
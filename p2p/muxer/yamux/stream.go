@@ -1,20 +1,52 @@
 package yamux
 
 import (
+	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
 
 	"github.com/libp2p/go-yamux/v4"
 )
 
 // stream implements mux.MuxedStream over yamux.Stream.
-type stream yamux.Stream
+type stream struct {
+	stream *yamux.Stream
+	conn   *conn
+
+	// windowLimit is the maximum window size, if the conn limits the window size per protocol
+	windowLimit atomic.Uint32
+	// spanBound is set once the stream's span knows the stream, see streamSpan
+	spanBound atomic.Bool
+
+	priority      atomic.Uint32
+	writeDeadline atomic.Pointer[time.Time]
+	// lastFinish is the virtual finish time of the last chunk written, see writeScheduler.
+	lastFinish uint64
+}
+
+var (
+	_ network.MuxedStream       = &stream{}
+	_ network.PrioritizedStream = &stream{}
+)
 
-var _ network.MuxedStream = &stream{}
+func newStream(s *yamux.Stream, c *conn) *stream {
+	str := &stream{stream: s, conn: c}
+	str.priority.Store(uint32(network.StreamPriorityDefault))
+	if c.windows != nil {
+		str.windowLimit.Store(c.windows.config.MaxStreamWindowSize)
+	}
+	return str
+}
 
 func (s *stream) Read(b []byte) (n int, err error) {
-	n, err = s.yamux().Read(b)
+	if s.conn.windows != nil && !s.spanBound.Load() {
+		n, err = s.readAndGrow(b)
+	} else {
+		n, err = s.yamux().Read(b)
+	}
 	if err == yamux.ErrStreamReset {
 		err = network.ErrReset
 	}
@@ -22,13 +54,80 @@ func (s *stream) Read(b []byte) (n int, err error) {
 	return n, err
 }
 
+// readAndGrow reads while holding the conn's growMx, so that the stream's span can learn
+// which stream it belongs to if yamux grows the window, see streamSpan.
+func (s *stream) readAndGrow(b []byte) (int, error) {
+	// Wait for data without consuming it. yamux only grows the window after consuming data,
+	// so growMx isn't held while waiting.
+	if _, err := s.yamux().Read(nil); err != nil {
+		return 0, err
+	}
+	s.conn.growMx.Lock()
+	defer s.conn.growMx.Unlock()
+	s.conn.growing.Store(s)
+	defer s.conn.growing.Store(nil)
+	return s.yamux().Read(b)
+}
+
+// Write writes b in chunks, taking turns with the other streams on the connection.
 func (s *stream) Write(b []byte) (n int, err error) {
-	n, err = s.yamux().Write(b)
-	if err == yamux.ErrStreamReset {
-		err = network.ErrReset
+	sched := s.conn.sched
+	sched.writers.Add(1)
+	var turn uint64
+	defer func() {
+		if turn != 0 {
+			sched.release(turn)
+		}
+		sched.writers.Add(-1)
+	}()
+	for n < len(b) {
+		size := len(b) - n
+		if sched.writers.Load() == 1 {
+			// No other stream is writing, so there's no need to take turns.
+			if turn != 0 {
+				sched.release(turn)
+				turn = 0
+			}
+			if size > maxWriteChunkSize {
+				size = maxWriteChunkSize
+			}
+		} else {
+			var deadline time.Time
+			if d := s.writeDeadline.Load(); d != nil {
+				deadline = *d
+			}
+			prio := network.StreamPriority(s.priority.Load())
+			turn, size, err = sched.acquire(turn, &s.lastFinish, prio, size, s.conn.yamux().CloseChan(), deadline)
+			if err != nil {
+				return n, err
+			}
+		}
+		m, err := s.yamux().Write(b[n : n+size])
+		n += m
+		if err != nil {
+			if err == yamux.ErrStreamReset {
+				err = network.ErrReset
+			}
+			return n, err
+		}
 	}
+	return n, nil
+}
 
-	return n, err
+// SetPriority sets the priority of the stream, relative to the other streams on the connection.
+func (s *stream) SetPriority(p network.StreamPriority) error {
+	if p == 0 {
+		return errors.New("invalid stream priority")
+	}
+	s.priority.Store(uint32(p))
+	return nil
+}
+
+// SetProtocol applies the window size configured for protocol p, see WithProtocolWindowSize.
+func (s *stream) SetProtocol(p protocol.ID) {
+	if s.conn.windows != nil {
+		s.windowLimit.Store(s.conn.windows.windowSize(p))
+	}
 }
 
 func (s *stream) Close() error {
@@ -48,6 +147,7 @@ func (s *stream) CloseWrite() error {
 }
 
 func (s *stream) SetDeadline(t time.Time) error {
+	s.writeDeadline.Store(&t)
 	return s.yamux().SetDeadline(t)
 }
 
@@ -56,9 +156,10 @@ func (s *stream) SetReadDeadline(t time.Time) error {
 }
 
 func (s *stream) SetWriteDeadline(t time.Time) error {
+	s.writeDeadline.Store(&t)
 	return s.yamux().SetWriteDeadline(t)
 }
 
 func (s *stream) yamux() *yamux.Stream {
-	return (*yamux.Stream)(s)
+	return s.stream
 }
@@ -1,11 +1,13 @@
 package yamux
 
 import (
+	"fmt"
 	"io"
 	"math"
 	"net"
 
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
 
 	"github.com/libp2p/go-yamux/v4"
 )
@@ -35,6 +37,13 @@ func init() {
 
 // Transport implements mux.Multiplexer that constructs
 // yamux-backed muxed connections.
+//
+// The receive window of a stream starts at the InitialStreamWindowSize of the config. It is
+// doubled whenever the peer sends fast enough to use up the window within a few round trips,
+// so that only streams that are limited by their window grow it. Memory for the window is
+// reserved from the resource manager as the window grows.
+// Streams grow their window up to the MaxStreamWindowSize of the config. Use
+// NewWindowedTransport to limit the window size per protocol.
 type Transport yamux.Config
 
 var _ network.Multiplexer = &Transport{}
@@ -61,3 +70,111 @@ func (t *Transport) NewConn(nc net.Conn, isServer bool, scope network.PeerScope)
 func (t *Transport) Config() *yamux.Config {
 	return (*yamux.Config)(t)
 }
+
+// WindowedTransport is a yamux transport that limits the receive window of streams
+// depending on their protocol, see WithProtocolWindowSize.
+type WindowedTransport struct {
+	config yamux.Config
+	// protocolWindows contains the maximum window sizes of streams of a protocol
+	protocolWindows map[protocol.ID]uint32
+}
+
+var _ network.Multiplexer = &WindowedTransport{}
+
+// Option is an option for the windowed yamux transport.
+type Option func(*WindowedTransport) error
+
+// WithProtocolWindowSize sets the maximum receive window size for streams of protocol p.
+// The window size is applied once the protocol of the stream is set. Since windows grow in
+// powers of two, the window of a stream might not grow all the way up to size.
+// The window of a stream that already grew beyond size is not shrunk.
+func WithProtocolWindowSize(p protocol.ID, size uint32) Option {
+	return func(t *WindowedTransport) error {
+		if t.protocolWindows == nil {
+			t.protocolWindows = make(map[protocol.ID]uint32)
+		}
+		t.protocolWindows[p] = size
+		return nil
+	}
+}
+
+// NewWindowedTransport creates a new yamux transport with the given config.
+// If config is nil, the config of DefaultTransport is used.
+// Streams of protocols without a window size use the MaxStreamWindowSize of the config.
+func NewWindowedTransport(config *yamux.Config, opts ...Option) (*WindowedTransport, error) {
+	if config == nil {
+		config = DefaultTransport.Config()
+	}
+	t := &WindowedTransport{config: *config}
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+	if err := yamux.VerifyConfig(&t.config); err != nil {
+		return nil, err
+	}
+	for p, size := range t.protocolWindows {
+		if size < t.config.InitialStreamWindowSize {
+			return nil, fmt.Errorf("window size for protocol %s is smaller than the initial window size", p)
+		}
+	}
+	return t, nil
+}
+
+func (t *WindowedTransport) NewConn(nc net.Conn, isServer bool, scope network.PeerScope) (network.MuxedConn, error) {
+	c := &conn{sched: newWriteScheduler(), windows: t}
+	// The session allows every stream to grow up to the largest window size. The window
+	// size of a stream is limited by refusing to reserve memory for it, see streamSpan.
+	config := t.config
+	config.MaxStreamWindowSize = t.maxWindowSize()
+	newSpan := func() (yamux.MemoryManager, error) {
+		var span network.ResourceScopeSpan
+		if scope != nil {
+			var err error
+			span, err = scope.BeginSpan()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &streamSpan{conn: c, span: span}, nil
+	}
+
+	var s *yamux.Session
+	var err error
+	if isServer {
+		s, err = yamux.Server(nc, &config, newSpan)
+	} else {
+		s, err = yamux.Client(nc, &config, newSpan)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.session = s
+	return c, nil
+}
+
+// Config returns the yamux config used for new connections.
+// It must not be modified after the first connection was created.
+func (t *WindowedTransport) Config() *yamux.Config {
+	return &t.config
+}
+
+// windowSize returns the maximum window size for streams of protocol p.
+func (t *WindowedTransport) windowSize(p protocol.ID) uint32 {
+	if size, ok := t.protocolWindows[p]; ok {
+		return size
+	}
+	return t.config.MaxStreamWindowSize
+}
+
+// maxWindowSize returns the largest window size of any stream.
+func (t *WindowedTransport) maxWindowSize() uint32 {
+	max := t.config.MaxStreamWindowSize
+	for _, size := range t.protocolWindows {
+		if size > max {
+			max = size
+		}
+	}
+	return max
+}
@@ -2,8 +2,10 @@ package yamux
 
 import (
 	"testing"
+	"time"
 
 	tmux "github.com/libp2p/go-libp2p/p2p/muxer/testsuite"
+	"github.com/libp2p/go-libp2p/p2p/net/netem"
 )
 
 func TestDefaultTransport(t *testing.T) {
@@ -13,3 +15,21 @@ func TestDefaultTransport(t *testing.T) {
 
 	tmux.SubtestAll(t, DefaultTransport)
 }
+
+func TestTransportWithProtocolWindows(t *testing.T) {
+	delete(tmux.Subtests, "github.com/libp2p/go-libp2p-testing/suites/mux.SubtestStress1Conn1000Stream10Msg")
+
+	tpt, err := NewWindowedTransport(nil, WithProtocolWindowSize("/foo", 1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmux.SubtestAll(t, tpt)
+}
+
+func TestEmulatedLink(t *testing.T) {
+	tmux.SubtestEmulatedLink(t, DefaultTransport, netem.Config{RTT: 10 * time.Millisecond, Jitter: 2 * time.Millisecond, Bandwidth: 10e6, Loss: 0.01})
+}
+
+func BenchmarkDefaultTransport(b *testing.B) {
+	tmux.BenchmarkAll(b, DefaultTransport)
+}
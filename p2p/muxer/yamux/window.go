@@ -0,0 +1,74 @@
+package yamux
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/network"
+
+	"github.com/libp2p/go-yamux/v4"
+)
+
+var errWindowLimit = errors.New("stream window size limit reached")
+
+// streamSpan is the memory manager of a stream on a conn of a WindowedTransport. yamux reserves
+// memory from it whenever it grows the receive window of the stream, so it limits the window
+// size by refusing reservations.
+//
+// yamux doesn't tell which stream a span belongs to. It only grows windows while a stream
+// is reading, so the first time the window grows, the span learns its stream from the conn,
+// see stream.Read. This is an implementation detail of yamux, TestWindowGrowsWhileReading fails
+// if it changes.
+type streamSpan struct {
+	conn *conn
+	// span is nil if the connection doesn't have a resource scope
+	span network.ResourceScopeSpan
+
+	mx       sync.Mutex
+	reserved int
+	// stream is nil until the window grows for the first time
+	stream *stream
+}
+
+var _ yamux.MemoryManager = &streamSpan{}
+
+func (s *streamSpan) ReserveMemory(size int, prio uint8) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	// The initial window is always granted.
+	if s.reserved > 0 {
+		if s.stream == nil {
+			s.stream = s.conn.growing.Load()
+			if s.stream == nil {
+				// The window didn't grow while the stream was reading.
+				return errWindowLimit
+			}
+			s.stream.spanBound.Store(true)
+		}
+		if s.reserved+size > int(s.stream.windowLimit.Load()) {
+			return errWindowLimit
+		}
+	}
+	if s.span != nil {
+		if err := s.span.ReserveMemory(size, prio); err != nil {
+			return err
+		}
+	}
+	s.reserved += size
+	return nil
+}
+
+func (s *streamSpan) ReleaseMemory(size int) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.reserved -= size
+	if s.span != nil {
+		s.span.ReleaseMemory(size)
+	}
+}
+
+func (s *streamSpan) Done() {
+	if s.span != nil {
+		s.span.Done()
+	}
+}
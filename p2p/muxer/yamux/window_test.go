@@ -0,0 +1,137 @@
+package yamux
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolWindowSize(t *testing.T) {
+	const (
+		small protocol.ID = "/small"
+		bulk  protocol.ID = "/bulk"
+	)
+	tpt, err := NewWindowedTransport(nil, WithProtocolWindowSize(small, 256*1024), WithProtocolWindowSize(bulk, 32<<20))
+	require.NoError(t, err)
+	require.Equal(t, uint32(256*1024), tpt.windowSize(small))
+	require.Equal(t, uint32(32<<20), tpt.windowSize(bulk))
+	require.Equal(t, DefaultTransport.Config().MaxStreamWindowSize, tpt.windowSize("/other"))
+	require.Equal(t, uint32(32<<20), tpt.maxWindowSize())
+
+	c1, c2 := net.Pipe()
+	client, err := tpt.NewConn(c1, false, nil)
+	require.NoError(t, err)
+	defer client.Close()
+	server, err := tpt.NewConn(c2, true, nil)
+	require.NoError(t, err)
+	defer server.Close()
+
+	str, err := client.OpenStream(context.Background())
+	require.NoError(t, err)
+	_, err = str.Write([]byte("foo"))
+	require.NoError(t, err)
+	accepted, err := server.AcceptStream()
+	require.NoError(t, err)
+
+	c := server.(*conn)
+	s := accepted.(*stream)
+	span := &streamSpan{conn: c}
+	require.NoError(t, span.ReserveMemory(256*1024, 255))
+
+	// The window only grows while a stream is reading.
+	require.ErrorIs(t, span.ReserveMemory(256*1024, 128), errWindowLimit)
+
+	c.growing.Store(s)
+	s.SetProtocol(small)
+	require.ErrorIs(t, span.ReserveMemory(256*1024, 128), errWindowLimit)
+	require.Equal(t, s, span.stream)
+	require.True(t, s.spanBound.Load())
+	c.growing.Store(nil)
+
+	s.SetProtocol(bulk)
+	require.NoError(t, span.ReserveMemory(256*1024, 128))
+	require.Equal(t, 512*1024, span.reserved)
+}
+
+func TestProtocolWindowSizeTransfer(t *testing.T) {
+	tpt, err := NewWindowedTransport(nil, WithProtocolWindowSize("/small", 256*1024))
+	require.NoError(t, err)
+
+	c1, c2 := net.Pipe()
+	client, err := tpt.NewConn(c1, false, nil)
+	require.NoError(t, err)
+	defer client.Close()
+	server, err := tpt.NewConn(c2, true, nil)
+	require.NoError(t, err)
+	defer server.Close()
+
+	const size = 8 << 20
+	for _, p := range []protocol.ID{"/small", "/other"} {
+		str, err := client.OpenStream(context.Background())
+		require.NoError(t, err)
+		go func() {
+			str.Write(make([]byte, size))
+			str.Close()
+		}()
+		accepted, err := server.AcceptStream()
+		require.NoError(t, err)
+		accepted.(*stream).SetProtocol(p)
+		n, err := io.Copy(io.Discard, accepted)
+		require.NoError(t, err)
+		require.EqualValues(t, size, n)
+	}
+}
+
+func TestProtocolWindowSizeTooSmall(t *testing.T) {
+	_, err := NewWindowedTransport(nil, WithProtocolWindowSize("/small", 1024))
+	require.Error(t, err)
+}
+
+// latencyConn delays every write, so that yamux measures a non-zero RTT and grows windows.
+type latencyConn struct {
+	net.Conn
+	delay time.Duration
+}
+
+func (c *latencyConn) Write(b []byte) (int, error) {
+	time.Sleep(c.delay)
+	return c.Conn.Write(b)
+}
+
+// yamux doesn't tell the memory manager which stream it belongs to. We rely on it only growing
+// windows while the stream is reading (see stream.readAndGrow). If it grew windows elsewhere,
+// the spans would never learn their stream, and the windows would never grow.
+func TestWindowGrowsWhileReading(t *testing.T) {
+	tpt, err := NewWindowedTransport(nil, WithProtocolWindowSize("/bulk", 16<<20))
+	require.NoError(t, err)
+
+	c1, c2 := net.Pipe()
+	client, err := tpt.NewConn(&latencyConn{Conn: c1, delay: time.Millisecond}, false, nil)
+	require.NoError(t, err)
+	defer client.Close()
+	server, err := tpt.NewConn(&latencyConn{Conn: c2, delay: time.Millisecond}, true, nil)
+	require.NoError(t, err)
+	defer server.Close()
+
+	str, err := client.OpenStream(context.Background())
+	require.NoError(t, err)
+	const size = 8 << 20
+	go func() {
+		str.Write(make([]byte, size))
+		str.Close()
+	}()
+	accepted, err := server.AcceptStream()
+	require.NoError(t, err)
+	s := accepted.(*stream)
+	s.SetProtocol("/bulk")
+	n, err := io.Copy(io.Discard, s)
+	require.NoError(t, err)
+	require.EqualValues(t, size, n)
+	require.True(t, s.spanBound.Load(), "window didn't grow while the stream was reading")
+}
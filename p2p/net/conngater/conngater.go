@@ -271,6 +271,22 @@ func (cg *BasicConnectionGater) ListBlockedSubnets() []*net.IPNet {
 	return result
 }
 
+var _ connmgr.KeySuccessionHandler = (*BasicConnectionGater)(nil)
+
+// PeerSucceeded blocks the successor of p if p is blocked, so that peers can't get unblocked by
+// announcing a new key.
+func (cg *BasicConnectionGater) PeerSucceeded(p, successor peer.ID) {
+	cg.RLock()
+	_, blocked := cg.blockedPeers[p]
+	cg.RUnlock()
+	if !blocked {
+		return
+	}
+	if err := cg.BlockPeer(successor); err != nil {
+		log.Errorf("error blocking successor %s of blocked peer %s: %s", successor, p, err)
+	}
+}
+
 // ConnectionGater interface
 var _ connmgr.ConnectionGater = (*BasicConnectionGater)(nil)
 
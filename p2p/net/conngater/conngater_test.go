@@ -372,3 +372,28 @@ func (cma *mockConnMultiaddrs) LocalMultiaddr() ma.Multiaddr {
 func (cma *mockConnMultiaddrs) RemoteMultiaddr() ma.Multiaddr {
 	return cma.remote
 }
+
+func TestConnectionGaterPeerSucceeded(t *testing.T) {
+	cg, err := NewBasicConnectionGater(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peerA := peer.ID("A")
+	peerB := peer.ID("B")
+	peerC := peer.ID("C")
+	peerD := peer.ID("D")
+
+	if err := cg.BlockPeer(peerA); err != nil {
+		t.Fatal(err)
+	}
+	cg.PeerSucceeded(peerA, peerB)
+	if cg.InterceptPeerDial(peerB) {
+		t.Fatal("expected the successor of a blocked peer to be blocked")
+	}
+
+	cg.PeerSucceeded(peerC, peerD)
+	if !cg.InterceptPeerDial(peerD) {
+		t.Fatal("expected the successor of an unblocked peer to not be blocked")
+	}
+}
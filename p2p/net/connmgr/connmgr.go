@@ -239,6 +239,51 @@ func (cm *BasicConnMgr) IsProtected(id peer.ID, tag string) (protected bool) {
 	return protected
 }
 
+var _ connmgr.KeySuccessionHandler = (*BasicConnMgr)(nil)
+
+// PeerSucceeded transfers the protections and tags of p to its successor.
+// Tags that are already set on the successor are not overwritten. Decaying tags are not transferred.
+func (cm *BasicConnMgr) PeerSucceeded(p, successor peer.ID) {
+	cm.plk.Lock()
+	if tags, ok := cm.protected[p]; ok {
+		successorTags, ok := cm.protected[successor]
+		if !ok {
+			successorTags = make(map[string]struct{}, len(tags))
+			cm.protected[successor] = successorTags
+		}
+		for tag := range tags {
+			successorTags[tag] = struct{}{}
+		}
+	}
+	cm.plk.Unlock()
+
+	s := cm.segments.get(p)
+	s.Lock()
+	var tags map[string]int
+	if pi, ok := s.peers[p]; ok && len(pi.tags) > 0 {
+		tags = make(map[string]int, len(pi.tags))
+		for t, v := range pi.tags {
+			tags[t] = v
+		}
+	}
+	s.Unlock()
+	if len(tags) == 0 {
+		return
+	}
+
+	s = cm.segments.get(successor)
+	s.Lock()
+	defer s.Unlock()
+	pi := s.tagInfoFor(successor, cm.clock.Now())
+	for t, v := range tags {
+		if _, ok := pi.tags[t]; ok {
+			continue
+		}
+		pi.tags[t] = v
+		pi.value += v
+	}
+}
+
 // peerInfo stores metadata for a given peer.
 type peerInfo struct {
 	id       peer.ID
@@ -966,3 +966,29 @@ func TestSafeConcurrency(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+func TestPeerSucceeded(t *testing.T) {
+	cm, err := NewConnManager(19, 20, WithGracePeriod(0), WithSilencePeriod(time.Hour))
+	require.NoError(t, err)
+	defer cm.Close()
+
+	p := tu.RandPeerIDFatal(t)
+	successor := tu.RandPeerIDFatal(t)
+	cm.Protect(p, "foo")
+	cm.TagPeer(p, "a", 10)
+	cm.TagPeer(p, "b", 20)
+	cm.TagPeer(successor, "b", 5)
+
+	cm.PeerSucceeded(p, successor)
+	require.True(t, cm.IsProtected(successor, "foo"))
+	require.True(t, cm.IsProtected(p, "foo"))
+	info := cm.GetTagInfo(successor)
+	require.Equal(t, map[string]int{"a": 10, "b": 5}, info.Tags)
+	require.Equal(t, 15, info.Value)
+
+	// Nothing to transfer.
+	other := tu.RandPeerIDFatal(t)
+	cm.PeerSucceeded(tu.RandPeerIDFatal(t), other)
+	require.False(t, cm.IsProtected(other, ""))
+	require.Nil(t, cm.GetTagInfo(other))
+}
@@ -0,0 +1,184 @@
+package membership
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/record"
+	"github.com/libp2p/go-libp2p/p2p/net/membership/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+//go:generate protoc --go_out=. --go_opt=Mpb/membership.proto=./pb pb/membership.proto
+
+func init() {
+	record.RegisterType(&Certificate{})
+	record.RegisterType(&RevocationList{})
+}
+
+// CertificateEnvelopeDomain is the domain string used for membership certificates contained in an Envelope.
+const CertificateEnvelopeDomain = "libp2p-membership-certificate"
+
+// CertificateEnvelopePayloadType is the type hint used to identify membership certificates in an Envelope.
+// There's no multicodec for membership certificates, so this is a code from the private use range.
+var CertificateEnvelopePayloadType = []byte{0x80, 0x80, 0xc0, 0x02}
+
+// RevocationListEnvelopeDomain is the domain string used for revocation lists contained in an Envelope.
+const RevocationListEnvelopeDomain = "libp2p-membership-revocation-list"
+
+// RevocationListEnvelopePayloadType is the type hint used to identify revocation lists in an Envelope.
+// There's no multicodec for revocation lists, so this is a code from the private use range.
+var RevocationListEnvelopePayloadType = []byte{0x80, 0x80, 0xc0, 0x03}
+
+var _ record.Record = (*Certificate)(nil)
+
+// Certificate certifies that a peer is a member of a private network.
+// It is sealed in an Envelope signed by the network's certificate authority (CA), see Issue.
+type Certificate struct {
+	// Network is the name of the private network.
+	Network string
+	// Peer is the ID of the member.
+	Peer peer.ID
+	// Serial is the serial number of the certificate, used to revoke it.
+	Serial uint64
+	// NotBefore and NotAfter define the validity period of the certificate.
+	// Timestamps are stored with a precision of one second.
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// Issue seals a membership certificate in an Envelope signed by the key of the CA.
+func Issue(caKey ic.PrivKey, cert *Certificate) (*record.Envelope, error) {
+	if cert.Network == "" {
+		return nil, errors.New("missing network name")
+	}
+	if cert.Peer == "" {
+		return nil, errors.New("missing peer ID")
+	}
+	if !cert.NotAfter.After(cert.NotBefore) {
+		return nil, errors.New("certificate expires before it becomes valid")
+	}
+	return record.Seal(cert, caKey)
+}
+
+// Valid says if the certificate is valid at the given time.
+func (c *Certificate) Valid(now time.Time) bool {
+	return !now.Before(c.NotBefore) && !now.After(c.NotAfter)
+}
+
+// Domain is used when signing and validating Certificates contained in Envelopes.
+func (c *Certificate) Domain() string {
+	return CertificateEnvelopeDomain
+}
+
+// Codec is a binary identifier for the Certificate type.
+func (c *Certificate) Codec() []byte {
+	return CertificateEnvelopePayloadType
+}
+
+// UnmarshalRecord parses a Certificate from a byte slice.
+func (c *Certificate) UnmarshalRecord(b []byte) error {
+	if c == nil {
+		return fmt.Errorf("cannot unmarshal Certificate to nil receiver")
+	}
+	var msg pb.MembershipCertificate
+	if err := proto.Unmarshal(b, &msg); err != nil {
+		return err
+	}
+	var id peer.ID
+	if err := id.UnmarshalBinary(msg.PeerId); err != nil {
+		return err
+	}
+	*c = Certificate{
+		Network:   msg.Network,
+		Peer:      id,
+		Serial:    msg.Serial,
+		NotBefore: time.Unix(msg.NotBefore, 0),
+		NotAfter:  time.Unix(msg.NotAfter, 0),
+	}
+	return nil
+}
+
+// MarshalRecord serializes a Certificate to a byte slice.
+func (c *Certificate) MarshalRecord() ([]byte, error) {
+	idBytes, err := c.Peer.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(&pb.MembershipCertificate{
+		Network:   c.Network,
+		PeerId:    idBytes,
+		Serial:    c.Serial,
+		NotBefore: c.NotBefore.Unix(),
+		NotAfter:  c.NotAfter.Unix(),
+	})
+}
+
+var _ record.SequencedRecord = (*RevocationList)(nil)
+
+// RevocationList lists the serial numbers of revoked certificates.
+// It is sealed in an Envelope signed by the network's CA, see IssueRevocationList.
+// A revocation list replaces all previous revocation lists, so it needs to contain
+// all certificates that were revoked and haven't expired yet.
+type RevocationList struct {
+	// Network is the name of the private network.
+	Network string
+	// Seq is a monotonically-increasing sequence counter that's used to order
+	// RevocationLists in time.
+	Seq uint64
+	// Revoked contains the serial numbers of the revoked certificates.
+	Revoked []uint64
+}
+
+// IssueRevocationList seals a revocation list in an Envelope signed by the key of the CA.
+func IssueRevocationList(caKey ic.PrivKey, rl *RevocationList) (*record.Envelope, error) {
+	if rl.Network == "" {
+		return nil, errors.New("missing network name")
+	}
+	return record.Seal(rl, caKey)
+}
+
+// SequenceNumber returns the Seq field of the RevocationList.
+func (rl *RevocationList) SequenceNumber() uint64 {
+	return rl.Seq
+}
+
+// Domain is used when signing and validating RevocationLists contained in Envelopes.
+func (rl *RevocationList) Domain() string {
+	return RevocationListEnvelopeDomain
+}
+
+// Codec is a binary identifier for the RevocationList type.
+func (rl *RevocationList) Codec() []byte {
+	return RevocationListEnvelopePayloadType
+}
+
+// UnmarshalRecord parses a RevocationList from a byte slice.
+func (rl *RevocationList) UnmarshalRecord(b []byte) error {
+	if rl == nil {
+		return fmt.Errorf("cannot unmarshal RevocationList to nil receiver")
+	}
+	var msg pb.RevocationList
+	if err := proto.Unmarshal(b, &msg); err != nil {
+		return err
+	}
+	*rl = RevocationList{
+		Network: msg.Network,
+		Seq:     msg.Seq,
+		Revoked: msg.RevokedSerials,
+	}
+	return nil
+}
+
+// MarshalRecord serializes a RevocationList to a byte slice.
+func (rl *RevocationList) MarshalRecord() ([]byte, error) {
+	return proto.Marshal(&pb.RevocationList{
+		Network:        rl.Network,
+		Seq:            rl.Seq,
+		RevokedSerials: rl.Revoked,
+	})
+}
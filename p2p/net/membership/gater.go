@@ -0,0 +1,245 @@
+// Package membership implements private networks in which membership is proven by certificates.
+//
+// Unlike a private network protected by a pre-shared key (see libp2p.PrivateNetwork), every member
+// holds a Certificate for its own peer ID, issued by the network's certificate authority (CA).
+// Members exchange their certificates during the security handshake, and the Gater rejects
+// connections to peers without a valid certificate. Individual members can be removed from the
+// network by revoking their certificates, without having to re-key all other members.
+//
+// Certificates are exchanged by the Noise and TLS security transports, and therefore work with all
+// transports using them, including QUIC and WebTransport. To set up a node, pass the Gater as the
+// connection gater:
+//
+//	gater, err := membership.NewGater(caPubKey, "my-network", cert)
+//	h, err := libp2p.New(libp2p.Identity(priv), libp2p.ConnectionGater(gater))
+package membership
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/connmgr"
+	"github.com/libp2p/go-libp2p/core/control"
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/record"
+	"github.com/libp2p/go-libp2p/core/sec"
+
+	logging "github.com/ipfs/go-log/v2"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+var log = logging.Logger("net/membership")
+
+// Option is an option for the Gater.
+type Option func(*Gater) error
+
+// WithConnectionGater additionally applies the rules of another connection gater.
+// A connection is only allowed if it is allowed by both gaters.
+func WithConnectionGater(g connmgr.ConnectionGater) Option {
+	return func(m *Gater) error {
+		m.next = g
+		return nil
+	}
+}
+
+// Gater is a connmgr.ConnectionGater that only allows connections to members of a private network.
+//
+// It implements sec.HandshakeCredentials to exchange membership certificates during the security
+// handshake. Certificates received from peers are checked in InterceptSecured, which rejects peers
+// that didn't present a certificate, or whose certificate is expired or revoked.
+type Gater struct {
+	caKey     ic.PubKey
+	network   string
+	localCert []byte
+	next      connmgr.ConnectionGater
+
+	mx sync.Mutex
+	// certs contains the certificates received from peers.
+	// Only certificates issued by the CA are stored, so the map is bounded by the size of the network.
+	certs         map[peer.ID]*Certificate
+	revocationSeq uint64
+	revoked       map[uint64]struct{}
+}
+
+var _ connmgr.ConnectionGater = (*Gater)(nil)
+var _ sec.HandshakeCredentials = (*Gater)(nil)
+
+// NewGater creates a new Gater for the private network with the given name.
+// caKey is the public key of the network's CA, and cert is the certificate of the local node.
+func NewGater(caKey ic.PubKey, network string, cert *record.Envelope, opts ...Option) (*Gater, error) {
+	g := &Gater{
+		caKey:   caKey,
+		network: network,
+		certs:   make(map[peer.ID]*Certificate),
+		revoked: make(map[uint64]struct{}),
+	}
+	if _, err := g.verifyCertificate(cert); err != nil {
+		return nil, fmt.Errorf("invalid local certificate: %w", err)
+	}
+	var err error
+	g.localCert, err = cert.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		if err := opt(g); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func (g *Gater) verifyCertificate(env *record.Envelope) (*Certificate, error) {
+	if !env.PublicKey.Equals(g.caKey) {
+		return nil, errors.New("certificate not issued by the CA")
+	}
+	r, err := env.Record()
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := r.(*Certificate)
+	if !ok {
+		return nil, fmt.Errorf("unexpected record type: %T", r)
+	}
+	if cert.Network != g.network {
+		return nil, fmt.Errorf("certificate issued for network %q", cert.Network)
+	}
+	return cert, nil
+}
+
+// UpdateRevocationList replaces the revocation list. The list must be issued by the CA, and must
+// have a higher sequence number than the current list.
+//
+// Existing connections are not closed. UpdateRevocationList returns the peers whose certificates
+// were revoked by the list, so that the caller can close connections to them.
+func (g *Gater) UpdateRevocationList(env *record.Envelope) ([]peer.ID, error) {
+	if !env.PublicKey.Equals(g.caKey) {
+		return nil, errors.New("revocation list not issued by the CA")
+	}
+	r, err := env.Record()
+	if err != nil {
+		return nil, err
+	}
+	rl, ok := r.(*RevocationList)
+	if !ok {
+		return nil, fmt.Errorf("unexpected record type: %T", r)
+	}
+	if rl.Network != g.network {
+		return nil, fmt.Errorf("revocation list issued for network %q", rl.Network)
+	}
+
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	if rl.Seq <= g.revocationSeq {
+		return nil, errors.New("revocation list is not newer than the current list")
+	}
+	g.revocationSeq = rl.Seq
+	g.revoked = make(map[uint64]struct{}, len(rl.Revoked))
+	for _, serial := range rl.Revoked {
+		g.revoked[serial] = struct{}{}
+	}
+	var peers []peer.ID
+	for p, cert := range g.certs {
+		if _, ok := g.revoked[cert.Serial]; ok {
+			peers = append(peers, p)
+		}
+	}
+	return peers, nil
+}
+
+// LocalCredential returns the certificate of the local node.
+func (g *Gater) LocalCredential() []byte {
+	return g.localCert
+}
+
+// HandleRemoteCredential stores the certificate sent by a peer. It rejects certificates that were
+// not issued by the CA for this peer. Whether the certificate is currently valid is checked in
+// InterceptSecured.
+func (g *Gater) HandleRemoteCredential(p peer.ID, credential []byte) error {
+	if credential == nil {
+		return nil
+	}
+	env, _, err := record.ConsumeEnvelope(credential, CertificateEnvelopeDomain)
+	if err != nil {
+		return err
+	}
+	cert, err := g.verifyCertificate(env)
+	if err != nil {
+		return err
+	}
+	if cert.Peer != p {
+		return fmt.Errorf("certificate issued for peer %s", cert.Peer)
+	}
+	g.mx.Lock()
+	g.certs[p] = cert
+	g.mx.Unlock()
+	return nil
+}
+
+// isMember says if the peer presented a certificate that is valid and not revoked.
+func (g *Gater) isMember(p peer.ID) bool {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	cert, ok := g.certs[p]
+	if !ok {
+		log.Debugw("rejecting peer without membership certificate", "peer", p)
+		return false
+	}
+	if !cert.Valid(time.Now()) {
+		log.Debugw("rejecting peer with expired membership certificate", "peer", p, "serial", cert.Serial)
+		return false
+	}
+	if _, revoked := g.revoked[cert.Serial]; revoked {
+		log.Debugw("rejecting peer with revoked membership certificate", "peer", p, "serial", cert.Serial)
+		return false
+	}
+	return true
+}
+
+// InterceptPeerDial rejects dials to peers whose certificate is known to be revoked.
+func (g *Gater) InterceptPeerDial(p peer.ID) (allow bool) {
+	if g.isRevoked(p) {
+		return false
+	}
+	return g.next == nil || g.next.InterceptPeerDial(p)
+}
+
+func (g *Gater) isRevoked(p peer.ID) bool {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	cert, ok := g.certs[p]
+	if !ok {
+		return false
+	}
+	_, revoked := g.revoked[cert.Serial]
+	return revoked
+}
+
+func (g *Gater) InterceptAddrDial(p peer.ID, a ma.Multiaddr) (allow bool) {
+	return g.next == nil || g.next.InterceptAddrDial(p, a)
+}
+
+func (g *Gater) InterceptAccept(cma network.ConnMultiaddrs) (allow bool) {
+	return g.next == nil || g.next.InterceptAccept(cma)
+}
+
+// InterceptSecured only allows connections to peers that presented a valid certificate.
+func (g *Gater) InterceptSecured(dir network.Direction, p peer.ID, cma network.ConnMultiaddrs) (allow bool) {
+	if !g.isMember(p) {
+		return false
+	}
+	return g.next == nil || g.next.InterceptSecured(dir, p, cma)
+}
+
+func (g *Gater) InterceptUpgraded(c network.Conn) (allow bool, reason control.DisconnectReason) {
+	if g.next == nil {
+		return true, 0
+	}
+	return g.next.InterceptUpgraded(c)
+}
@@ -0,0 +1,255 @@
+package membership
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/record"
+	tpt "github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/p2p/transport/quicreuse"
+	libp2pwebtransport "github.com/libp2p/go-libp2p/p2p/transport/webtransport"
+
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+)
+
+const testNetwork = "test-network"
+
+func newCA(t *testing.T) ic.PrivKey {
+	t.Helper()
+	priv, _, err := ic.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	return priv
+}
+
+func newPeer(t *testing.T) peer.ID {
+	t.Helper()
+	_, pub, err := ic.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	id, err := peer.IDFromPublicKey(pub)
+	require.NoError(t, err)
+	return id
+}
+
+func issue(t *testing.T, ca ic.PrivKey, p peer.ID, serial uint64, notAfter time.Time) *record.Envelope {
+	t.Helper()
+	env, err := Issue(ca, &Certificate{
+		Network:   testNetwork,
+		Peer:      p,
+		Serial:    serial,
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  notAfter,
+	})
+	require.NoError(t, err)
+	return env
+}
+
+func marshal(t *testing.T, env *record.Envelope) []byte {
+	t.Helper()
+	b, err := env.Marshal()
+	require.NoError(t, err)
+	return b
+}
+
+func newTestGater(t *testing.T, ca ic.PrivKey) *Gater {
+	t.Helper()
+	g, err := NewGater(ca.GetPublic(), testNetwork, issue(t, ca, newPeer(t), 1, time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	return g
+}
+
+func TestCertificateRoundtrip(t *testing.T) {
+	ca := newCA(t)
+	p := newPeer(t)
+	env := issue(t, ca, p, 42, time.Now().Add(time.Hour))
+
+	_, r, err := record.ConsumeEnvelope(marshal(t, env), CertificateEnvelopeDomain)
+	require.NoError(t, err)
+	cert, ok := r.(*Certificate)
+	require.True(t, ok)
+	require.Equal(t, testNetwork, cert.Network)
+	require.Equal(t, p, cert.Peer)
+	require.Equal(t, uint64(42), cert.Serial)
+	require.True(t, cert.Valid(time.Now()))
+	require.False(t, cert.Valid(time.Now().Add(2*time.Hour)))
+}
+
+func TestIssueInvalidCertificate(t *testing.T) {
+	ca := newCA(t)
+	now := time.Now()
+	_, err := Issue(ca, &Certificate{Peer: newPeer(t), NotBefore: now, NotAfter: now.Add(time.Hour)})
+	require.Error(t, err)
+	_, err = Issue(ca, &Certificate{Network: testNetwork, NotBefore: now, NotAfter: now.Add(time.Hour)})
+	require.Error(t, err)
+	_, err = Issue(ca, &Certificate{Network: testNetwork, Peer: newPeer(t), NotBefore: now, NotAfter: now})
+	require.Error(t, err)
+}
+
+func TestNewGaterRejectsInvalidCertificate(t *testing.T) {
+	ca := newCA(t)
+	_, err := NewGater(ca.GetPublic(), testNetwork, issue(t, newCA(t), newPeer(t), 1, time.Now().Add(time.Hour)))
+	require.Error(t, err)
+	_, err = NewGater(ca.GetPublic(), "other-network", issue(t, ca, newPeer(t), 1, time.Now().Add(time.Hour)))
+	require.Error(t, err)
+}
+
+func TestGaterLocalCredential(t *testing.T) {
+	ca := newCA(t)
+	env := issue(t, ca, newPeer(t), 1, time.Now().Add(time.Hour))
+	g, err := NewGater(ca.GetPublic(), testNetwork, env)
+	require.NoError(t, err)
+	require.Equal(t, marshal(t, env), g.LocalCredential())
+}
+
+func TestGaterInterceptSecured(t *testing.T) {
+	ca := newCA(t)
+
+	t.Run("valid certificate", func(t *testing.T) {
+		g := newTestGater(t, ca)
+		p := newPeer(t)
+		require.NoError(t, g.HandleRemoteCredential(p, marshal(t, issue(t, ca, p, 2, time.Now().Add(time.Hour)))))
+		require.True(t, g.InterceptSecured(network.DirInbound, p, nil))
+	})
+
+	t.Run("no certificate", func(t *testing.T) {
+		g := newTestGater(t, ca)
+		p := newPeer(t)
+		require.NoError(t, g.HandleRemoteCredential(p, nil))
+		require.False(t, g.InterceptSecured(network.DirInbound, p, nil))
+	})
+
+	t.Run("expired certificate", func(t *testing.T) {
+		g := newTestGater(t, ca)
+		p := newPeer(t)
+		require.NoError(t, g.HandleRemoteCredential(p, marshal(t, issue(t, ca, p, 2, time.Now().Add(-time.Minute)))))
+		require.False(t, g.InterceptSecured(network.DirOutbound, p, nil))
+	})
+
+	t.Run("certificate for a different peer", func(t *testing.T) {
+		g := newTestGater(t, ca)
+		p := newPeer(t)
+		require.Error(t, g.HandleRemoteCredential(p, marshal(t, issue(t, ca, newPeer(t), 2, time.Now().Add(time.Hour)))))
+		require.False(t, g.InterceptSecured(network.DirInbound, p, nil))
+	})
+
+	t.Run("certificate issued by a different CA", func(t *testing.T) {
+		g := newTestGater(t, ca)
+		p := newPeer(t)
+		require.Error(t, g.HandleRemoteCredential(p, marshal(t, issue(t, newCA(t), p, 2, time.Now().Add(time.Hour)))))
+		require.False(t, g.InterceptSecured(network.DirInbound, p, nil))
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		g := newTestGater(t, ca)
+		require.Error(t, g.HandleRemoteCredential(newPeer(t), []byte("foobar")))
+	})
+}
+
+func TestGaterRevocation(t *testing.T) {
+	ca := newCA(t)
+	g := newTestGater(t, ca)
+	p1 := newPeer(t)
+	p2 := newPeer(t)
+	require.NoError(t, g.HandleRemoteCredential(p1, marshal(t, issue(t, ca, p1, 10, time.Now().Add(time.Hour)))))
+	require.NoError(t, g.HandleRemoteCredential(p2, marshal(t, issue(t, ca, p2, 11, time.Now().Add(time.Hour)))))
+
+	rl, err := IssueRevocationList(ca, &RevocationList{Network: testNetwork, Seq: 2, Revoked: []uint64{10}})
+	require.NoError(t, err)
+	revoked, err := g.UpdateRevocationList(rl)
+	require.NoError(t, err)
+	require.Equal(t, []peer.ID{p1}, revoked)
+	require.False(t, g.InterceptSecured(network.DirInbound, p1, nil))
+	require.False(t, g.InterceptPeerDial(p1))
+	require.True(t, g.InterceptSecured(network.DirInbound, p2, nil))
+	require.True(t, g.InterceptPeerDial(p2))
+
+	// older revocation lists are rejected
+	rl, err = IssueRevocationList(ca, &RevocationList{Network: testNetwork, Seq: 1})
+	require.NoError(t, err)
+	_, err = g.UpdateRevocationList(rl)
+	require.Error(t, err)
+	require.False(t, g.InterceptSecured(network.DirInbound, p1, nil))
+
+	// revocation lists issued by other CAs are rejected
+	rl, err = IssueRevocationList(newCA(t), &RevocationList{Network: testNetwork, Seq: 3})
+	require.NoError(t, err)
+	_, err = g.UpdateRevocationList(rl)
+	require.Error(t, err)
+
+	// a newer list replaces the current one
+	rl, err = IssueRevocationList(ca, &RevocationList{Network: testNetwork, Seq: 3, Revoked: []uint64{11}})
+	require.NoError(t, err)
+	revoked, err = g.UpdateRevocationList(rl)
+	require.NoError(t, err)
+	require.Equal(t, []peer.ID{p2}, revoked)
+	require.True(t, g.InterceptSecured(network.DirInbound, p1, nil))
+	require.False(t, g.InterceptSecured(network.DirInbound, p2, nil))
+}
+
+func newWebTransport(t *testing.T, priv ic.PrivKey, g *Gater) tpt.Transport {
+	t.Helper()
+	cm, err := quicreuse.NewConnManager([32]byte{})
+	require.NoError(t, err)
+	t.Cleanup(func() { cm.Close() })
+	tr, err := libp2pwebtransport.New(priv, nil, cm, g, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { tr.(io.Closer).Close() })
+	return tr
+}
+
+func TestGaterWebTransport(t *testing.T) {
+	ca := newCA(t)
+	newMember := func(ca ic.PrivKey) (peer.ID, ic.PrivKey, *Gater) {
+		priv, _, err := ic.GenerateEd25519Key(nil)
+		require.NoError(t, err)
+		id, err := peer.IDFromPrivateKey(priv)
+		require.NoError(t, err)
+		g, err := NewGater(ca.GetPublic(), testNetwork, issue(t, ca, id, 1, time.Now().Add(time.Hour)))
+		require.NoError(t, err)
+		return id, priv, g
+	}
+
+	serverID, serverKey, serverGater := newMember(ca)
+	ln, err := newWebTransport(t, serverKey, serverGater).Listen(ma.StringCast("/ip4/127.0.0.1/udp/0/quic-v1/webtransport"))
+	require.NoError(t, err)
+	defer ln.Close()
+	accepted := make(chan peer.ID, 1)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c.RemotePeer()
+		}
+	}()
+
+	t.Run("member", func(t *testing.T) {
+		clientID, clientKey, clientGater := newMember(ca)
+		conn, err := newWebTransport(t, clientKey, clientGater).Dial(context.Background(), ln.Multiaddr(), serverID)
+		require.NoError(t, err)
+		defer conn.Close()
+		select {
+		case p := <-accepted:
+			require.Equal(t, clientID, p)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	})
+
+	t.Run("member of a different network", func(t *testing.T) {
+		_, clientKey, clientGater := newMember(newCA(t))
+		_, err := newWebTransport(t, clientKey, clientGater).Dial(context.Background(), ln.Multiaddr(), serverID)
+		require.Error(t, err)
+		select {
+		case <-accepted:
+			t.Fatal("didn't expect the connection to be accepted")
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+}
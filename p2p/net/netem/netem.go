@@ -0,0 +1,175 @@
+// Package netem emulates network links with a configurable round trip time, jitter,
+// bandwidth and packet loss.
+//
+// It is meant for benchmarking stream muxers and transports under realistic network
+// conditions:
+//
+//   - Pipe returns a pair of connected net.Conns, similar to net.Pipe.
+//   - TCPProxy and UDPProxy forward connections and packets on the loopback interface,
+//     so that transports can be tested over an emulated link.
+package netem
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// packetSize is the payload size of a packet on the emulated link. Loss is applied per packet.
+const packetSize = 1400
+
+// Config configures an emulated link. The same configuration applies to both directions.
+type Config struct {
+	// RTT is the round trip time. Every packet is delayed by RTT/2.
+	RTT time.Duration
+	// Jitter is the maximum additional, uniformly distributed delay of every packet.
+	Jitter time.Duration
+	// Bandwidth is the bandwidth of the link in bytes per second.
+	// If zero, the bandwidth isn't limited.
+	Bandwidth float64
+	// Loss is the probability that a packet is lost.
+	// On stream links (Pipe and TCPProxy), lost packets are retransmitted after one RTT,
+	// delaying all subsequent data.
+	Loss float64
+	// Seed seeds the random number generator used for jitter and loss, so that runs are
+	// reproducible.
+	Seed int64
+}
+
+func (c Config) validate() error {
+	if c.RTT < 0 || c.Jitter < 0 {
+		return errors.New("netem: negative delay")
+	}
+	if c.Bandwidth < 0 {
+		return errors.New("netem: negative bandwidth")
+	}
+	if c.Loss < 0 || c.Loss >= 1 {
+		return errors.New("netem: loss must be in [0, 1)")
+	}
+	return nil
+}
+
+// String returns a short description of the link, suitable for benchmark names.
+func (c Config) String() string {
+	s := "rtt=" + c.RTT.String()
+	if c.Jitter > 0 {
+		s += ",jitter=" + c.Jitter.String()
+	}
+	if c.Bandwidth > 0 {
+		s += ",bw=" + formatBandwidth(c.Bandwidth)
+	}
+	if c.Loss > 0 {
+		s += ",loss=" + formatPercent(c.Loss)
+	}
+	return s
+}
+
+// shaper computes the delivery times of the packets sent in one direction of a link.
+type shaper struct {
+	cfg Config
+
+	mx  sync.Mutex
+	rng *rand.Rand
+	// busyUntil is the time the link finishes sending the data that was sent so far
+	busyUntil time.Time
+	// lastDelivery is the delivery time of the last segment, used to keep streams in order
+	lastDelivery time.Time
+}
+
+func newShaper(cfg Config, seed int64) *shaper {
+	return &shaper{cfg: cfg, rng: rand.New(rand.NewSource(seed))}
+}
+
+// transmit accounts for sending n bytes. It returns the time the data has been sent, i.e.
+// when the sender may send more data.
+func (s *shaper) transmit(now time.Time, n int) time.Time {
+	if s.busyUntil.Before(now) {
+		s.busyUntil = now
+	}
+	if s.cfg.Bandwidth > 0 {
+		s.busyUntil = s.busyUntil.Add(time.Duration(float64(n) / s.cfg.Bandwidth * float64(time.Second)))
+	}
+	return s.busyUntil
+}
+
+func (s *shaper) delay() time.Duration {
+	d := s.cfg.RTT / 2
+	if s.cfg.Jitter > 0 {
+		d += time.Duration(s.rng.Int63n(int64(s.cfg.Jitter)))
+	}
+	return d
+}
+
+func (s *shaper) lost() bool {
+	return s.cfg.Loss > 0 && s.rng.Float64() < s.cfg.Loss
+}
+
+// segment computes when n bytes of stream data sent now are delivered.
+// It returns the time the data has been sent, and the delivery time.
+func (s *shaper) segment(now time.Time, n int) (sent, delivery time.Time) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	sent = s.transmit(now, n)
+	delivery = sent.Add(s.delay())
+	// Every lost packet is retransmitted after one RTT.
+	// Packets of the same segment are retransmitted in parallel.
+	var retransmit time.Duration
+	for i := 0; i < n; i += packetSize {
+		var d time.Duration
+		for s.lost() {
+			d += s.cfg.RTT
+		}
+		if d > retransmit {
+			retransmit = d
+		}
+	}
+	delivery = delivery.Add(retransmit)
+	// Stream data is delivered in order.
+	if delivery.Before(s.lastDelivery) {
+		delivery = s.lastDelivery
+	}
+	s.lastDelivery = delivery
+	return sent, delivery
+}
+
+// packet computes when a packet of n bytes sent now is delivered.
+// It returns false if the packet is dropped, either because it was lost or because the
+// queue of the link is full.
+func (s *shaper) packet(now time.Time, n int) (delivery time.Time, ok bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	// The queue holds up to one RTT worth of data (but at least 10ms).
+	maxQueue := s.cfg.RTT
+	if maxQueue < 10*time.Millisecond {
+		maxQueue = 10 * time.Millisecond
+	}
+	if s.busyUntil.Sub(now) > maxQueue {
+		return time.Time{}, false
+	}
+	sent := s.transmit(now, n)
+	if s.lost() {
+		return time.Time{}, false
+	}
+	return sent.Add(s.delay()), true
+}
+
+func formatBandwidth(bw float64) string {
+	switch {
+	case bw >= 1e9:
+		return fmt.Sprintf("%gGBps", bw/1e9)
+	case bw >= 1e6:
+		return fmt.Sprintf("%gMBps", bw/1e6)
+	case bw >= 1e3:
+		return fmt.Sprintf("%gkBps", bw/1e3)
+	default:
+		return fmt.Sprintf("%gBps", bw)
+	}
+}
+
+func formatPercent(p float64) string {
+	return fmt.Sprintf("%g%%", p*100)
+}
@@ -0,0 +1,178 @@
+package netem
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, Config{RTT: time.Millisecond, Loss: 0.5}.validate())
+	require.Error(t, Config{RTT: -time.Millisecond}.validate())
+	require.Error(t, Config{Bandwidth: -1}.validate())
+	require.Error(t, Config{Loss: 1}.validate())
+	_, _, err := Pipe(Config{Loss: -0.1})
+	require.Error(t, err)
+}
+
+func TestConfigString(t *testing.T) {
+	require.Equal(t, "rtt=50ms", Config{RTT: 50 * time.Millisecond}.String())
+	require.Equal(t,
+		"rtt=100ms,jitter=5ms,bw=12.5MBps,loss=1%",
+		Config{RTT: 100 * time.Millisecond, Jitter: 5 * time.Millisecond, Bandwidth: 12.5e6, Loss: 0.01}.String(),
+	)
+}
+
+func TestPipeLatency(t *testing.T) {
+	const rtt = 100 * time.Millisecond
+	c1, c2, err := Pipe(Config{RTT: rtt})
+	require.NoError(t, err)
+	defer c1.Close()
+	defer c2.Close()
+
+	go io.Copy(c2, c2) // echo
+	start := time.Now()
+	_, err = c1.Write([]byte("foobar"))
+	require.NoError(t, err)
+	b := make([]byte, 6)
+	_, err = io.ReadFull(c1, b)
+	require.NoError(t, err)
+	require.Equal(t, "foobar", string(b))
+	took := time.Since(start)
+	require.GreaterOrEqual(t, took, rtt)
+	require.Less(t, took, rtt+50*time.Millisecond)
+}
+
+func TestPipeBandwidth(t *testing.T) {
+	const bandwidth = 1 << 20 // 1 MiB/s
+	c1, c2, err := Pipe(Config{Bandwidth: bandwidth})
+	require.NoError(t, err)
+	defer c2.Close()
+
+	go func() {
+		c1.Write(make([]byte, bandwidth/4))
+		c1.Close()
+	}()
+	start := time.Now()
+	n, err := io.Copy(io.Discard, c2)
+	require.NoError(t, err)
+	require.Equal(t, int64(bandwidth/4), n)
+	took := time.Since(start)
+	require.GreaterOrEqual(t, took, 240*time.Millisecond)
+	require.Less(t, took, 400*time.Millisecond)
+}
+
+func TestPipeLossKeepsOrder(t *testing.T) {
+	c1, c2, err := Pipe(Config{RTT: 2 * time.Millisecond, Jitter: time.Millisecond, Loss: 0.05})
+	require.NoError(t, err)
+	defer c2.Close()
+
+	data := make([]byte, 1<<20)
+	rand.Read(data)
+	go func() {
+		c1.Write(data)
+		c1.Close()
+	}()
+	received, err := io.ReadAll(c2)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(data, received))
+}
+
+func TestTCPProxy(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				io.Copy(c, c)
+			}()
+		}
+	}()
+
+	const rtt = 50 * time.Millisecond
+	p, err := NewTCPProxy(Config{RTT: rtt}, ln.Addr().String())
+	require.NoError(t, err)
+	defer p.Close()
+
+	c, err := net.Dial("tcp", p.Addr().String())
+	require.NoError(t, err)
+	defer c.Close()
+	start := time.Now()
+	_, err = c.Write([]byte("foobar"))
+	require.NoError(t, err)
+	b := make([]byte, 6)
+	_, err = io.ReadFull(c, b)
+	require.NoError(t, err)
+	require.Equal(t, "foobar", string(b))
+	require.GreaterOrEqual(t, time.Since(start), rtt)
+
+	// Closing the proxy closes all connections.
+	require.NoError(t, p.Close())
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = c.Read(b)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, net.ErrClosed)
+}
+
+func TestUDPProxy(t *testing.T) {
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer server.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := server.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			server.WriteToUDP(buf[:n], addr)
+		}
+	}()
+
+	const rtt = 20 * time.Millisecond
+	p, err := NewUDPProxy(Config{RTT: rtt, Loss: 0.2}, server.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer p.Close()
+
+	c, err := net.DialUDP("udp", nil, p.Addr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer c.Close()
+
+	const num = 500
+	start := time.Now()
+	receivedChan := make(chan int)
+	go func() {
+		var received int
+		buf := make([]byte, 1500)
+		for {
+			c.SetReadDeadline(time.Now().Add(5 * rtt))
+			if _, err := c.Read(buf); err != nil {
+				break
+			}
+			if received == 0 && time.Since(start) < rtt {
+				t.Error("packet arrived too early")
+			}
+			received++
+		}
+		receivedChan <- received
+	}()
+	for i := 0; i < num; i++ {
+		_, err := c.Write([]byte("foobar"))
+		require.NoError(t, err)
+		time.Sleep(100 * time.Microsecond)
+	}
+	received := <-receivedChan
+	// Packets are lost in both directions, so about 64% arrive.
+	require.InDelta(t, 0.64*num, received, 0.1*num)
+}
@@ -0,0 +1,99 @@
+package netem
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxSegmentSize is the maximum number of bytes that is forwarded at once on stream links.
+const maxSegmentSize = 16 * 1024
+
+// maxPacingError is the maximum time a sender may be ahead of the emulated bandwidth.
+const maxPacingError = time.Millisecond
+
+// maxInflightSegments is the maximum number of segments on the wire in one direction.
+const maxInflightSegments = 4096
+
+// Pipe creates a synchronous, in-memory, full duplex network connection over an emulated link.
+// Both ends implement the net.Conn interface, see net.Pipe.
+func Pipe(cfg Config) (net.Conn, net.Conn, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, nil, err
+	}
+	c1, r1 := net.Pipe()
+	r2, c2 := net.Pipe()
+	newRelay(cfg, r1, r2)
+	return c1, c2, nil
+}
+
+// relay forwards data between two connections over an emulated link.
+type relay struct {
+	a, b      io.ReadWriteCloser
+	closeOnce sync.Once
+	// closed is closed when both connections have been closed
+	closed chan struct{}
+}
+
+// newRelay starts forwarding data between a and b. When either connection is closed, the
+// data in flight is delivered and then both connections are closed.
+func newRelay(cfg Config, a, b io.ReadWriteCloser) *relay {
+	r := &relay{a: a, b: b, closed: make(chan struct{})}
+	go r.forward(b, a, newShaper(cfg, cfg.Seed))
+	go r.forward(a, b, newShaper(cfg, cfg.Seed+1))
+	return r
+}
+
+func (r *relay) close() {
+	r.closeOnce.Do(func() {
+		r.a.Close()
+		r.b.Close()
+		close(r.closed)
+	})
+}
+
+type segment struct {
+	data     []byte
+	delivery time.Time
+}
+
+func (r *relay) forward(dst io.Writer, src io.Reader, s *shaper) {
+	segments := make(chan segment, maxInflightSegments)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer r.close()
+		for seg := range segments {
+			time.Sleep(time.Until(seg.delivery))
+			if _, err := dst.Write(seg.data); err != nil {
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		close(segments)
+		<-done
+	}()
+	for {
+		buf := make([]byte, maxSegmentSize)
+		n, err := src.Read(buf)
+		if n > 0 {
+			sent, delivery := s.segment(time.Now(), n)
+			select {
+			case segments <- segment{data: buf[:n], delivery: delivery}:
+			case <-done:
+				return
+			}
+			// The sender is blocked until the data has been sent. Timers aren't precise enough to
+			// pace every segment at high bandwidths, so the sender may get slightly ahead.
+			if d := time.Until(sent); d > maxPacingError {
+				time.Sleep(d)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package netem
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// TCPProxy forwards TCP connections to a target address over an emulated link.
+type TCPProxy struct {
+	cfg    Config
+	target string
+	ln     net.Listener
+
+	mx     sync.Mutex
+	closed bool
+	relays map[*relay]struct{}
+}
+
+// NewTCPProxy starts a proxy listening on a random port on the loopback interface.
+// Connections to the proxy are forwarded to target.
+func NewTCPProxy(cfg Config, target string) (*TCPProxy, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	p := &TCPProxy{cfg: cfg, target: target, ln: ln, relays: make(map[*relay]struct{})}
+	go p.serve()
+	return p, nil
+}
+
+// Addr returns the address of the proxy.
+func (p *TCPProxy) Addr() net.Addr {
+	return p.ln.Addr()
+}
+
+func (p *TCPProxy) serve() {
+	for {
+		c, err := p.ln.Accept()
+		if err != nil {
+			return
+		}
+		go p.handle(c)
+	}
+}
+
+func (p *TCPProxy) handle(c net.Conn) {
+	tc, err := net.Dial("tcp", p.target)
+	if err != nil {
+		c.Close()
+		return
+	}
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if p.closed {
+		c.Close()
+		tc.Close()
+		return
+	}
+	r := newRelay(p.cfg, c, tc)
+	p.relays[r] = struct{}{}
+	go func() {
+		<-r.closed
+		p.mx.Lock()
+		delete(p.relays, r)
+		p.mx.Unlock()
+	}()
+}
+
+// Close stops the proxy and closes all connections.
+func (p *TCPProxy) Close() error {
+	p.mx.Lock()
+	p.closed = true
+	relays := p.relays
+	p.relays = nil
+	p.mx.Unlock()
+
+	for r := range relays {
+		r.close()
+	}
+	return p.ln.Close()
+}
+
+// maxPacketSize is the maximum size of a UDP packet forwarded by the UDPProxy.
+const maxPacketSize = 1 << 16
+
+// UDPProxy forwards UDP packets to a target address over an emulated link.
+// For every client address, packets are sent to the target from a separate socket.
+type UDPProxy struct {
+	cfg    Config
+	target *net.UDPAddr
+	conn   *net.UDPConn
+
+	mx      sync.Mutex
+	clients map[string]*udpClient
+	// seed is the seed for the next client
+	seed int64
+}
+
+type udpClient struct {
+	addr *net.UDPAddr
+	// conn is connected to the target
+	conn     *net.UDPConn
+	up, down *shaper
+}
+
+// NewUDPProxy starts a proxy listening on a random port on the loopback interface.
+// Packets sent to the proxy are forwarded to target.
+func NewUDPProxy(cfg Config, target *net.UDPAddr) (*UDPProxy, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return nil, err
+	}
+	p := &UDPProxy{
+		cfg:     cfg,
+		target:  target,
+		conn:    conn,
+		clients: make(map[string]*udpClient),
+		seed:    cfg.Seed,
+	}
+	go p.serve()
+	return p, nil
+}
+
+// Addr returns the address of the proxy.
+func (p *UDPProxy) Addr() net.Addr {
+	return p.conn.LocalAddr()
+}
+
+func (p *UDPProxy) serve() {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := p.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		c, err := p.client(addr)
+		if err != nil {
+			continue
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		if delivery, ok := c.up.packet(time.Now(), n); ok {
+			time.AfterFunc(time.Until(delivery), func() { c.conn.Write(data) })
+		}
+	}
+}
+
+func (p *UDPProxy) client(addr *net.UDPAddr) (*udpClient, error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if p.clients == nil {
+		return nil, net.ErrClosed
+	}
+	if c, ok := p.clients[addr.String()]; ok {
+		return c, nil
+	}
+	conn, err := net.DialUDP("udp", nil, p.target)
+	if err != nil {
+		return nil, err
+	}
+	c := &udpClient{
+		addr: addr,
+		conn: conn,
+		up:   newShaper(p.cfg, p.seed),
+		down: newShaper(p.cfg, p.seed+1),
+	}
+	p.seed += 2
+	p.clients[addr.String()] = c
+	go p.receive(c)
+	return c, nil
+}
+
+// receive forwards the packets sent by the target to the client.
+func (p *UDPProxy) receive(c *udpClient) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			return
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		if delivery, ok := c.down.packet(time.Now(), n); ok {
+			time.AfterFunc(time.Until(delivery), func() { p.conn.WriteToUDP(data, c.addr) })
+		}
+	}
+}
+
+// Close stops the proxy.
+func (p *UDPProxy) Close() error {
+	p.mx.Lock()
+	clients := p.clients
+	p.clients = nil
+	p.mx.Unlock()
+
+	for _, c := range clients {
+		c.conn.Close()
+	}
+	return p.conn.Close()
+}
@@ -3,16 +3,63 @@ package pnet
 import (
 	"errors"
 	"net"
+	"time"
 
 	ipnet "github.com/libp2p/go-libp2p/core/pnet"
 )
 
 // NewProtectedConn creates a new protected connection
 func NewProtectedConn(psk ipnet.PSK, conn net.Conn) (net.Conn, error) {
+	key, err := toKey(psk)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newPSKConn(key, conn)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewProtectedConnWithPSKSet creates a new protected connection that accepts all keys of a PSK set.
+//
+// Outgoing data is protected with the current key of the set. The nonce sent at the beginning of
+// the connection is tagged with the key used, which allows the remote peer to select the right key
+// from its own PSK set. Nodes using a single PSK (see NewProtectedConn) interpret the tagged nonce
+// as a random nonce, and untagged nonces sent by them are decrypted using the current key.
+// The validity periods of the keys are evaluated when the connection is created.
+func NewProtectedConnWithPSKSet(psks ipnet.PSKSet, conn net.Conn) (net.Conn, error) {
+	now := time.Now()
+	current, err := psks.Current(now)
+	if err != nil {
+		return nil, err
+	}
+	key, err := toKey(current)
+	if err != nil {
+		return nil, err
+	}
+	accepted := psks.Accepted(now)
+	acceptedKeys := make([]*[32]byte, 0, len(accepted))
+	for _, psk := range accepted {
+		k, err := toKey(psk)
+		if err != nil {
+			return nil, err
+		}
+		acceptedKeys = append(acceptedKeys, k)
+	}
+	c, err := newPSKConn(key, conn)
+	if err != nil {
+		return nil, err
+	}
+	c.accepted = acceptedKeys
+	return c, nil
+}
+
+func toKey(psk ipnet.PSK) (*[32]byte, error) {
 	if len(psk) != 32 {
 		return nil, errors.New("expected 32 byte PSK")
 	}
 	var p [32]byte
 	copy(p[:], psk)
-	return newPSKConn(&p, conn)
+	return &p, nil
 }
@@ -2,7 +2,9 @@ package pnet
 
 import (
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"io"
 	"net"
 
@@ -20,9 +22,18 @@ var (
 	errPSKNil      = pnet.NewError("pre-shread key is nil")
 )
 
+// nonceTagLen is the length of the key tag at the beginning of the nonce.
+// The remaining bytes of the nonce are random.
+const nonceTagLen = 8
+
+var nonceTagPrefix = []byte("libp2p-pnet-nonce-tag:")
+
 type pskConn struct {
 	net.Conn
 	psk *[32]byte
+	// accepted are the keys accepted for reading, selected by the tag of the nonce.
+	// If nil, nonces are not tagged, and psk is used for reading.
+	accepted []*[32]byte
 
 	writeS20 cipher.Stream
 	readS20  cipher.Stream
@@ -35,7 +46,7 @@ func (c *pskConn) Read(out []byte) (int, error) {
 		if err != nil {
 			return 0, errShortNonce
 		}
-		c.readS20 = salsa20.New(c.psk, nonce)
+		c.readS20 = salsa20.New(c.readKey(nonce), nonce)
 	}
 
 	n, err := c.Conn.Read(out) // read to in
@@ -52,6 +63,9 @@ func (c *pskConn) Write(in []byte) (int, error) {
 		if err != nil {
 			return 0, err
 		}
+		if c.accepted != nil {
+			copy(nonce, nonceTag(c.psk, nonce[nonceTagLen:]))
+		}
 		_, err = c.Conn.Write(nonce)
 		if err != nil {
 			return 0, err
@@ -67,9 +81,28 @@ func (c *pskConn) Write(in []byte) (int, error) {
 	return c.Conn.Write(out) // send
 }
 
+// readKey returns the key that the remote peer used to protect the connection.
+func (c *pskConn) readKey(nonce []byte) *[32]byte {
+	for _, k := range c.accepted {
+		if hmac.Equal(nonce[:nonceTagLen], nonceTag(k, nonce[nonceTagLen:])) {
+			return k
+		}
+	}
+	// The remote peer either uses the same key as we do, or it doesn't tag its nonces.
+	return c.psk
+}
+
+// nonceTag calculates the tag that identifies the key used to protect a connection.
+func nonceTag(psk *[32]byte, random []byte) []byte {
+	mac := hmac.New(sha256.New, psk[:])
+	mac.Write(nonceTagPrefix)
+	mac.Write(random)
+	return mac.Sum(nil)[:nonceTagLen]
+}
+
 var _ net.Conn = (*pskConn)(nil)
 
-func newPSKConn(psk *[32]byte, insecure net.Conn) (net.Conn, error) {
+func newPSKConn(psk *[32]byte, insecure net.Conn) (*pskConn, error) {
 	if insecure == nil {
 		return nil, errInsecureNil
 	}
@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"io"
 	"net"
 	"testing"
+	"time"
+
+	ipnet "github.com/libp2p/go-libp2p/core/pnet"
 )
 
 func setupPSKConns(ctx context.Context, t *testing.T) (net.Conn, net.Conn) {
@@ -88,3 +92,82 @@ func TestPSKFragmentation(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func exchangeMessage(t *testing.T, from, to net.Conn) []byte {
+	t.Helper()
+	msg := []byte("hello world")
+	wch := make(chan error, 1)
+	go func() {
+		_, err := from.Write(msg)
+		wch <- err
+	}()
+	out := make([]byte, len(msg))
+	if _, err := io.ReadFull(to, out); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-wch; err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestPSKSetRotation(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+	now := time.Now()
+	// conn1 still uses the old key, conn2 already switched to the new key
+	set1 := ipnet.PSKSet{{PSK: oldKey}, {PSK: newKey, NotBefore: now.Add(time.Hour)}}
+	set2 := ipnet.PSKSet{{PSK: oldKey}, {PSK: newKey, NotBefore: now.Add(-time.Hour)}}
+
+	c1, c2 := net.Pipe()
+	psk1, err := NewProtectedConnWithPSKSet(set1, c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	psk2, err := NewProtectedConnWithPSKSet(set2, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := exchangeMessage(t, psk1, psk2); string(out) != "hello world" {
+		t.Fatalf("unexpected message: %q", out)
+	}
+	if out := exchangeMessage(t, psk2, psk1); string(out) != "hello world" {
+		t.Fatalf("unexpected message: %q", out)
+	}
+}
+
+func TestPSKSetSingleKeyCompatibility(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	set := ipnet.PSKSet{{PSK: key}, {PSK: bytes.Repeat([]byte{2}, 32), NotBefore: time.Now().Add(time.Hour)}}
+
+	c1, c2 := net.Pipe()
+	psk1, err := NewProtectedConn(key, c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	psk2, err := NewProtectedConnWithPSKSet(set, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := exchangeMessage(t, psk1, psk2); string(out) != "hello world" {
+		t.Fatalf("unexpected message: %q", out)
+	}
+	if out := exchangeMessage(t, psk2, psk1); string(out) != "hello world" {
+		t.Fatalf("unexpected message: %q", out)
+	}
+}
+
+func TestPSKSetWrongKey(t *testing.T) {
+	c1, c2 := net.Pipe()
+	psk1, err := NewProtectedConnWithPSKSet(ipnet.NewPSKSet(bytes.Repeat([]byte{1}, 32)), c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	psk2, err := NewProtectedConnWithPSKSet(ipnet.NewPSKSet(bytes.Repeat([]byte{2}, 32)), c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := exchangeMessage(t, psk1, psk2); string(out) == "hello world" {
+		t.Fatal("expected message to be garbled")
+	}
+}
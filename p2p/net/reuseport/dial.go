@@ -2,6 +2,7 @@ package reuseport
 
 import (
 	"context"
+	"syscall"
 
 	ma "github.com/multiformats/go-multiaddr"
 	manet "github.com/multiformats/go-multiaddr/net"
@@ -27,9 +28,9 @@ func (t *Transport) DialContext(ctx context.Context, raddr ma.Multiaddr) (manet.
 	var d *dialer
 	switch network {
 	case "tcp4":
-		d = t.v4.getDialer(network)
+		d = t.v4.getDialer(t.DialControl, t.MultipathTCP)
 	case "tcp6":
-		d = t.v6.getDialer(network)
+		d = t.v6.getDialer(t.DialControl, t.MultipathTCP)
 	default:
 		return nil, ErrWrongProto
 	}
@@ -45,7 +46,7 @@ func (t *Transport) DialContext(ctx context.Context, raddr ma.Multiaddr) (manet.
 	return maconn, nil
 }
 
-func (n *network) getDialer(network string) *dialer {
+func (n *network) getDialer(control func(network, address string, c syscall.RawConn) error, multipathTCP bool) *dialer {
 	n.mu.RLock()
 	d := n.dialer
 	n.mu.RUnlock()
@@ -54,7 +55,7 @@ func (n *network) getDialer(network string) *dialer {
 		defer n.mu.Unlock()
 
 		if n.dialer == nil {
-			n.dialer = newDialer(n.listeners)
+			n.dialer = newDialer(n.listeners, control, multipathTCP)
 		}
 		d = n.dialer
 	}
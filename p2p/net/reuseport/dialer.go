@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"syscall"
 
 	"github.com/libp2p/go-netroute"
 )
 
 type dialer struct {
+	control      func(network, address string, c syscall.RawConn) error
+	multipathTCP bool
+
 	// All address that are _not_ loopback or unspecified (0.0.0.0 or ::).
 	specific []*net.TCPAddr
 	// All loopback addresses (127.*.*.*, ::1).
@@ -66,7 +70,7 @@ func (d *dialer) DialContext(ctx context.Context, network, addr string) (net.Con
 				if _, _, preferredSrc, err := router.Route(ip); err == nil {
 					for _, optAddr := range d.specific {
 						if optAddr.IP.Equal(preferredSrc) {
-							return reuseDial(ctx, optAddr, network, addr)
+							return reuseDial(ctx, d.control, d.multipathTCP, optAddr, network, addr)
 						}
 					}
 				}
@@ -76,22 +80,22 @@ func (d *dialer) DialContext(ctx context.Context, network, addr string) (net.Con
 		// Otherwise, if we are listening on a loopback address and the destination is also
 		// a loopback address, use the port from our loopback listener.
 		if len(d.loopback) > 0 && ip.IsLoopback() {
-			return reuseDial(ctx, randAddr(d.loopback), network, addr)
+			return reuseDial(ctx, d.control, d.multipathTCP, randAddr(d.loopback), network, addr)
 		}
 	}
 
 	// If we're listening on any uspecified addresses, use a randomly chosen port from one of
 	// these listeners.
 	if len(d.unspecified) > 0 {
-		return reuseDial(ctx, randAddr(d.unspecified), network, addr)
+		return reuseDial(ctx, d.control, d.multipathTCP, randAddr(d.unspecified), network, addr)
 	}
 
 	// Finally, just pick a random port.
-	var dialer net.Dialer
+	dialer := newNetDialer(d.control, d.multipathTCP)
 	return dialer.DialContext(ctx, network, addr)
 }
 
-func newDialer(listeners map[*listener]struct{}) *dialer {
+func newDialer(listeners map[*listener]struct{}, control func(network, address string, c syscall.RawConn) error, multipathTCP bool) *dialer {
 	specific := make([]*net.TCPAddr, 0)
 	loopback := make([]*net.TCPAddr, 0)
 	unspecified := make([]*net.TCPAddr, 0)
@@ -107,8 +111,10 @@ func newDialer(listeners map[*listener]struct{}) *dialer {
 		}
 	}
 	return &dialer{
-		specific:    specific,
-		loopback:    loopback,
-		unspecified: unspecified,
+		control:      control,
+		multipathTCP: multipathTCP,
+		specific:     specific,
+		loopback:     loopback,
+		unspecified:  unspecified,
 	}
 }
@@ -1,7 +1,11 @@
 package reuseport
 
 import (
+	"context"
 	"net"
+	"syscall"
+
+	"github.com/libp2p/go-libp2p/internal/mptcp"
 
 	"github.com/libp2p/go-reuseport"
 	ma "github.com/multiformats/go-multiaddr"
@@ -44,11 +48,12 @@ func (t *Transport) Listen(laddr ma.Multiaddr) (manet.Listener, error) {
 	}
 
 	if !reuseport.Available() {
-		return manet.Listen(laddr)
+		return listen(nw, naddr, t.ListenControl, t.MultipathTCP)
 	}
-	nl, err := reuseport.Listen(nw, naddr)
+	lc := newListenConfig(chainControl(reuseport.Control, t.ListenControl), t.MultipathTCP)
+	nl, err := lc.Listen(context.Background(), nw, naddr)
 	if err != nil {
-		return manet.Listen(laddr)
+		return listen(nw, naddr, t.ListenControl, t.MultipathTCP)
 	}
 
 	if _, ok := nl.Addr().(*net.TCPAddr); !ok {
@@ -78,3 +83,23 @@ func (t *Transport) Listen(laddr ma.Multiaddr) (manet.Listener, error) {
 
 	return list, nil
 }
+
+// listen listens without enabling reuseport.
+func listen(nw, naddr string, control func(network, address string, c syscall.RawConn) error, multipathTCP bool) (manet.Listener, error) {
+	lc := newListenConfig(control, multipathTCP)
+	nl, err := lc.Listen(context.Background(), nw, naddr)
+	if err != nil {
+		return nil, err
+	}
+	return manet.WrapNetListener(nl)
+}
+
+// newListenConfig returns a net.ListenConfig using the given control function,
+// with Multipath TCP enabled if requested.
+func newListenConfig(control func(network, address string, c syscall.RawConn) error, multipathTCP bool) net.ListenConfig {
+	lc := net.ListenConfig{Control: control}
+	if multipathTCP {
+		mptcp.EnableListenConfig(&lc)
+	}
+	return lc
+}
@@ -3,22 +3,23 @@ package reuseport
 import (
 	"context"
 	"net"
+	"syscall"
+
+	"github.com/libp2p/go-libp2p/internal/mptcp"
 
 	"github.com/libp2p/go-reuseport"
 )
 
-var fallbackDialer net.Dialer
-
 // Dials using reuseport and then redials normally if that fails.
-func reuseDial(ctx context.Context, laddr *net.TCPAddr, network, raddr string) (con net.Conn, err error) {
+// control is called for every socket created, in addition to setting the reuseport options.
+func reuseDial(ctx context.Context, control func(network, address string, c syscall.RawConn) error, multipathTCP bool, laddr *net.TCPAddr, network, raddr string) (con net.Conn, err error) {
+	fallbackDialer := newNetDialer(control, multipathTCP)
 	if laddr == nil {
 		return fallbackDialer.DialContext(ctx, network, raddr)
 	}
 
-	d := net.Dialer{
-		LocalAddr: laddr,
-		Control:   reuseport.Control,
-	}
+	d := newNetDialer(chainControl(reuseport.Control, control), multipathTCP)
+	d.LocalAddr = laddr
 
 	con, err = d.DialContext(ctx, network, raddr)
 	if err == nil {
@@ -33,3 +34,28 @@ func reuseDial(ctx context.Context, laddr *net.TCPAddr, network, raddr string) (
 	}
 	return con, err
 }
+
+// newNetDialer returns a net.Dialer using the given control function,
+// with Multipath TCP enabled if requested.
+func newNetDialer(control func(network, address string, c syscall.RawConn) error, multipathTCP bool) net.Dialer {
+	d := net.Dialer{Control: control}
+	if multipathTCP {
+		mptcp.EnableDialer(&d)
+	}
+	return d
+}
+
+// chainControl returns a control function that calls all non-nil control functions in order.
+func chainControl(controls ...func(network, address string, c syscall.RawConn) error) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		for _, control := range controls {
+			if control == nil {
+				continue
+			}
+			if err := control(network, address, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -12,6 +12,7 @@ package reuseport
 import (
 	"errors"
 	"sync"
+	"syscall"
 
 	logging "github.com/ipfs/go-log/v2"
 )
@@ -24,6 +25,20 @@ var ErrWrongProto = errors.New("can only dial TCP over IPv4 or IPv6")
 // Transport is a TCP reuse transport that reuses listener ports.
 // The zero value is safe to use.
 type Transport struct {
+	// DialControl, if set, is called for every socket created for dialing, after the
+	// reuseport socket options were set. See net.Dialer.Control.
+	// It must be set before the transport is used.
+	DialControl func(network, address string, c syscall.RawConn) error
+	// ListenControl, if set, is called for every listening socket, after the reuseport
+	// socket options were set. See net.ListenConfig.Control.
+	// It must be set before the transport is used.
+	ListenControl func(network, address string, c syscall.RawConn) error
+	// MultipathTCP enables Multipath TCP for all dialed and listening sockets.
+	// The kernel falls back to regular TCP if either side doesn't support it.
+	// It has no effect when built with Go versions before 1.21.
+	// It must be set before the transport is used.
+	MultipathTCP bool
+
 	v4 network
 	v6 network
 }
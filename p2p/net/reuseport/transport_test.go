@@ -2,8 +2,11 @@ package reuseport
 
 import (
 	"context"
+	"errors"
 	"net"
 	"runtime"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -275,3 +278,52 @@ func TestDuplicateGlobal(t *testing.T) {
 		dialOne(t, &trB, listenerA, port)
 	}
 }
+
+func TestControl(t *testing.T) {
+	var dialCalls, listenCalls atomic.Int32
+	trA := Transport{
+		ListenControl: func(string, string, syscall.RawConn) error {
+			listenCalls.Add(1)
+			return nil
+		},
+	}
+	trB := Transport{
+		DialControl: func(string, string, syscall.RawConn) error {
+			dialCalls.Add(1)
+			return nil
+		},
+	}
+	listenerA, err := trA.Listen(loopbackV4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listenerA.Close()
+	if n := listenCalls.Load(); n != 1 {
+		t.Fatalf("expected listen control to be called once, got %d", n)
+	}
+
+	// Without a listener, we dial from a random port.
+	dialOne(t, &trB, listenerA)
+	if n := dialCalls.Load(); n != 1 {
+		t.Fatalf("expected dial control to be called once, got %d", n)
+	}
+
+	// With a listener, we reuse its port.
+	listenerB, err := trB.Listen(loopbackV4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listenerB.Close()
+	dialOne(t, &trB, listenerA, listenerB.Addr().(*net.TCPAddr).Port)
+	if n := dialCalls.Load(); n != 2 {
+		t.Fatalf("expected dial control to be called twice, got %d", n)
+	}
+
+	// Errors returned by the control function are returned to the caller.
+	trC := Transport{
+		ListenControl: func(string, string, syscall.RawConn) error { return errors.New("denied") },
+	}
+	if _, err := trC.Listen(loopbackV4); err == nil {
+		t.Fatal("expected listen to fail")
+	}
+}
@@ -1,34 +1,19 @@
 package swarm
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 
+	"github.com/libp2p/go-libp2p/core/network"
+
+	ds "github.com/ipfs/go-datastore"
 	ma "github.com/multiformats/go-multiaddr"
 	manet "github.com/multiformats/go-multiaddr/net"
 )
 
-type blackHoleState int
-
-const (
-	blackHoleStateProbing blackHoleState = iota
-	blackHoleStateAllowed
-	blackHoleStateBlocked
-)
-
-func (st blackHoleState) String() string {
-	switch st {
-	case blackHoleStateProbing:
-		return "Probing"
-	case blackHoleStateAllowed:
-		return "Allowed"
-	case blackHoleStateBlocked:
-		return "Blocked"
-	default:
-		return fmt.Sprintf("Unknown %d", st)
-	}
-}
-
 type blackHoleResult int
 
 const (
@@ -66,10 +51,14 @@ type blackHoleFilter struct {
 	// successes is the count of successful dials in outcomes
 	successes int
 	// state is the current state of the detector
-	state blackHoleState
+	state network.BlackHoleState
+	// override is the state set by the user, if any. It takes precedence over state.
+	override *network.BlackHoleState
 
 	mu            sync.Mutex
 	metricsTracer MetricsTracer
+	// onStateChange is called, without holding mu, when the effective state of the filter changes
+	onStateChange func(b *blackHoleFilter, st network.BlackHoleState, overridden bool)
 }
 
 // RecordResult records the outcome of a dial. A successful dial will change the state
@@ -77,9 +66,13 @@ type blackHoleFilter struct {
 // fraction over the last n outcomes is less than the minSuccessFraction of the filter.
 func (b *blackHoleFilter) RecordResult(success bool) {
 	b.mu.Lock()
-	defer b.mu.Unlock()
+	prev := b.effectiveState()
+	b.recordResult(success)
+	b.unlockAndNotify(prev)
+}
 
-	if b.state == blackHoleStateBlocked && success {
+func (b *blackHoleFilter) recordResult(success bool) {
+	if b.state == network.BlackHoleStateBlocked && success {
 		// If the call succeeds in a blocked state we reset to allowed.
 		// This is better than slowly accumulating values till we cross the minSuccessFraction
 		// threshold since a blackhole is a binary property.
@@ -112,15 +105,95 @@ func (b *blackHoleFilter) HandleRequest() blackHoleResult {
 
 	b.trackMetrics()
 
-	if b.state == blackHoleStateAllowed {
+	if b.override != nil {
+		if *b.override == network.BlackHoleStateBlocked {
+			return blackHoleResultBlocked
+		}
+		return blackHoleResultAllowed
+	}
+	if b.state == network.BlackHoleStateAllowed {
 		return blackHoleResultAllowed
-	} else if b.state == blackHoleStateProbing || b.requests%b.n == 0 {
+	} else if b.state == network.BlackHoleStateProbing || b.requests%b.n == 0 {
 		return blackHoleResultProbing
 	} else {
 		return blackHoleResultBlocked
 	}
 }
 
+// Override forces the filter into state st, which must be either Allowed or Blocked.
+// Dial outcomes are still recorded, but don't affect the filter until the override
+// is removed by calling Reset.
+func (b *blackHoleFilter) Override(st network.BlackHoleState) error {
+	if st != network.BlackHoleStateAllowed && st != network.BlackHoleStateBlocked {
+		return fmt.Errorf("cannot override black hole state to %s", st)
+	}
+	b.mu.Lock()
+	prev := b.effectiveState()
+	b.override = &st
+	b.trackMetrics()
+	b.unlockAndNotify(prev)
+	return nil
+}
+
+// Reset removes the override, if any, and discards all recorded dial outcomes.
+// The filter is probing afterwards.
+func (b *blackHoleFilter) Reset() {
+	b.mu.Lock()
+	b.override = nil
+	b.reset()
+	b.trackMetrics()
+	st := b.state
+	b.mu.Unlock()
+	// Always notify, even if the filter was already probing. This makes sure the reset is persisted.
+	if b.onStateChange != nil {
+		b.onStateChange(b, st, false)
+	}
+}
+
+// State returns a snapshot of the filter's state.
+func (b *blackHoleFilter) State() BlackHoleFilterState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BlackHoleFilterState{
+		Name:           b.name,
+		State:          b.effectiveState(),
+		Overridden:     b.override != nil,
+		Successes:      b.successes,
+		Dials:          len(b.dialResults),
+		N:              b.n,
+		MinSuccesses:   b.minSuccesses,
+		NextProbeAfter: b.nextProbeAfter(),
+	}
+}
+
+func (b *blackHoleFilter) effectiveState() network.BlackHoleState {
+	if b.override != nil {
+		return *b.override
+	}
+	return b.state
+}
+
+// unlockAndNotify releases mu, and calls onStateChange if the effective state changed from prev.
+func (b *blackHoleFilter) unlockAndNotify(prev network.BlackHoleState) {
+	st := b.effectiveState()
+	overridden := b.override != nil
+	b.mu.Unlock()
+	if st != prev && b.onStateChange != nil {
+		b.onStateChange(b, st, overridden)
+	}
+}
+
+func (b *blackHoleFilter) nextProbeAfter() int {
+	if b.effectiveState() != network.BlackHoleStateBlocked {
+		return 0
+	}
+	if b.override != nil {
+		// no probes are sent while overridden
+		return -1
+	}
+	return b.n - (b.requests % b.n)
+}
+
 func (b *blackHoleFilter) reset() {
 	b.successes = 0
 	b.dialResults = b.dialResults[:0]
@@ -132,11 +205,11 @@ func (b *blackHoleFilter) updateState() {
 	st := b.state
 
 	if len(b.dialResults) < b.n {
-		b.state = blackHoleStateProbing
+		b.state = network.BlackHoleStateProbing
 	} else if b.successes >= b.minSuccesses {
-		b.state = blackHoleStateAllowed
+		b.state = network.BlackHoleStateAllowed
 	} else {
-		b.state = blackHoleStateBlocked
+		b.state = network.BlackHoleStateBlocked
 	}
 
 	if st != b.state {
@@ -149,10 +222,7 @@ func (b *blackHoleFilter) trackMetrics() {
 		return
 	}
 
-	nextRequestAllowedAfter := 0
-	if b.state == blackHoleStateBlocked {
-		nextRequestAllowedAfter = b.n - (b.requests % b.n)
-	}
+	nextRequestAllowedAfter := b.nextProbeAfter()
 
 	successFraction := 0.0
 	if len(b.dialResults) > 0 {
@@ -161,7 +231,7 @@ func (b *blackHoleFilter) trackMetrics() {
 
 	b.metricsTracer.UpdatedBlackHoleFilterState(
 		b.name,
-		b.state,
+		b.effectiveState(),
 		nextRequestAllowedAfter,
 		successFraction,
 	)
@@ -175,6 +245,11 @@ func (b *blackHoleFilter) trackMetrics() {
 // because of dial prioritisation logic.
 type blackHoleDetector struct {
 	udp, ipv6 *blackHoleFilter
+
+	// store is used to persist the state of the filters, may be nil
+	store ds.Datastore
+	// onStateChange is called when the effective state of a filter changes, may be nil
+	onStateChange func(name string, st network.BlackHoleState, overridden bool)
 }
 
 // FilterAddrs filters the peer's addresses removing black holed addresses
@@ -244,6 +319,92 @@ func (d *blackHoleDetector) RecordResult(addr ma.Multiaddr, success bool) {
 	}
 }
 
+// BlackHoleFilterState is a snapshot of the state of one of the swarm's black hole filters.
+type BlackHoleFilterState struct {
+	// Name is the name of the filter, "UDP" or "IPv6".
+	Name  string
+	State network.BlackHoleState
+	// Overridden is true if the state was set using Swarm.OverrideBlackHoleState.
+	Overridden bool
+	// Successes is the number of successful dials among the last Dials dials.
+	Successes int
+	Dials     int
+	// N and MinSuccesses are the configuration of the filter: Dials are blocked if
+	// there are fewer than MinSuccesses successes in the last N dials.
+	N            int
+	MinSuccesses int
+	// NextProbeAfter is the number of dial requests after which the next probing dial is
+	// allowed in Blocked state. It is -1 if the state is overridden, since no probes are
+	// made in that case.
+	NextProbeAfter int
+}
+
+// blackHoleStoreNamespace is the datastore namespace the state of the black hole filters is persisted in.
+const blackHoleStoreNamespace = "/libp2p/swarm/blackhole"
+
+// storedBlackHoleFilter is the persisted state of a blackHoleFilter
+type storedBlackHoleFilter struct {
+	DialResults []bool                  `json:"dialResults"`
+	Requests    int                     `json:"requests"`
+	Override    *network.BlackHoleState `json:"override,omitempty"`
+}
+
+func (b *blackHoleFilter) storeKey() ds.Key {
+	return ds.NewKey(blackHoleStoreNamespace).ChildString(b.name)
+}
+
+func (b *blackHoleFilter) persist(store ds.Datastore) error {
+	b.mu.Lock()
+	st := storedBlackHoleFilter{
+		DialResults: append([]bool(nil), b.dialResults...),
+		Requests:    b.requests,
+		Override:    b.override,
+	}
+	b.mu.Unlock()
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return store.Put(context.Background(), b.storeKey(), data)
+}
+
+// restore restores the state persisted by persist. It must be called before the filter is used.
+func (b *blackHoleFilter) restore(store ds.Datastore) error {
+	data, err := store.Get(context.Background(), b.storeKey())
+	if err != nil {
+		if err == ds.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	var st storedBlackHoleFilter
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	if st.Override != nil && *st.Override != network.BlackHoleStateAllowed && *st.Override != network.BlackHoleStateBlocked {
+		return fmt.Errorf("invalid black hole state override: %d", *st.Override)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// The window size might have been changed since the state was persisted.
+	if len(st.DialResults) > b.n {
+		st.DialResults = st.DialResults[len(st.DialResults)-b.n:]
+	}
+	b.dialResults = st.DialResults
+	b.successes = 0
+	for _, success := range b.dialResults {
+		if success {
+			b.successes++
+		}
+	}
+	b.requests = st.Requests
+	b.override = st.Override
+	b.updateState()
+	b.trackMetrics()
+	return nil
+}
+
 // blackHoleConfig is the config used for black hole detection
 type blackHoleConfig struct {
 	// Enabled enables black hole detection
@@ -256,7 +417,15 @@ type blackHoleConfig struct {
 }
 
 func newBlackHoleDetector(udpConfig, ipv6Config blackHoleConfig, mt MetricsTracer) *blackHoleDetector {
-	d := &blackHoleDetector{}
+	return newBlackHoleDetectorWithStore(udpConfig, ipv6Config, mt, nil, nil)
+}
+
+// newBlackHoleDetectorWithStore creates a blackHoleDetector that persists the state of its filters
+// in store and restores it on creation. onStateChange is called when the state of a filter changes.
+// Both store and onStateChange may be nil.
+func newBlackHoleDetectorWithStore(udpConfig, ipv6Config blackHoleConfig, mt MetricsTracer,
+	store ds.Datastore, onStateChange func(name string, st network.BlackHoleState, overridden bool)) *blackHoleDetector {
+	d := &blackHoleDetector{store: store, onStateChange: onStateChange}
 
 	if udpConfig.Enabled {
 		d.udp = &blackHoleFilter{
@@ -275,5 +444,66 @@ func newBlackHoleDetector(udpConfig, ipv6Config blackHoleConfig, mt MetricsTrace
 			metricsTracer: mt,
 		}
 	}
+
+	for _, f := range d.filters() {
+		f.onStateChange = d.handleStateChange
+		if store != nil {
+			if err := f.restore(store); err != nil {
+				log.Warnf("failed to restore %s black hole filter state: %s", f.name, err)
+			}
+		}
+	}
 	return d
 }
+
+func (d *blackHoleDetector) filters() []*blackHoleFilter {
+	var fs []*blackHoleFilter
+	if d.udp != nil {
+		fs = append(fs, d.udp)
+	}
+	if d.ipv6 != nil {
+		fs = append(fs, d.ipv6)
+	}
+	return fs
+}
+
+func (d *blackHoleDetector) filter(name string) (*blackHoleFilter, error) {
+	for _, f := range d.filters() {
+		if strings.EqualFold(f.name, name) {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("no black hole filter named %q", name)
+}
+
+func (d *blackHoleDetector) handleStateChange(f *blackHoleFilter, st network.BlackHoleState, overridden bool) {
+	if d.store != nil {
+		if err := f.persist(d.store); err != nil {
+			log.Warnf("failed to persist %s black hole filter state: %s", f.name, err)
+		}
+	}
+	if d.onStateChange != nil {
+		d.onStateChange(f.name, st, overridden)
+	}
+}
+
+// States returns the state of all enabled filters.
+func (d *blackHoleDetector) States() []BlackHoleFilterState {
+	var states []BlackHoleFilterState
+	for _, f := range d.filters() {
+		states = append(states, f.State())
+	}
+	return states
+}
+
+// Close persists the state of all filters.
+func (d *blackHoleDetector) Close() {
+	if d.store == nil {
+		return
+	}
+	for _, f := range d.filters() {
+		if err := f.persist(d.store); err != nil {
+			log.Warnf("failed to persist %s black hole filter state: %s", f.name, err)
+		}
+	}
+}
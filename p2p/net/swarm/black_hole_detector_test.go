@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/libp2p/go-libp2p/core/network"
+
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/require"
 )
@@ -199,3 +203,103 @@ func TestBlackHoleDetectorAddrFiltering(t *testing.T) {
 	require.ElementsMatch(t, bothBlockedOutput, gotAddrs)
 	require.ElementsMatch(t, bothPublicAddrs, gotRemovedAddrs)
 }
+
+func TestBlackHoleDetectorOverride(t *testing.T) {
+	type stateChange struct {
+		name       string
+		state      network.BlackHoleState
+		overridden bool
+	}
+	var changes []stateChange
+	bhd := newBlackHoleDetectorWithStore(
+		blackHoleConfig{Enabled: true, N: 10, MinSuccesses: 5},
+		blackHoleConfig{Enabled: true, N: 10, MinSuccesses: 5},
+		nil, nil,
+		func(name string, st network.BlackHoleState, overridden bool) {
+			changes = append(changes, stateChange{name, st, overridden})
+		},
+	)
+	udpAddr := ma.StringCast("/ip4/1.2.3.4/udp/1234/quic-v1")
+	for i := 0; i < 10; i++ {
+		bhd.RecordResult(udpAddr, false)
+	}
+	require.Equal(t, []stateChange{{"UDP", network.BlackHoleStateBlocked, false}}, changes)
+
+	udp, err := bhd.filter("udp")
+	require.NoError(t, err)
+	_, err = bhd.filter("tcp")
+	require.Error(t, err)
+	require.Error(t, udp.Override(network.BlackHoleStateProbing))
+
+	require.NoError(t, udp.Override(network.BlackHoleStateAllowed))
+	require.Equal(t, stateChange{"UDP", network.BlackHoleStateAllowed, true}, changes[len(changes)-1])
+	// overridden filters allow all dials, and aren't affected by dial outcomes
+	for i := 0; i < 20; i++ {
+		addrs, blocked := bhd.FilterAddrs([]ma.Multiaddr{udpAddr})
+		require.Equal(t, []ma.Multiaddr{udpAddr}, addrs)
+		require.Empty(t, blocked)
+		bhd.RecordResult(udpAddr, false)
+	}
+	require.Len(t, changes, 2)
+
+	require.NoError(t, udp.Override(network.BlackHoleStateBlocked))
+	for i := 0; i < 20; i++ {
+		addrs, _ := bhd.FilterAddrs([]ma.Multiaddr{udpAddr})
+		require.Empty(t, addrs, "expected no probes while overridden")
+	}
+
+	states := bhd.States()
+	require.Len(t, states, 2)
+	require.Equal(t, BlackHoleFilterState{
+		Name:           "UDP",
+		State:          network.BlackHoleStateBlocked,
+		Overridden:     true,
+		Dials:          10,
+		N:              10,
+		MinSuccesses:   5,
+		NextProbeAfter: -1,
+	}, states[0])
+	require.Equal(t, network.BlackHoleStateProbing, states[1].State)
+
+	udp.Reset()
+	require.Equal(t, stateChange{"UDP", network.BlackHoleStateProbing, false}, changes[len(changes)-1])
+	st := udp.State()
+	require.False(t, st.Overridden)
+	require.Zero(t, st.Dials)
+}
+
+func TestBlackHoleDetectorPersistence(t *testing.T) {
+	store := dssync.MutexWrap(ds.NewMapDatastore())
+	udpConfig := blackHoleConfig{Enabled: true, N: 10, MinSuccesses: 5}
+	ipv6Config := blackHoleConfig{Enabled: true, N: 10, MinSuccesses: 5}
+	udpAddr := ma.StringCast("/ip4/1.2.3.4/udp/1234/quic-v1")
+	ipv6Addr := ma.StringCast("/ip6/1::1/tcp/1234")
+
+	bhd := newBlackHoleDetectorWithStore(udpConfig, ipv6Config, nil, store, nil)
+	for i := 0; i < 10; i++ {
+		bhd.RecordResult(udpAddr, false)
+		bhd.RecordResult(ipv6Addr, true)
+	}
+	bhd.RecordResult(ipv6Addr, false)
+	bhd.Close()
+
+	bhd = newBlackHoleDetectorWithStore(udpConfig, ipv6Config, nil, store, nil)
+	states := bhd.States()
+	require.Equal(t, network.BlackHoleStateBlocked, states[0].State)
+	require.Equal(t, network.BlackHoleStateAllowed, states[1].State)
+	require.Equal(t, 9, states[1].Successes)
+	require.Equal(t, 10, states[1].Dials)
+
+	// overrides are persisted as well
+	ipv6, err := bhd.filter("IPv6")
+	require.NoError(t, err)
+	require.NoError(t, ipv6.Override(network.BlackHoleStateBlocked))
+	bhd = newBlackHoleDetectorWithStore(udpConfig, ipv6Config, nil, store, nil)
+	require.Equal(t, network.BlackHoleStateBlocked, bhd.States()[1].State)
+	require.True(t, bhd.States()[1].Overridden)
+
+	// a smaller window is applied to the restored state
+	bhd = newBlackHoleDetectorWithStore(udpConfig, blackHoleConfig{Enabled: true, N: 5, MinSuccesses: 5}, nil, store, nil)
+	require.Equal(t, 5, bhd.States()[1].Dials)
+	require.Equal(t, 4, bhd.States()[1].Successes)
+}
@@ -0,0 +1,262 @@
+package swarm
+
+import (
+	"encoding/gob"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// PeerDialRanker is a dial ranker that takes the peer being dialed into account, and
+// learns from the outcome of previous dials. It's used instead of the network.DialRanker
+// if configured using WithPeerDialRanker.
+type PeerDialRanker interface {
+	// RankAddrs returns the dial schedule for the addresses of peer p.
+	RankAddrs(p peer.ID, addrs []ma.Multiaddr) []network.AddrDelay
+	// DialSucceeded is called when a dial to addr established a connection to peer p.
+	// d is the time it took from starting the dial until the connection was established.
+	DialSucceeded(p peer.ID, addr ma.Multiaddr, d time.Duration)
+}
+
+const (
+	// LastGoodAddrKey is the peerstore metadata key the AdaptiveDialRanker stores the
+	// LastGoodAddr of a peer under.
+	LastGoodAddrKey = "swarm/LastGoodAddr"
+
+	// MinAdaptiveDelay and MaxAdaptiveDelay bound the delay by which the AdaptiveDialRanker
+	// delays the remaining dials relative to the dial to the last good address.
+	MinAdaptiveDelay = 20 * time.Millisecond
+	MaxAdaptiveDelay = 2 * PublicTCPDelay
+
+	// LastGoodAddrTTL is how long a LastGoodAddr is used after the dial that stored it.
+	LastGoodAddrTTL = 7 * 24 * time.Hour
+
+	// maxPrefixStats is the maximum number of IP prefixes the AdaptiveDialRanker keeps statistics for.
+	maxPrefixStats = 2048
+	// prefixStatsAlpha is the weight given to a new sample in the dial duration EWMA.
+	prefixStatsAlpha = 0.3
+)
+
+func init() {
+	// Register LastGoodAddr so that it can be stored in a datastore backed peerstore.
+	gob.Register(LastGoodAddr{})
+}
+
+// LastGoodAddr is the hint the AdaptiveDialRanker persists in the peerstore: the address
+// of the last successful dial to a peer, and how long that dial took.
+type LastGoodAddr struct {
+	// Addr is the binary representation of the multiaddr.
+	Addr         []byte
+	DialDuration time.Duration
+	// Time is the time of the dial. Hints older than LastGoodAddrTTL are ignored.
+	Time time.Time
+}
+
+type prefixStats struct {
+	dialDuration time.Duration // EWMA of the dial durations
+	lastUpdated  time.Time
+}
+
+// AdaptiveDialRanker is a PeerDialRanker that learns which address successfully connected
+// to a peer, and how fast. When reconnecting, the last good address is dialed first, and
+// the remaining addresses are scheduled by the fallback ranker, delayed by a multiple of
+// the expected dial duration. The expected dial duration is derived from the duration of the
+// last successful dial and the peer's latency as recorded in the peerstore.
+//
+// For peers without a last good address, the ranker uses the dial durations of other peers
+// in the same IP prefix (/24 for IPv4, /48 for IPv6) using the same transport.
+//
+// The last good address is persisted in the peerstore under LastGoodAddrKey, so it
+// survives restarts when using a persistent peerstore. Dials over relays are not taken
+// into account, relay addresses are always scheduled by the fallback ranker.
+type AdaptiveDialRanker struct {
+	ps       peerstore.Peerstore
+	fallback network.DialRanker
+
+	mx       sync.Mutex
+	prefixes map[string]*prefixStats
+}
+
+var _ PeerDialRanker = (*AdaptiveDialRanker)(nil)
+
+// NewAdaptiveDialRanker creates a new AdaptiveDialRanker. fallback is used to rank the
+// addresses that aren't preferred, if nil DefaultDialRanker is used.
+func NewAdaptiveDialRanker(ps peerstore.Peerstore, fallback network.DialRanker) *AdaptiveDialRanker {
+	if fallback == nil {
+		fallback = DefaultDialRanker
+	}
+	return &AdaptiveDialRanker{
+		ps:       ps,
+		fallback: fallback,
+		prefixes: make(map[string]*prefixStats),
+	}
+}
+
+// RankAddrs implements PeerDialRanker.
+func (r *AdaptiveDialRanker) RankAddrs(p peer.ID, addrs []ma.Multiaddr) []network.AddrDelay {
+	preferred, expected := r.preferredAddr(p, addrs)
+	if preferred == nil {
+		return r.fallback(addrs)
+	}
+
+	rest := make([]ma.Multiaddr, 0, len(addrs)-1)
+	for _, a := range addrs {
+		if !a.Equal(preferred) {
+			rest = append(rest, a)
+		}
+	}
+	delay := 2 * expected
+	if delay < MinAdaptiveDelay {
+		delay = MinAdaptiveDelay
+	}
+	if delay > MaxAdaptiveDelay {
+		delay = MaxAdaptiveDelay
+	}
+
+	res := make([]network.AddrDelay, 0, len(addrs))
+	res = append(res, network.AddrDelay{Addr: preferred, Delay: 0})
+	for _, ad := range r.fallback(rest) {
+		res = append(res, network.AddrDelay{Addr: ad.Addr, Delay: ad.Delay + delay})
+	}
+	return res
+}
+
+// preferredAddr returns the address to dial first, and the expected duration of that dial.
+func (r *AdaptiveDialRanker) preferredAddr(p peer.ID, addrs []ma.Multiaddr) (ma.Multiaddr, time.Duration) {
+	if hint, ok := r.LastGoodAddr(p); ok && time.Since(hint.Time) <= LastGoodAddrTTL {
+		for _, a := range addrs {
+			if string(a.Bytes()) != string(hint.Addr) || isRelayAddr(a) {
+				continue
+			}
+			expected := hint.DialDuration
+			// The latency may have changed since the last dial.
+			if l := r.ps.LatencyEWMA(p); l > 0 {
+				if d := time.Duration(handshakeRTTs(a)) * l; d > expected {
+					expected = d
+				}
+			}
+			return a, expected
+		}
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	var best ma.Multiaddr
+	var bestDuration time.Duration
+	for _, a := range addrs {
+		key, ok := prefixKey(a)
+		if !ok {
+			continue
+		}
+		if s, ok := r.prefixes[key]; ok && (best == nil || s.dialDuration < bestDuration) {
+			best = a
+			bestDuration = s.dialDuration
+		}
+	}
+	return best, bestDuration
+}
+
+// DialSucceeded implements PeerDialRanker.
+func (r *AdaptiveDialRanker) DialSucceeded(p peer.ID, addr ma.Multiaddr, d time.Duration) {
+	// A relayed connection says nothing about the direct addresses, and shouldn't
+	// take precedence over them on the next dial.
+	if isRelayAddr(addr) {
+		return
+	}
+	now := time.Now()
+	if err := r.ps.Put(p, LastGoodAddrKey, LastGoodAddr{Addr: addr.Bytes(), DialDuration: d, Time: now}); err != nil {
+		log.Debugw("failed to store last good address", "peer", p, "error", err)
+	}
+
+	key, ok := prefixKey(addr)
+	if !ok {
+		return
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if s, ok := r.prefixes[key]; ok {
+		s.dialDuration = time.Duration(prefixStatsAlpha*float64(d) + (1-prefixStatsAlpha)*float64(s.dialDuration))
+		s.lastUpdated = now
+		return
+	}
+	if len(r.prefixes) >= maxPrefixStats {
+		r.evictOldestLocked()
+	}
+	r.prefixes[key] = &prefixStats{dialDuration: d, lastUpdated: now}
+}
+
+func (r *AdaptiveDialRanker) evictOldestLocked() {
+	var oldestKey string
+	var oldest time.Time
+	for k, s := range r.prefixes {
+		if oldestKey == "" || s.lastUpdated.Before(oldest) {
+			oldestKey = k
+			oldest = s.lastUpdated
+		}
+	}
+	delete(r.prefixes, oldestKey)
+}
+
+// LastGoodAddr returns the last good address stored for peer p.
+func (r *AdaptiveDialRanker) LastGoodAddr(p peer.ID) (LastGoodAddr, bool) {
+	v, err := r.ps.Get(p, LastGoodAddrKey)
+	if err != nil {
+		return LastGoodAddr{}, false
+	}
+	hint, ok := v.(LastGoodAddr)
+	return hint, ok
+}
+
+// handshakeRTTs is the (rough) number of round trips it takes to establish a connection.
+func handshakeRTTs(a ma.Multiaddr) int {
+	switch {
+	case isProtocolAddr(a, ma.P_WEBTRANSPORT):
+		return 2 // QUIC handshake + HTTP/3 CONNECT
+	case isQUICAddr(a):
+		return 1
+	case isProtocolAddr(a, ma.P_TCP):
+		return 3 // TCP handshake + security handshake + muxer negotiation
+	default:
+		return 1
+	}
+}
+
+// prefixKey returns the key under which dial statistics for a are aggregated:
+// the IP prefix (/24 for IPv4, /48 for IPv6) and the transport protocols.
+// Relay addresses and addresses without an IP are not aggregated.
+func prefixKey(a ma.Multiaddr) (string, bool) {
+	if isRelayAddr(a) {
+		return "", false
+	}
+	ip, err := manet.ToIP(a)
+	if err != nil {
+		return "", false
+	}
+	var prefix string
+	if ip4 := ip.To4(); ip4 != nil {
+		prefix = ip4.Mask(net.CIDRMask(24, 32)).String()
+	} else {
+		prefix = ip.Mask(net.CIDRMask(48, 128)).String()
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	ma.ForEach(a, func(c ma.Component) bool {
+		switch c.Protocol().Code {
+		case ma.P_IP4, ma.P_IP6, ma.P_IP6ZONE, ma.P_CERTHASH:
+		default:
+			b.WriteByte('/')
+			b.WriteString(c.Protocol().Name)
+		}
+		return true
+	})
+	return b.String(), true
+}
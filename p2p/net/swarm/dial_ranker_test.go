@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/test"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
 	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
 )
 
 func sortAddrDelays(addrDelays []network.AddrDelay) {
@@ -259,3 +262,105 @@ func TestDelayRankerRelay(t *testing.T) {
 		})
 	}
 }
+
+func TestAdaptiveDialRanker(t *testing.T) {
+	ps, err := pstoremem.NewPeerstore()
+	require.NoError(t, err)
+	defer ps.Close()
+	r := NewAdaptiveDialRanker(ps, nil)
+
+	q1 := ma.StringCast("/ip4/1.2.3.4/udp/1/quic-v1")
+	q1v6 := ma.StringCast("/ip6/1::1/udp/1/quic-v1")
+	t1 := ma.StringCast("/ip4/1.2.3.4/tcp/1")
+	p1 := test.RandPeerIDFatal(t)
+	addrs := []ma.Multiaddr{q1, q1v6, t1}
+
+	// without any history, the fallback ranker is used
+	res := r.RankAddrs(p1, append([]ma.Multiaddr{}, addrs...))
+	require.Equal(t, DefaultDialRanker(append([]ma.Multiaddr{}, addrs...)), res)
+
+	// the last good address is dialed first, the others are delayed by the expected dial duration
+	r.DialSucceeded(p1, t1, 50*time.Millisecond)
+	hint, ok := r.LastGoodAddr(p1)
+	require.True(t, ok)
+	require.Equal(t, t1.Bytes(), hint.Addr)
+	res = r.RankAddrs(p1, append([]ma.Multiaddr{}, addrs...))
+	require.Equal(t, []network.AddrDelay{
+		{Addr: t1, Delay: 0},
+		{Addr: q1v6, Delay: 100 * time.Millisecond},
+		{Addr: q1, Delay: 100*time.Millisecond + PublicQUICDelay},
+	}, res)
+
+	// the delay accounts for the current latency of the peer
+	ps.RecordLatency(p1, 100*time.Millisecond)
+	res = r.RankAddrs(p1, append([]ma.Multiaddr{}, addrs...))
+	require.Equal(t, t1, res[0].Addr)
+	require.Equal(t, MaxAdaptiveDelay, res[1].Delay)
+
+	// the delay is never shorter than MinAdaptiveDelay
+	p2 := test.RandPeerIDFatal(t)
+	r.DialSucceeded(p2, q1, time.Millisecond)
+	res = r.RankAddrs(p2, append([]ma.Multiaddr{}, addrs...))
+	require.Equal(t, q1, res[0].Addr)
+	require.Equal(t, MinAdaptiveDelay, res[1].Delay)
+
+	// peers in the same prefix prefer the transport that worked for other peers
+	p3 := test.RandPeerIDFatal(t)
+	q2 := ma.StringCast("/ip4/1.2.3.5/udp/2/quic-v1")
+	t2 := ma.StringCast("/ip4/1.2.3.5/tcp/2")
+	res = r.RankAddrs(p3, []ma.Multiaddr{q2, t2})
+	require.Equal(t, q2, res[0].Addr)
+	require.Equal(t, t2, res[1].Addr)
+	require.Equal(t, MinAdaptiveDelay, res[1].Delay)
+
+	// if the last good address isn't available anymore, fall back to the prefix statistics
+	res = r.RankAddrs(p1, []ma.Multiaddr{q2, t2})
+	require.Equal(t, q2, res[0].Addr)
+}
+
+func TestAdaptiveDialRankerIgnoresRelayAndStaleHints(t *testing.T) {
+	ps, err := pstoremem.NewPeerstore()
+	require.NoError(t, err)
+	defer ps.Close()
+	r := NewAdaptiveDialRanker(ps, nil)
+
+	q1 := ma.StringCast("/ip4/1.2.3.4/udp/1/quic-v1")
+	t1 := ma.StringCast("/ip4/1.2.3.4/tcp/1")
+	r1 := ma.StringCast("/ip4/1.2.3.5/tcp/1/p2p/12D3KooWSh1CpHBYAC2ZeCtQA5q9cWfLzqnFanZGRsCa8bqpVcfa/p2p-circuit")
+	addrs := []ma.Multiaddr{q1, t1, r1}
+
+	// a relayed dial is not remembered, relay addresses keep their delay
+	p1 := test.RandPeerIDFatal(t)
+	r.DialSucceeded(p1, r1, time.Millisecond)
+	_, ok := r.LastGoodAddr(p1)
+	require.False(t, ok)
+	require.Equal(t, DefaultDialRanker(append([]ma.Multiaddr{}, addrs...)), r.RankAddrs(p1, append([]ma.Multiaddr{}, addrs...)))
+
+	// a relay address stored by an older version is ignored
+	require.NoError(t, ps.Put(p1, LastGoodAddrKey, LastGoodAddr{Addr: r1.Bytes(), Time: time.Now()}))
+	require.Equal(t, DefaultDialRanker(append([]ma.Multiaddr{}, addrs...)), r.RankAddrs(p1, append([]ma.Multiaddr{}, addrs...)))
+
+	// stale hints are ignored
+	p2 := test.RandPeerIDFatal(t)
+	require.NoError(t, ps.Put(p2, LastGoodAddrKey, LastGoodAddr{Addr: t1.Bytes(), Time: time.Now().Add(-LastGoodAddrTTL - time.Minute)}))
+	require.Equal(t, DefaultDialRanker(append([]ma.Multiaddr{}, addrs...)), r.RankAddrs(p2, append([]ma.Multiaddr{}, addrs...)))
+}
+
+func TestPrefixKey(t *testing.T) {
+	for _, tc := range []struct {
+		addr string
+		key  string
+	}{
+		{"/ip4/1.2.3.4/tcp/1", "1.2.3.0/tcp"},
+		{"/ip4/1.2.3.200/udp/2/quic-v1", "1.2.3.0/udp/quic-v1"},
+		{"/ip6/2001:db8:1:2::1/udp/2/quic-v1/webtransport/certhash/uEgNmb28", "2001:db8:1::/udp/quic-v1/webtransport"},
+	} {
+		key, ok := prefixKey(ma.StringCast(tc.addr))
+		require.True(t, ok)
+		require.Equal(t, tc.key, key, tc.addr)
+	}
+	_, ok := prefixKey(ma.StringCast("/dns/example.com/tcp/1"))
+	require.False(t, ok)
+	_, ok = prefixKey(ma.StringCast("/ip4/1.2.3.4/tcp/1/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC/p2p-circuit"))
+	require.False(t, ok)
+}
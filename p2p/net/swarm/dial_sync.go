@@ -49,6 +49,9 @@ func (ad *activeDial) dial(ctx context.Context) (*Conn, error) {
 	if simConnect, isClient, reason := network.GetSimultaneousConnect(ctx); simConnect {
 		dialCtx = network.WithSimultaneousConnect(dialCtx, isClient, reason)
 	}
+	if addrs, ok := network.GetDialAddrs(ctx); ok {
+		dialCtx = network.WithDialAddrs(dialCtx, addrs)
+	}
 
 	resch := make(chan dialResponse, 1)
 	select {
@@ -0,0 +1,161 @@
+package swarm
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// DialTracer receives events about every dial the swarm makes. It is configured using
+// WithDialTracer.
+//
+// Events are emitted from the dial worker loop of the peer being dialed. Events for a single
+// peer are delivered sequentially, events for different peers concurrently.
+// DialEvent must not block.
+type DialTracer interface {
+	DialEvent(DialEvent)
+}
+
+// DialEventType is the type of a DialEvent.
+type DialEventType int
+
+const (
+	// DialEventStart is emitted when the swarm starts dialing a peer.
+	DialEventStart DialEventType = iota
+	// DialEventAddrSkipped is emitted for an address that won't be dialed, for example
+	// because it was blocked by the connection gater or the black hole detector, or the
+	// address is in backoff. Err contains the reason.
+	DialEventAddrSkipped
+	// DialEventAddrRanked is emitted for every address scheduled for dialing. Delay is the
+	// delay assigned by the dial ranker.
+	DialEventAddrRanked
+	// DialEventAddrDialStart is emitted when a dial to an address is started.
+	DialEventAddrDialStart
+	// DialEventAddrDialSuccess is emitted when a dial to an address established a connection.
+	DialEventAddrDialSuccess
+	// DialEventAddrDialFailed is emitted when a dial to an address failed. Err contains the error.
+	DialEventAddrDialFailed
+	// DialEventAddrDialCancelled is emitted for dials that were still scheduled or in flight
+	// when the dial to the peer completed, usually because another address connected first.
+	DialEventAddrDialCancelled
+	// DialEventEnd is emitted when the swarm stops dialing the peer. Err is nil if a
+	// connection was established.
+	DialEventEnd
+)
+
+func (t DialEventType) String() string {
+	switch t {
+	case DialEventStart:
+		return "start"
+	case DialEventAddrSkipped:
+		return "skipped"
+	case DialEventAddrRanked:
+		return "ranked"
+	case DialEventAddrDialStart:
+		return "dialing"
+	case DialEventAddrDialSuccess:
+		return "connected"
+	case DialEventAddrDialFailed:
+		return "failed"
+	case DialEventAddrDialCancelled:
+		return "cancelled"
+	case DialEventEnd:
+		return "end"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+// DialEvent is an event emitted while dialing a peer.
+type DialEvent struct {
+	Type DialEventType
+	Peer peer.ID
+	Time time.Time
+	// Addr is the address this event refers to. It is nil for DialEventStart and DialEventEnd.
+	Addr ma.Multiaddr
+	// Delay is the ranking delay of the address. Only set for DialEventAddrRanked.
+	Delay time.Duration
+	Err   error
+}
+
+// DialRecorder is a DialTracer that keeps the events of the most recent dial to every peer.
+// It's meant for debugging, events are kept until Forget is called.
+type DialRecorder struct {
+	mx     sync.Mutex
+	events map[peer.ID][]DialEvent
+}
+
+var _ DialTracer = (*DialRecorder)(nil)
+
+// NewDialRecorder creates a new DialRecorder.
+func NewDialRecorder() *DialRecorder {
+	return &DialRecorder{events: make(map[peer.ID][]DialEvent)}
+}
+
+// DialEvent implements DialTracer.
+func (r *DialRecorder) DialEvent(ev DialEvent) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if ev.Type == DialEventStart {
+		// only keep the latest dial
+		r.events[ev.Peer] = r.events[ev.Peer][:0]
+	}
+	r.events[ev.Peer] = append(r.events[ev.Peer], ev)
+}
+
+// Events returns the events recorded for the most recent dial to peer p.
+func (r *DialRecorder) Events(p peer.ID) []DialEvent {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return append([]DialEvent(nil), r.events[p]...)
+}
+
+// Timeline renders the events recorded for the most recent dial to peer p, see FormatDialTimeline.
+func (r *DialRecorder) Timeline(p peer.ID) string {
+	return FormatDialTimeline(r.Events(p))
+}
+
+// Forget removes the events recorded for peer p.
+func (r *DialRecorder) Forget(p peer.ID) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	delete(r.events, p)
+}
+
+// FormatDialTimeline renders a human-readable timeline of dial events, one event per line,
+// with times relative to the first event:
+//
+//	+0s        start
+//	+0s        ranked     /ip4/1.2.3.4/udp/1234/quic-v1 (delay 0s)
+//	+0s        ranked     /ip4/1.2.3.4/tcp/1234 (delay 250ms)
+//	+0s        dialing    /ip4/1.2.3.4/udp/1234/quic-v1
+//	+250ms     dialing    /ip4/1.2.3.4/tcp/1234
+//	+5s        failed     /ip4/1.2.3.4/udp/1234/quic-v1: timeout: no recent network activity
+//	+5.01s     connected  /ip4/1.2.3.4/tcp/1234
+//	+5.01s     end
+func FormatDialTimeline(events []DialEvent) string {
+	if len(events) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	start := events[0].Time
+	for _, ev := range events {
+		fmt.Fprintf(&b, "%-10s %-10s", "+"+ev.Time.Sub(start).String(), ev.Type)
+		if ev.Addr != nil {
+			fmt.Fprintf(&b, " %s", ev.Addr)
+		}
+		if ev.Type == DialEventAddrRanked {
+			fmt.Fprintf(&b, " (delay %s)", ev.Delay)
+		}
+		if ev.Err != nil {
+			fmt.Fprintf(&b, ": %s", ev.Err)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
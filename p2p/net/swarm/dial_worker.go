@@ -2,6 +2,7 @@ package swarm
 
 import (
 	"context"
+	"errors"
 	"math"
 	"sync"
 	"time"
@@ -62,6 +63,8 @@ type addrDial struct {
 	createdAt time.Time
 	// dialRankingDelay is the delay in dialing this address introduced by the ranking logic
 	dialRankingDelay time.Duration
+	// dialedAt is the time the dial to this address was started
+	dialedAt time.Time
 }
 
 // dialWorker synchronises concurrent dials to a peer. It ensures that we make at most one dial to a
@@ -136,6 +139,7 @@ func (w *dialWorker) loop() {
 
 	// totalDials is used to track number of dials made by this worker for metrics
 	totalDials := 0
+	w.trace(DialEvent{Type: DialEventStart})
 loop:
 	for {
 		// The loop has three parts
@@ -152,6 +156,9 @@ loop:
 				if w.s.metricsTracer != nil {
 					w.s.metricsTracer.DialCompleted(w.connected, totalDials)
 				}
+				if w.s.dialTracer != nil {
+					w.traceEnd(dialsInFlight)
+				}
 				return
 			}
 			// We have received a new request. If we do not have a suitable connection,
@@ -166,6 +173,9 @@ loop:
 			}
 
 			addrs, addrErrs, err := w.s.addrsForDial(req.ctx, w.peer)
+			for _, te := range addrErrs {
+				w.trace(DialEvent{Type: DialEventAddrSkipped, Addr: te.Address, Err: te.Cause})
+			}
 			if err != nil {
 				req.resch <- dialResponse{
 					err: &DialError{
@@ -262,6 +272,7 @@ loop:
 						createdAt: now,
 					}
 					dq.Add(network.AddrDelay{Addr: a, Delay: addrDelay[string(a.Bytes())]})
+					w.trace(DialEvent{Type: DialEventAddrRanked, Addr: a, Delay: addrDelay[string(a.Bytes())]})
 				}
 			}
 			// setup dialTimer for updates to dq
@@ -282,16 +293,19 @@ loop:
 					continue
 				}
 				ad.dialed = true
+				ad.dialedAt = now
 				ad.dialRankingDelay = now.Sub(ad.createdAt)
 				err := w.s.dialNextAddr(ad.ctx, w.peer, ad.addr, w.resch)
 				if err != nil {
 					// Errored without attempting a dial. This happens in case of
 					// backoff or black hole.
+					w.trace(DialEvent{Type: DialEventAddrSkipped, Addr: ad.addr, Err: err})
 					w.dispatchError(ad, err)
 				} else {
 					// the dial was successful. update inflight dials
 					dialsInFlight++
 					totalDials++
+					w.trace(DialEvent{Type: DialEventAddrDialStart, Addr: ad.addr})
 				}
 			}
 			timerRunning = false
@@ -319,9 +333,14 @@ loop:
 				if err != nil {
 					// oops no, we failed to add it to the swarm
 					res.Conn.Close()
+					w.trace(DialEvent{Type: DialEventAddrDialFailed, Addr: ad.addr, Err: err})
 					w.dispatchError(ad, err)
 					continue loop
 				}
+				w.trace(DialEvent{Type: DialEventAddrDialSuccess, Addr: ad.addr})
+				if w.s.peerDialRanker != nil {
+					w.s.peerDialRanker.DialSucceeded(w.peer, ad.addr, time.Since(ad.dialedAt))
+				}
 
 				for pr := range w.pendingRequests {
 					if _, ok := pr.addrs[string(ad.addr.Bytes())]; ok {
@@ -352,6 +371,11 @@ loop:
 					w.peer, res.Addr)
 			}
 
+			if errors.Is(res.Err, context.Canceled) {
+				w.trace(DialEvent{Type: DialEventAddrDialCancelled, Addr: ad.addr, Err: res.Err})
+			} else {
+				w.trace(DialEvent{Type: DialEventAddrDialFailed, Addr: ad.addr, Err: res.Err})
+			}
 			w.dispatchError(ad, res.Err)
 			// Only schedule next dial on error.
 			// If we scheduleNextDial on success, we will end up making one dial more than
@@ -361,6 +385,35 @@ loop:
 	}
 }
 
+// trace emits ev to the swarm's DialTracer, if any
+func (w *dialWorker) trace(ev DialEvent) {
+	if w.s.dialTracer == nil {
+		return
+	}
+	ev.Peer = w.peer
+	ev.Time = w.cl.Now()
+	w.s.dialTracer.DialEvent(ev)
+}
+
+// traceEnd emits the events for the end of the dial: addresses that were still
+// scheduled or being dialed are reported as cancelled.
+func (w *dialWorker) traceEnd(dialsInFlight int) {
+	for _, ad := range w.trackedDials {
+		if ad.conn == nil && ad.err == nil {
+			w.trace(DialEvent{Type: DialEventAddrDialCancelled, Addr: ad.addr})
+		}
+	}
+	var err error
+	if !w.connected {
+		if dialsInFlight > 0 {
+			err = context.Canceled
+		} else {
+			err = ErrAllDialsFailed
+		}
+	}
+	w.trace(DialEvent{Type: DialEventEnd, Err: err})
+}
+
 // dispatches an error to a specific addr dial
 func (w *dialWorker) dispatchError(ad *addrDial, err error) {
 	ad.err = err
@@ -400,6 +453,9 @@ func (w *dialWorker) rankAddrs(addrs []ma.Multiaddr, isSimConnect bool) []networ
 	if isSimConnect {
 		return NoDelayDialRanker(addrs)
 	}
+	if w.s.peerDialRanker != nil {
+		return w.s.peerDialRanker.RankAddrs(w.peer, addrs)
+	}
 	return w.s.dialRanker(addrs)
 }
 
@@ -9,6 +9,7 @@ import (
 	mrand "math/rand"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"testing/quick"
@@ -1081,3 +1082,72 @@ func TestDialWorkerLoopAddrDedup(t *testing.T) {
 		t.Errorf("expected a fail response")
 	}
 }
+
+func TestDialWorkerLoopPeerDialRanker(t *testing.T) {
+	s1 := makeSwarmWithNoListenAddrs(t)
+	defer s1.Close()
+	s2 := makeSwarm(t)
+	defer s2.Close()
+
+	r := NewAdaptiveDialRanker(s1.Peerstore(), nil)
+	s1.peerDialRanker = r
+	s1.Peerstore().AddAddrs(s2.LocalPeer(), s2.ListenAddresses(), peerstore.PermanentAddrTTL)
+
+	c, err := s1.DialPeer(context.Background(), s2.LocalPeer())
+	require.NoError(t, err)
+
+	hint, ok := r.LastGoodAddr(s2.LocalPeer())
+	require.True(t, ok)
+	require.Equal(t, c.RemoteMultiaddr().Bytes(), hint.Addr)
+	require.Positive(t, hint.DialDuration)
+}
+
+func TestDialWorkerLoopTracer(t *testing.T) {
+	s1 := makeSwarmWithNoListenAddrs(t)
+	defer s1.Close()
+	s2 := makeSwarm(t)
+	defer s2.Close()
+
+	rec := NewDialRecorder()
+	s1.dialTracer = rec
+	var tcpAddr ma.Multiaddr
+	for _, a := range s2.ListenAddresses() {
+		if isProtocolAddr(a, ma.P_TCP) {
+			tcpAddr = a
+		}
+	}
+	require.NotNil(t, tcpAddr)
+	blocked := ma.StringCast("/ip4/1.2.3.4/udp/1234") // there is no transport for plain UDP
+	s1.Peerstore().AddAddrs(s2.LocalPeer(), []ma.Multiaddr{tcpAddr, blocked}, peerstore.PermanentAddrTTL)
+
+	_, err := s1.DialPeer(context.Background(), s2.LocalPeer())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		events := rec.Events(s2.LocalPeer())
+		return len(events) > 0 && events[len(events)-1].Type == DialEventEnd
+	}, 5*time.Second, 10*time.Millisecond)
+
+	events := rec.Events(s2.LocalPeer())
+	var types []DialEventType
+	for _, ev := range events {
+		require.Equal(t, s2.LocalPeer(), ev.Peer)
+		types = append(types, ev.Type)
+	}
+	require.Equal(t, []DialEventType{
+		DialEventStart,
+		DialEventAddrSkipped,
+		DialEventAddrRanked,
+		DialEventAddrDialStart,
+		DialEventAddrDialSuccess,
+		DialEventEnd,
+	}, types)
+	require.True(t, events[1].Addr.Equal(blocked))
+	require.Error(t, events[1].Err)
+	require.True(t, events[2].Addr.Equal(tcpAddr))
+	require.NoError(t, events[5].Err)
+
+	timeline := rec.Timeline(s2.LocalPeer())
+	require.Contains(t, timeline, "connected  "+tcpAddr.String())
+	require.Len(t, strings.Split(strings.TrimSpace(timeline), "\n"), len(events))
+}
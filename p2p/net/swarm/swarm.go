@@ -18,6 +18,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/peerstore"
 	"github.com/libp2p/go-libp2p/core/transport"
 
+	ds "github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log/v2"
 	ma "github.com/multiformats/go-multiaddr"
 	madns "github.com/multiformats/go-multiaddr-dns"
@@ -111,6 +112,26 @@ func WithDialRanker(d network.DialRanker) Option {
 	}
 }
 
+// WithPeerDialRanker configures swarm to use r for ranking addresses. It takes precedence
+// over the DialRanker set using WithDialRanker.
+func WithPeerDialRanker(r PeerDialRanker) Option {
+	return func(s *Swarm) error {
+		if r == nil {
+			return errors.New("swarm: peer dial ranker cannot be nil")
+		}
+		s.peerDialRanker = r
+		return nil
+	}
+}
+
+// WithDialTracer configures swarm to report the progress of every dial to t
+func WithDialTracer(t DialTracer) Option {
+	return func(s *Swarm) error {
+		s.dialTracer = t
+		return nil
+	}
+}
+
 // WithUDPBlackHoleConfig configures swarm to use c as the config for UDP black hole detection
 // n is the size of the sliding window used to evaluate black hole state
 // min is the minimum number of successes out of n required to not block requests
@@ -121,6 +142,16 @@ func WithUDPBlackHoleConfig(enabled bool, n, min int) Option {
 	}
 }
 
+// WithBlackHoleStateStore configures swarm to persist the state of the black hole filters in d.
+// The persisted state is restored when the swarm is created, so the swarm doesn't need to
+// start probing again after a restart. This includes overrides set using OverrideBlackHoleState.
+func WithBlackHoleStateStore(d ds.Datastore) Option {
+	return func(s *Swarm) error {
+		s.blackHoleStore = d
+		return nil
+	}
+}
+
 // WithIPv6BlackHoleConfig configures swarm to use c as the config for IPv6 black hole detection
 // n is the size of the sliding window used to evaluate black hole state
 // min is the minimum number of successes out of n required to not block requests
@@ -143,7 +174,8 @@ type Swarm struct {
 	// down before continuing.
 	refs sync.WaitGroup
 
-	emitter event.Emitter
+	emitter          event.Emitter
+	blackHoleEmitter event.Emitter
 
 	rcmgr network.ResourceManager
 
@@ -195,11 +227,18 @@ type Swarm struct {
 	bwc           metrics.Reporter
 	metricsTracer MetricsTracer
 
-	dialRanker network.DialRanker
+	dialRanker     network.DialRanker
+	peerDialRanker PeerDialRanker
+	dialTracer     DialTracer
 
 	udpBlackHoleConfig  blackHoleConfig
 	ipv6BlackHoleConfig blackHoleConfig
+	blackHoleStore      ds.Datastore
 	bhd                 *blackHoleDetector
+
+	connPool            connPool
+	directDialFallbacks directDialFallbacks
+	datagramHandlers    datagramHandlers
 }
 
 // NewSwarm constructs a Swarm.
@@ -208,11 +247,17 @@ func NewSwarm(local peer.ID, peers peerstore.Peerstore, eventBus event.Bus, opts
 	if err != nil {
 		return nil, err
 	}
+	blackHoleEmitter, err := eventBus.Emitter(new(event.EvtBlackHoleStateChanged))
+	if err != nil {
+		emitter.Close()
+		return nil, err
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	s := &Swarm{
 		local:            local,
 		peers:            peers,
 		emitter:          emitter,
+		blackHoleEmitter: blackHoleEmitter,
 		ctx:              ctx,
 		ctxCancel:        cancel,
 		dialTimeout:      defaultDialTimeout,
@@ -246,11 +291,44 @@ func NewSwarm(local peer.ID, peers peerstore.Peerstore, eventBus event.Bus, opts
 	s.limiter = newDialLimiter(s.dialAddr)
 	s.backf.init(s.ctx)
 
-	s.bhd = newBlackHoleDetector(s.udpBlackHoleConfig, s.ipv6BlackHoleConfig, s.metricsTracer)
+	s.bhd = newBlackHoleDetectorWithStore(s.udpBlackHoleConfig, s.ipv6BlackHoleConfig, s.metricsTracer,
+		s.blackHoleStore, s.emitBlackHoleStateChanged)
 
 	return s, nil
 }
 
+func (s *Swarm) emitBlackHoleStateChanged(name string, st network.BlackHoleState, overridden bool) {
+	s.blackHoleEmitter.Emit(event.EvtBlackHoleStateChanged{Filter: name, State: st, Overridden: overridden})
+}
+
+// BlackHoleFilterStates returns the state of the enabled black hole filters.
+func (s *Swarm) BlackHoleFilterStates() []BlackHoleFilterState {
+	return s.bhd.States()
+}
+
+// OverrideBlackHoleState forces the black hole filter with the given name ("UDP" or "IPv6")
+// into state st, which must be either Allowed or Blocked. This is useful when the network
+// environment is known to have changed, e.g. after a firewall change.
+// The override persists until ResetBlackHoleFilter is called.
+func (s *Swarm) OverrideBlackHoleState(filter string, st network.BlackHoleState) error {
+	f, err := s.bhd.filter(filter)
+	if err != nil {
+		return err
+	}
+	return f.Override(st)
+}
+
+// ResetBlackHoleFilter removes the override from the black hole filter with the given name,
+// and discards the outcomes of previous dials. The filter starts probing again.
+func (s *Swarm) ResetBlackHoleFilter(filter string) error {
+	f, err := s.bhd.filter(filter)
+	if err != nil {
+		return err
+	}
+	f.Reset()
+	return nil
+}
+
 func (s *Swarm) Close() error {
 	s.closeOnce.Do(s.close)
 	return nil
@@ -260,6 +338,8 @@ func (s *Swarm) close() {
 	s.ctxCancel()
 
 	s.emitter.Close()
+	s.bhd.Close()
+	s.blackHoleEmitter.Close()
 
 	// Prevents new connections and/or listeners from being added to the swarm.
 	s.listeners.Lock()
@@ -447,7 +527,7 @@ func (s *Swarm) NewStream(ctx context.Context, p peer.ID) (network.Stream, error
 	dials := 0
 	for {
 		// will prefer direct connections over relayed connections for opening streams
-		c, err := s.bestAcceptableConnToPeer(ctx, p)
+		c, err := s.connForStream(ctx, p)
 		if err != nil {
 			return nil, err
 		}
@@ -467,6 +547,7 @@ func (s *Swarm) NewStream(ctx context.Context, p peer.ID) (network.Stream, error
 			if err != nil {
 				return nil, err
 			}
+			s.maybeGrowConnPool(p, []*Conn{c})
 		}
 
 		s, err := c.NewStream(ctx)
@@ -39,6 +39,9 @@ type Conn struct {
 	}
 
 	stat network.ConnStats
+
+	// openStreamBytes is the number of bytes sent on the currently open streams
+	openStreamBytes atomic.Int64
 }
 
 var _ network.Conn = &Conn{}
@@ -98,6 +101,7 @@ func (c *Conn) removeStream(s *Stream) {
 	c.stat.NumStreams--
 	delete(c.streams.m, s)
 	c.streams.Unlock()
+	c.openStreamBytes.Add(-s.bytesSent.Load())
 	s.scope.Done()
 }
 
@@ -106,6 +110,11 @@ func (c *Conn) removeStream(s *Stream) {
 // The caller must take a swarm ref before calling. This function decrements the
 // swarm ref count.
 func (c *Conn) start() {
+	if dc, ok := c.conn.(transport.DatagramConn); ok && dc.SupportsDatagrams() {
+		c.swarm.refs.Add(1)
+		go c.handleDatagrams(dc)
+	}
+
 	go func() {
 		defer c.swarm.refs.Done()
 		defer c.Close()
@@ -0,0 +1,222 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// WithConnPool configures swarm to maintain connsPerPeer direct connections to every peer
+// it opens streams to, and to spread new streams across these connections by load.
+//
+// When a stream is opened to a peer with fewer than connsPerPeer direct connections, an
+// additional connection is dialed in the background. Addresses we don't have a connection
+// on yet are preferred, e.g. resulting in QUIC connections over both IPv4 and IPv6. If all
+// addresses are in use, additional connections are dialed to the same addresses.
+//
+// New streams are opened on the connection with the fewest open streams, ties are broken
+// by the number of bytes sent on the connection's open streams.
+func WithConnPool(connsPerPeer int) Option {
+	return func(s *Swarm) error {
+		if connsPerPeer < 1 {
+			return errors.New("swarm: connections per peer must be at least 1")
+		}
+		s.connPool.size = connsPerPeer
+		return nil
+	}
+}
+
+type connPool struct {
+	// size is the number of connections to maintain per peer. Pooling is disabled if <= 1.
+	size int
+
+	mx sync.Mutex
+	// dialing contains the peers we're currently dialing an additional connection to
+	dialing map[peer.ID]struct{}
+}
+
+// connLoad is the load of a connection used for scheduling streams
+type connLoad struct {
+	streams   int
+	bytesSent int64
+}
+
+func (c *Conn) load() connLoad {
+	c.streams.Lock()
+	n := len(c.streams.m)
+	c.streams.Unlock()
+	return connLoad{streams: n, bytesSent: c.openStreamBytes.Load()}
+}
+
+func (l connLoad) less(o connLoad) bool {
+	if l.streams != o.streams {
+		return l.streams < o.streams
+	}
+	return l.bytesSent < o.bytesSent
+}
+
+// acceptableConnsToPeer returns the connections to p that can be used to open a stream,
+// given the options set in ctx, sorted from best to worst.
+func (s *Swarm) acceptableConnsToPeer(ctx context.Context, p peer.ID) []*Conn {
+	forceDirect, _ := network.GetForceDirectDial(ctx)
+	useTransient, _ := network.GetUseTransient(ctx)
+
+	s.conns.RLock()
+	conns := make([]*Conn, 0, len(s.conns.m[p]))
+	for _, c := range s.conns.m[p] {
+		if c.conn.IsClosed() {
+			continue
+		}
+		if forceDirect && !isDirectConn(c) {
+			continue
+		}
+		if !useTransient && c.Stat().Transient {
+			continue
+		}
+		conns = append(conns, c)
+	}
+	s.conns.RUnlock()
+
+	// isBetterConn prefers the later connection when tied, so walk the connections in reverse
+	for i, j := 0, len(conns)-1; i < j; i, j = i+1, j-1 {
+		conns[i], conns[j] = conns[j], conns[i]
+	}
+	sort.SliceStable(conns, func(i, j int) bool {
+		return isBetterConn(conns[i], conns[j]) && !isBetterConn(conns[j], conns[i])
+	})
+	return conns
+}
+
+// connForStream returns the connection a new stream to p should be opened on.
+// It has the same semantics as bestAcceptableConnToPeer, but takes the ConnSelector
+// set in ctx and the connection pool into account.
+func (s *Swarm) connForStream(ctx context.Context, p peer.ID) (*Conn, error) {
+	best, err := s.bestAcceptableConnToPeer(ctx, p)
+	if best == nil || err != nil {
+		return best, err
+	}
+
+	sel := network.GetConnSelector(ctx)
+	if sel == nil && s.connPool.size <= 1 {
+		return best, nil
+	}
+	conns := s.acceptableConnsToPeer(ctx, p)
+
+	if sel != nil {
+		nconns := make([]network.Conn, 0, len(conns))
+		for _, c := range conns {
+			nconns = append(nconns, c)
+		}
+		if selected, ok := sel(nconns).(*Conn); ok {
+			for _, c := range conns {
+				if c == selected {
+					return c, nil
+				}
+			}
+		}
+		return best, nil
+	}
+
+	// Only spread streams across connections that are as good as the best one,
+	// we don't want to open streams on relayed connections if we have a direct one.
+	var pool []*Conn
+	for _, c := range conns {
+		if c.Stat().Transient == best.Stat().Transient && isDirectConn(c) == isDirectConn(best) {
+			pool = append(pool, c)
+		}
+	}
+	s.maybeGrowConnPool(p, pool)
+
+	selected := best
+	selectedLoad := best.load()
+	for _, c := range pool {
+		if l := c.load(); l.less(selectedLoad) {
+			selected, selectedLoad = c, l
+		}
+	}
+	return selected, nil
+}
+
+// maybeGrowConnPool dials an additional connection to p in the background if conns, the
+// pooled connections to p, are direct and fewer than configured, unless we're already doing so.
+func (s *Swarm) maybeGrowConnPool(p peer.ID, conns []*Conn) {
+	if len(conns) == 0 || len(conns) >= s.connPool.size {
+		return
+	}
+	if !isDirectConn(conns[0]) || conns[0].Stat().Transient {
+		return
+	}
+	s.connPool.mx.Lock()
+	if s.connPool.dialing == nil {
+		s.connPool.dialing = make(map[peer.ID]struct{})
+	}
+	if _, ok := s.connPool.dialing[p]; ok {
+		s.connPool.mx.Unlock()
+		return
+	}
+	s.connPool.dialing[p] = struct{}{}
+	s.connPool.mx.Unlock()
+
+	inUse := make([]ma.Multiaddr, 0, len(conns))
+	for _, c := range conns {
+		inUse = append(inUse, c.RemoteMultiaddr())
+	}
+
+	s.refs.Add(1)
+	go func() {
+		defer s.refs.Done()
+		defer func() {
+			s.connPool.mx.Lock()
+			delete(s.connPool.dialing, p)
+			s.connPool.mx.Unlock()
+		}()
+		if err := s.dialPoolConn(p, inUse); err != nil {
+			log.Debugw("failed to dial additional connection", "peer", p, "error", err)
+		}
+	}()
+}
+
+// dialPoolConn dials an additional direct connection to p. Addresses that are not in inUse
+// are tried first.
+func (s *Swarm) dialPoolConn(p peer.ID, inUse []ma.Multiaddr) error {
+	ctx, cancel := context.WithTimeout(s.ctx, s.dialTimeout)
+	defer cancel()
+
+	addrs, _, err := s.addrsForDial(ctx, p)
+	if err != nil {
+		return err
+	}
+	addrs = ma.FilterAddrs(addrs, s.nonProxyAddr)
+	ranking := s.dialRanker(addrs)
+	sort.SliceStable(ranking, func(i, j int) bool { return ranking[i].Delay < ranking[j].Delay })
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return !ma.Contains(inUse, ranking[i].Addr) && ma.Contains(inUse, ranking[j].Addr)
+	})
+
+	resch := make(chan dialResult, 1)
+	for _, ad := range ranking {
+		s.limitedDial(ctx, p, ad.Addr, resch)
+		var res dialResult
+		select {
+		case res = <-resch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if res.Err != nil {
+			log.Debugw("failed to dial additional connection", "peer", p, "addr", res.Addr, "error", res.Err)
+			continue
+		}
+		if _, err := s.addConn(res.Conn, network.DirOutbound); err != nil {
+			res.Conn.Close()
+			return err
+		}
+		return nil
+	}
+	return ErrAllDialsFailed
+}
@@ -0,0 +1,134 @@
+package swarm
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/core/transport"
+)
+
+// maxDatagramProtocolLen is the maximum length of the protocol ID prefixed to a datagram.
+const maxDatagramProtocolLen = 256
+
+var (
+	_ network.DatagramConn    = &Conn{}
+	_ network.DatagramNetwork = &Swarm{}
+)
+
+type datagramHandlers struct {
+	sync.RWMutex
+	m map[protocol.ID]network.DatagramHandler
+}
+
+// SetDatagramHandler sets the handler for datagrams received for protocol proto.
+func (s *Swarm) SetDatagramHandler(proto protocol.ID, handler network.DatagramHandler) {
+	s.datagramHandlers.Lock()
+	defer s.datagramHandlers.Unlock()
+	if s.datagramHandlers.m == nil {
+		s.datagramHandlers.m = make(map[protocol.ID]network.DatagramHandler)
+	}
+	s.datagramHandlers.m[proto] = handler
+}
+
+// RemoveDatagramHandler removes the handler for datagrams received for protocol proto.
+func (s *Swarm) RemoveDatagramHandler(proto protocol.ID) {
+	s.datagramHandlers.Lock()
+	defer s.datagramHandlers.Unlock()
+	delete(s.datagramHandlers.m, proto)
+}
+
+func (s *Swarm) datagramHandler(proto protocol.ID) network.DatagramHandler {
+	s.datagramHandlers.RLock()
+	defer s.datagramHandlers.RUnlock()
+	return s.datagramHandlers.m[proto]
+}
+
+// Datagrams are sent as:
+//
+//	uvarint(len(protocol ID)) | protocol ID | payload
+func datagramHeaderLen(proto protocol.ID) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], uint64(len(proto))) + len(proto)
+}
+
+func appendDatagram(b []byte, proto protocol.ID, data []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(proto)))
+	b = append(b, proto...)
+	return append(b, data...)
+}
+
+func parseDatagram(b []byte) (protocol.ID, []byte, bool) {
+	l, n := binary.Uvarint(b)
+	if n <= 0 || l > maxDatagramProtocolLen || l > uint64(len(b)-n) {
+		return "", nil, false
+	}
+	return protocol.ID(b[n : n+int(l)]), b[n+int(l):], true
+}
+
+// SupportsDatagrams says if datagram support was negotiated on this connection.
+func (c *Conn) SupportsDatagrams() bool {
+	dc, ok := c.conn.(transport.DatagramConn)
+	return ok && dc.SupportsDatagrams()
+}
+
+// MaxDatagramSize returns the maximum size of a datagram sent for protocol proto.
+// It returns 0 if the connection doesn't support datagrams.
+func (c *Conn) MaxDatagramSize(proto protocol.ID) int {
+	dc, ok := c.conn.(transport.DatagramConn)
+	if !ok || !dc.SupportsDatagrams() {
+		return 0
+	}
+	if n := dc.MaxDatagramSize() - datagramHeaderLen(proto); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// SendDatagram sends b as a datagram for protocol proto.
+func (c *Conn) SendDatagram(proto protocol.ID, b []byte) error {
+	dc, ok := c.conn.(transport.DatagramConn)
+	if !ok || !dc.SupportsDatagrams() {
+		return network.ErrDatagramsNotSupported
+	}
+	if len(proto) > maxDatagramProtocolLen || len(b) > c.MaxDatagramSize(proto) {
+		return network.ErrDatagramTooLarge
+	}
+	size := datagramHeaderLen(proto) + len(b)
+	if err := c.conn.Scope().ReserveMemory(size, network.ReservationPriorityMedium); err != nil {
+		return err
+	}
+	defer c.conn.Scope().ReleaseMemory(size)
+	return dc.SendDatagram(appendDatagram(make([]byte, 0, size), proto, b))
+}
+
+// handleDatagrams dispatches the datagrams received on the connection to the datagram
+// handlers, until the connection is closed.
+func (c *Conn) handleDatagrams(dc transport.DatagramConn) {
+	defer c.swarm.refs.Done()
+
+	for {
+		b, err := dc.ReceiveDatagram(context.Background())
+		if err != nil {
+			return
+		}
+		proto, data, ok := parseDatagram(b)
+		if !ok {
+			log.Debugw("received malformed datagram", "peer", c.RemotePeer())
+			continue
+		}
+		h := c.swarm.datagramHandler(proto)
+		if h == nil {
+			log.Debugw("dropping datagram for unhandled protocol", "peer", c.RemotePeer(), "protocol", proto)
+			continue
+		}
+		if err := c.conn.Scope().ReserveMemory(len(b), network.ReservationPriorityLow); err != nil {
+			log.Debugw("dropping datagram", "peer", c.RemotePeer(), "protocol", proto, "error", err)
+			continue
+		}
+		h(c, data)
+		c.conn.Scope().ReleaseMemory(len(b))
+	}
+}
@@ -0,0 +1,111 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/core/transport"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockDatagramConn struct {
+	transport.CapableConn
+	maxSize int
+	sent    chan []byte
+	rcvd    chan []byte
+	closed  chan struct{}
+}
+
+var _ transport.DatagramConn = &mockDatagramConn{}
+
+func newMockDatagramConn(maxSize int) *mockDatagramConn {
+	return &mockDatagramConn{
+		maxSize: maxSize,
+		sent:    make(chan []byte, 10),
+		rcvd:    make(chan []byte, 10),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (c *mockDatagramConn) Scope() network.ConnScope { return &network.NullScope{} }
+func (c *mockDatagramConn) RemotePeer() peer.ID      { return "peer" }
+func (c *mockDatagramConn) SupportsDatagrams() bool  { return true }
+func (c *mockDatagramConn) MaxDatagramSize() int     { return c.maxSize }
+
+func (c *mockDatagramConn) SendDatagram(b []byte) error {
+	c.sent <- b
+	return nil
+}
+
+func (c *mockDatagramConn) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	select {
+	case b := <-c.rcvd:
+		return b, nil
+	case <-c.closed:
+		return nil, errors.New("closed")
+	}
+}
+
+func TestDatagramFraming(t *testing.T) {
+	b := appendDatagram(nil, "/proto", []byte("foobar"))
+	require.Len(t, b, datagramHeaderLen("/proto")+len("foobar"))
+	proto, data, ok := parseDatagram(b)
+	require.True(t, ok)
+	require.Equal(t, protocol.ID("/proto"), proto)
+	require.Equal(t, []byte("foobar"), data)
+
+	_, data, ok = parseDatagram(appendDatagram(nil, "/proto", nil))
+	require.True(t, ok)
+	require.Empty(t, data)
+
+	for _, b := range [][]byte{nil, {0x80}, {10, 'a'}, appendDatagram(nil, protocol.ID(make([]byte, maxDatagramProtocolLen+1)), nil)} {
+		_, _, ok := parseDatagram(b)
+		require.False(t, ok)
+	}
+}
+
+func TestConnDatagrams(t *testing.T) {
+	dc := newMockDatagramConn(100)
+	s := &Swarm{}
+	c := &Conn{conn: dc, swarm: s}
+
+	require.True(t, c.SupportsDatagrams())
+	require.Equal(t, 100-datagramHeaderLen("/proto"), c.MaxDatagramSize("/proto"))
+	require.ErrorIs(t, c.SendDatagram("/proto", make([]byte, c.MaxDatagramSize("/proto")+1)), network.ErrDatagramTooLarge)
+	require.NoError(t, c.SendDatagram("/proto", []byte("foobar")))
+	require.Equal(t, appendDatagram(nil, "/proto", []byte("foobar")), <-dc.sent)
+
+	received := make(chan []byte, 10)
+	s.SetDatagramHandler("/proto", func(conn network.Conn, data []byte) {
+		require.Equal(t, c, conn)
+		received <- append([]byte(nil), data...)
+	})
+
+	s.refs.Add(1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.handleDatagrams(dc)
+	}()
+
+	dc.rcvd <- []byte{0xff}                                     // malformed
+	dc.rcvd <- appendDatagram(nil, "/other", []byte("dropped")) // no handler
+	dc.rcvd <- appendDatagram(nil, "/proto", []byte("foo"))
+	require.Equal(t, []byte("foo"), <-received)
+
+	s.RemoveDatagramHandler("/proto")
+	dc.rcvd <- appendDatagram(nil, "/proto", []byte("bar"))
+	close(dc.closed)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("datagram loop didn't exit")
+	}
+	require.Empty(t, received)
+}
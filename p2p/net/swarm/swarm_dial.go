@@ -271,9 +271,31 @@ func (s *Swarm) dialPeer(ctx context.Context, p peer.ID) (*Conn, error) {
 			log.Errorw("Handshake failed to properly authenticate peer", "authenticated", conn.RemotePeer(), "expected", p)
 			return nil, fmt.Errorf("unexpected peer")
 		}
+		if !isDirectConn(conn) {
+			// Only make the caller wait for a direct connection if they asked for one.
+			// Otherwise, return the relayed connection and upgrade in the background.
+			if forceDirect, _ := network.GetForceDirectDial(ctx); !forceDirect {
+				s.startDirectDialFallbacks(ctx, p)
+				return conn, nil
+			}
+			if direct := s.tryDirectDialFallbacks(ctx, p); direct != nil {
+				return direct, nil
+			}
+		}
 		return conn, nil
 	}
 
+	// We might still be able to get a direct connection using an existing relayed connection.
+	if ctx.Err() == nil && s.ctx.Err() == nil && s.hasProxyConnToPeer(p) {
+		if forceDirect, _ := network.GetForceDirectDial(ctx); forceDirect {
+			if direct := s.tryDirectDialFallbacks(ctx, p); direct != nil {
+				return direct, nil
+			}
+		} else {
+			s.startDirectDialFallbacks(ctx, p)
+		}
+	}
+
 	log.Debugf("network for %s finished dialing %s", s.local, p)
 
 	if ctx.Err() != nil {
@@ -296,7 +318,10 @@ func (s *Swarm) dialWorkerLoop(p peer.ID, reqch <-chan dialRequest) {
 }
 
 func (s *Swarm) addrsForDial(ctx context.Context, p peer.ID) (goodAddrs []ma.Multiaddr, addrErrs []TransportError, err error) {
-	peerAddrs := s.peers.Addrs(p)
+	peerAddrs, fromCtx := network.GetDialAddrs(ctx)
+	if !fromCtx {
+		peerAddrs = s.peers.Addrs(p)
+	}
 	if len(peerAddrs) == 0 {
 		return nil, nil, ErrNoAddresses
 	}
@@ -336,7 +361,9 @@ func (s *Swarm) addrsForDial(ctx context.Context, p peer.ID) (goodAddrs []ma.Mul
 		return nil, addrErrs, ErrNoGoodAddresses
 	}
 
-	s.peers.AddAddrs(p, goodAddrs, peerstore.TempAddrTTL)
+	if !fromCtx {
+		s.peers.AddAddrs(p, goodAddrs, peerstore.TempAddrTTL)
+	}
 
 	return goodAddrs, addrErrs, nil
 }
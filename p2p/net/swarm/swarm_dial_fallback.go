@@ -0,0 +1,109 @@
+package swarm
+
+import (
+	"context"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// DirectDialFallback is a strategy to establish a direct connection to a peer that we
+// failed to dial directly, but have a relayed connection to. The relayed connection can be
+// used for signalling, e.g. to ask the peer to dial us. It returns nil if it established
+// a direct connection to p.
+//
+// DirectDialFallbacks are run by DialPeer after dialing the peer's addresses either failed
+// or only resulted in a relayed connection. Unless the caller set ForceDirectDial, DialPeer
+// returns the relayed connection right away and runs the fallbacks in the background.
+type DirectDialFallback func(ctx context.Context, p peer.ID) error
+
+type directDialFallbacks struct {
+	sync.RWMutex
+	fs []DirectDialFallback
+	// peers for which the fallbacks are currently running in the background
+	running map[peer.ID]struct{}
+}
+
+// AddDirectDialFallback registers a DirectDialFallback. Fallbacks are tried in the order
+// they were added, until one of them succeeds.
+func (s *Swarm) AddDirectDialFallback(f DirectDialFallback) {
+	s.directDialFallbacks.Lock()
+	defer s.directDialFallbacks.Unlock()
+	s.directDialFallbacks.fs = append(s.directDialFallbacks.fs, f)
+}
+
+// hasProxyConnToPeer returns true if we have an open relayed connection to p.
+func (s *Swarm) hasProxyConnToPeer(p peer.ID) bool {
+	s.conns.RLock()
+	defer s.conns.RUnlock()
+	for _, c := range s.conns.m[p] {
+		if !c.conn.IsClosed() && !isDirectConn(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// tryDirectDialFallbacks runs the registered DirectDialFallbacks for p and returns the
+// direct connection established by the first successful one, or nil.
+func (s *Swarm) tryDirectDialFallbacks(ctx context.Context, p peer.ID) *Conn {
+	// Hole punching is a direct dial strategy on its own, don't interfere with it.
+	if simConnect, _, _ := network.GetSimultaneousConnect(ctx); simConnect {
+		return nil
+	}
+
+	s.directDialFallbacks.RLock()
+	fs := append([]DirectDialFallback(nil), s.directDialFallbacks.fs...)
+	s.directDialFallbacks.RUnlock()
+
+	for _, f := range fs {
+		if err := f(ctx, p); err != nil {
+			log.Debugw("direct dial fallback failed", "peer", p, "error", err)
+			continue
+		}
+		if c := s.bestConnToPeer(p); isDirectConn(c) {
+			return c
+		}
+	}
+	return nil
+}
+
+// startDirectDialFallbacks runs the registered DirectDialFallbacks for p in the background,
+// unless they are already running for p.
+func (s *Swarm) startDirectDialFallbacks(ctx context.Context, p peer.ID) {
+	// Hole punching is a direct dial strategy on its own, don't interfere with it.
+	if simConnect, _, _ := network.GetSimultaneousConnect(ctx); simConnect {
+		return
+	}
+
+	s.directDialFallbacks.Lock()
+	if len(s.directDialFallbacks.fs) == 0 || s.ctx.Err() != nil {
+		s.directDialFallbacks.Unlock()
+		return
+	}
+	if _, ok := s.directDialFallbacks.running[p]; ok {
+		s.directDialFallbacks.Unlock()
+		return
+	}
+	if s.directDialFallbacks.running == nil {
+		s.directDialFallbacks.running = make(map[peer.ID]struct{})
+	}
+	s.directDialFallbacks.running[p] = struct{}{}
+	s.directDialFallbacks.Unlock()
+
+	s.refs.Add(1)
+	go func() {
+		defer s.refs.Done()
+		defer func() {
+			s.directDialFallbacks.Lock()
+			delete(s.directDialFallbacks.running, p)
+			s.directDialFallbacks.Unlock()
+		}()
+
+		// Don't inherit the caller's context, the dial that triggered us has already returned.
+		ctx, cancel := context.WithTimeout(s.ctx, network.DialPeerTimeout)
+		defer cancel()
+		s.tryDirectDialFallbacks(ctx, p)
+	}()
+}
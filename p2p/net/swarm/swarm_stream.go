@@ -11,7 +11,10 @@ import (
 )
 
 // Validate Stream conforms to the go-libp2p-net Stream interface
-var _ network.Stream = &Stream{}
+var (
+	_ network.Stream            = &Stream{}
+	_ network.PrioritizedStream = &Stream{}
+)
 
 // Stream is the stream type used by swarm. In general, you won't use this type
 // directly.
@@ -27,6 +30,9 @@ type Stream struct {
 	protocol atomic.Pointer[protocol.ID]
 
 	stat network.Stats
+
+	// bytesSent is the number of bytes written to the stream
+	bytesSent atomic.Int64
 }
 
 func (s *Stream) ID() string {
@@ -64,6 +70,8 @@ func (s *Stream) Read(p []byte) (int, error) {
 // Write writes bytes to a stream, flushing for each call.
 func (s *Stream) Write(p []byte) (int, error) {
 	n, err := s.stream.Write(p)
+	s.bytesSent.Add(int64(n))
+	s.conn.openStreamBytes.Add(int64(n))
 	// TODO: push this down to a lower level for better accuracy.
 	if s.conn.swarm.bwc != nil {
 		s.conn.swarm.bwc.LogSentMessage(int64(n))
@@ -126,6 +134,10 @@ func (s *Stream) SetProtocol(p protocol.ID) error {
 	}
 
 	s.protocol.Store(&p)
+	// Let the stream muxer apply per-protocol settings.
+	if ps, ok := s.stream.(interface{ SetProtocol(protocol.ID) }); ok {
+		ps.SetProtocol(p)
+	}
 	return nil
 }
 
@@ -144,6 +156,16 @@ func (s *Stream) SetWriteDeadline(t time.Time) error {
 	return s.stream.SetWriteDeadline(t)
 }
 
+// SetPriority sets the priority of this stream.
+// It returns network.ErrPriorityNotSupported if the stream muxer doesn't support priorities.
+func (s *Stream) SetPriority(p network.StreamPriority) error {
+	ps, ok := s.stream.(network.PrioritizedStream)
+	if !ok {
+		return network.ErrPriorityNotSupported
+	}
+	return ps.SetPriority(p)
+}
+
 // Stat returns metadata information for this stream.
 func (s *Stream) Stat() network.Stats {
 	return s.stat
@@ -568,3 +568,79 @@ func TestListenCloseCount(t *testing.T) {
 	_, err := remainingAddrs[0].ValueForProtocol(ma.P_TCP)
 	require.NoError(t, err, "expected the TCP address to still be present")
 }
+
+func TestConnPool(t *testing.T) {
+	s1 := GenSwarm(t, OptDisableQUIC, OptDisableReuseport, WithSwarmOpts(swarm.WithConnPool(3)))
+	s2 := GenSwarm(t, OptDisableQUIC, OptDisableReuseport)
+	defer s1.Close()
+	defer s2.Close()
+	s2.SetStreamHandler(EchoStreamHandler)
+	s1.Peerstore().AddAddrs(s2.LocalPeer(), s2.ListenAddresses(), peerstore.PermanentAddrTTL)
+
+	// opening streams makes the swarm dial additional connections
+	var streams []network.Stream
+	require.Eventually(t, func() bool {
+		str, err := s1.NewStream(context.Background(), s2.LocalPeer())
+		require.NoError(t, err)
+		streams = append(streams, str)
+		return len(s1.ConnsToPeer(s2.LocalPeer())) == 3
+	}, 5*time.Second, 50*time.Millisecond)
+	for _, str := range streams {
+		str.Close()
+	}
+
+	// streams are spread evenly across the connections
+	for i := 0; i < 6; i++ {
+		_, err := s1.NewStream(context.Background(), s2.LocalPeer())
+		require.NoError(t, err)
+	}
+	conns := s1.ConnsToPeer(s2.LocalPeer())
+	require.Len(t, conns, 3)
+	for _, c := range conns {
+		require.Len(t, c.GetStreams(), 2)
+	}
+}
+
+func TestConnSelector(t *testing.T) {
+	s1 := GenSwarm(t, OptDisableQUIC, OptDisableReuseport, WithSwarmOpts(swarm.WithConnPool(2)))
+	s2 := GenSwarm(t, OptDisableQUIC, OptDisableReuseport)
+	defer s1.Close()
+	defer s2.Close()
+	s1.Peerstore().AddAddrs(s2.LocalPeer(), s2.ListenAddresses(), peerstore.PermanentAddrTTL)
+
+	str, err := s1.NewStream(context.Background(), s2.LocalPeer())
+	require.NoError(t, err)
+	str.Close()
+	require.Eventually(t, func() bool { return len(s1.ConnsToPeer(s2.LocalPeer())) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		var selected network.Conn
+		ctx := network.WithConnSelector(context.Background(), func(conns []network.Conn) network.Conn {
+			require.Len(t, conns, 2)
+			selected = conns[i]
+			return selected
+		})
+		str, err := s1.NewStream(ctx, s2.LocalPeer())
+		require.NoError(t, err)
+		require.Equal(t, selected, str.Conn())
+		str.Close()
+	}
+
+	// returning nil falls back to the default selection
+	ctx := network.WithConnSelector(context.Background(), func([]network.Conn) network.Conn { return nil })
+	str, err = s1.NewStream(ctx, s2.LocalPeer())
+	require.NoError(t, err)
+	str.Close()
+}
+
+func TestDialPeerWithDialAddrs(t *testing.T) {
+	swarms := makeSwarms(t, 2)
+	s1, s2 := swarms[0], swarms[1]
+	require.Empty(t, s1.Peerstore().Addrs(s2.LocalPeer()))
+
+	ctx := network.WithDialAddrs(context.Background(), s2.ListenAddresses())
+	c, err := s1.DialPeer(ctx, s2.LocalPeer())
+	require.NoError(t, err)
+	require.Equal(t, s2.LocalPeer(), c.RemotePeer())
+	require.Empty(t, s1.Peerstore().Addrs(s2.LocalPeer()))
+}
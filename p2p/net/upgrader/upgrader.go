@@ -41,6 +41,15 @@ func WithAcceptTimeout(t time.Duration) Option {
 	}
 }
 
+// WithPSKSet protects connections using a set of PSKs, allowing the PSK of the private network
+// to be rotated. It takes precedence over the PSK passed to New.
+func WithPSKSet(psks ipnet.PSKSet) Option {
+	return func(u *upgrader) error {
+		u.psks = psks
+		return nil
+	}
+}
+
 type StreamMuxer struct {
 	ID    protocol.ID
 	Muxer network.Multiplexer
@@ -50,6 +59,7 @@ type StreamMuxer struct {
 // to a full transport connection (secure and multiplexed).
 type upgrader struct {
 	psk       ipnet.PSK
+	psks      ipnet.PSKSet
 	connGater connmgr.ConnectionGater
 	rcmgr     network.ResourceManager
 
@@ -140,8 +150,14 @@ func (u *upgrader) upgrade(ctx context.Context, t transport.Transport, maconn ma
 	}
 
 	var conn net.Conn = maconn
-	if u.psk != nil {
-		pconn, err := pnet.NewProtectedConn(u.psk, conn)
+	if u.psks != nil || u.psk != nil {
+		var pconn net.Conn
+		var err error
+		if u.psks != nil {
+			pconn, err = pnet.NewProtectedConnWithPSKSet(u.psks, conn)
+		} else {
+			pconn, err = pnet.NewProtectedConn(u.psk, conn)
+		}
 		if err != nil {
 			conn.Close()
 			return nil, fmt.Errorf("failed to setup private network protector: %w", err)
@@ -207,6 +223,9 @@ func (u *upgrader) setupSecurity(ctx context.Context, conn net.Conn, p peer.ID,
 	if err != nil {
 		return nil, "", false, err
 	}
+	if hc, ok := u.connGater.(sec.HandshakeCredentials); ok {
+		ctx = sec.WithHandshakeCredentials(ctx, hc)
+	}
 	if isServer {
 		sconn, err := st.SecureInbound(ctx, conn, p)
 		return sconn, st.ID(), true, err
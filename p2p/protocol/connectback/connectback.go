@@ -0,0 +1,465 @@
+// Package connectback implements the connect-back protocol.
+//
+// When we fail to dial a peer directly, but are connected to it via a relay, we can ask the
+// peer to dial us instead. This is useful when only one side is reachable, for example when
+// we only support TCP and the peer blocks inbound TCP connections, but we accept inbound
+// connections. It complements hole punching (DCUtR), which is used when neither side is
+// reachable.
+//
+// The requesting peer opens a connect-back stream on the relayed connection and sends the
+// addresses it can be dialed on. The receiving peer dials these addresses and responds once
+// the dial completed. To prevent the protocol from being used to make us dial arbitrary
+// hosts, the receiving peer only dials addresses whose IP it has already observed for the
+// requesting peer on a direct connection, or that are in an explicitly configured allowlist
+// (see WithDialBackAllowlist).
+package connectback
+
+//go:generate protoc --proto_path=$PWD:$PWD/../../.. --go_out=. --go_opt=Mpb/connectback.proto=./pb pb/connectback.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	"github.com/libp2p/go-libp2p/p2p/protocol/connectback/pb"
+	"github.com/libp2p/go-msgio/pbio"
+
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// Protocol is the libp2p protocol for connect-back requests.
+const Protocol protocol.ID = "/libp2p/connect-back/1.0.0"
+
+var log = logging.Logger("p2p-connectback")
+
+// StreamTimeout is the timeout for the connect-back protocol stream.
+var StreamTimeout = 1 * time.Minute
+
+const (
+	ServiceName = "libp2p.connectback"
+
+	maxMsgSize = 4 * 1024 // 4K
+
+	// maxAddrs is the maximum number of addresses we dial back on a single request.
+	maxAddrs = 8
+	// maxConcurrentDialBacks is the maximum number of connect-back requests we handle concurrently.
+	maxConcurrentDialBacks = 8
+
+	defaultDialTimeout = 10 * time.Second
+	// connWaitTimeout is how long we wait for the connection to show up after the peer
+	// reported that it connected back.
+	connWaitTimeout = 5 * time.Second
+
+	// observedIPTTL is how long we remember the IP of a direct connection to a peer.
+	observedIPTTL = time.Hour
+	// maxObservedPeers is the maximum number of peers we remember observed IPs for.
+	maxObservedPeers = 1024
+	// maxObservedIPsPerPeer is the maximum number of observed IPs we remember per peer.
+	maxObservedIPsPerPeer = 8
+)
+
+var (
+	// ErrClosed is returned when the connect-back service is closed.
+	ErrClosed = errors.New("connect-back service closing")
+	// ErrNoAddrs is returned by ConnectBack when we don't have any addresses the peer could dial.
+	ErrNoAddrs = errors.New("no addresses to connect back to")
+)
+
+type Option func(*Service) error
+
+// WithDialTimeout sets the timeout for dialing back a peer that requested it.
+func WithDialTimeout(d time.Duration) Option {
+	return func(s *Service) error {
+		if d <= 0 {
+			return errors.New("dial timeout must be positive")
+		}
+		s.dialTimeout = d
+		return nil
+	}
+}
+
+// Service handles connect-back requests from other peers, and allows us to ask other
+// peers to connect back to us.
+//
+// If the host's network supports it, the Service registers itself as a direct dial fallback
+// with the swarm (see swarm.Swarm.AddDirectDialFallback), so that a peer we only reached via
+// a relay is asked to connect back. DialPeer only waits for this when ForceDirectDial is set,
+// otherwise it returns the relayed connection and the peer connects back in the background.
+type Service struct {
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+
+	host        host.Host
+	filter      AddrFilter
+	dialTimeout time.Duration
+	allowlist   []*net.IPNet
+	notifiee    network.Notifiee
+
+	mx       sync.Mutex
+	inflight map[peer.ID]struct{}
+
+	observedMx sync.Mutex
+	// observed maps peers to the IPs of direct connections we've had to them,
+	// and the time we last saw them.
+	observed map[peer.ID]map[string]time.Time
+
+	refCount sync.WaitGroup
+}
+
+// NewService creates a new connect-back service.
+func NewService(h host.Host, opts ...Option) (*Service, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Service{
+		ctx:         ctx,
+		ctxCancel:   cancel,
+		host:        h,
+		dialTimeout: defaultDialTimeout,
+		inflight:    make(map[peer.ID]struct{}),
+		observed:    make(map[peer.ID]map[string]time.Time),
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	s.notifiee = &network.NotifyBundle{
+		ConnectedF: func(_ network.Network, c network.Conn) { s.observeConn(c) },
+	}
+	h.Network().Notify(s.notifiee)
+	for _, c := range h.Network().Conns() {
+		s.observeConn(c)
+	}
+
+	h.SetStreamHandler(Protocol, s.handleNewStream)
+	if sw, ok := h.Network().(interface {
+		AddDirectDialFallback(swarm.DirectDialFallback)
+	}); ok {
+		sw.AddDirectDialFallback(s.ConnectBack)
+	}
+	return s, nil
+}
+
+// Close closes the service.
+func (s *Service) Close() error {
+	s.host.RemoveStreamHandler(Protocol)
+	s.host.Network().StopNotify(s.notifiee)
+	s.mx.Lock()
+	s.ctxCancel()
+	s.mx.Unlock()
+	s.refCount.Wait()
+	return nil
+}
+
+type dialBackKey struct{}
+
+// isDialBack returns true if ctx is used for dialing back a peer. We must not ask the peer
+// to connect back to us in that case.
+func isDialBack(ctx context.Context) bool {
+	return ctx.Value(dialBackKey{}) != nil
+}
+
+// ConnectBack asks peer p to dial us, using an existing (usually relayed) connection to p.
+// It returns nil once a direct connection to p has been established.
+func (s *Service) ConnectBack(ctx context.Context, p peer.ID) error {
+	if s.ctx.Err() != nil {
+		return ErrClosed
+	}
+	if isDialBack(ctx) {
+		return errors.New("not requesting connect-back while dialing back")
+	}
+	if getDirectConnection(s.host, p) != nil {
+		return nil
+	}
+
+	addrs := removeRelayAddrs(s.host.Addrs())
+	if s.filter != nil {
+		addrs = s.filter.FilterLocal(p, addrs)
+	} else {
+		addrs = publicAddrs(addrs)
+	}
+	if len(addrs) == 0 {
+		return ErrNoAddrs
+	}
+
+	// Watch for the connection before sending the request, it might be established before we
+	// receive the response.
+	connected := make(chan struct{}, 1)
+	notifiee := &network.NotifyBundle{
+		ConnectedF: func(_ network.Network, c network.Conn) {
+			if c.RemotePeer() == p && !isRelayAddress(c.RemoteMultiaddr()) {
+				select {
+				case connected <- struct{}{}:
+				default:
+				}
+			}
+		},
+	}
+	s.host.Network().Notify(notifiee)
+	defer s.host.Network().StopNotify(notifiee)
+
+	if err := s.requestConnectBack(ctx, p, addrs); err != nil {
+		return err
+	}
+
+	if getDirectConnection(s.host, p) != nil {
+		return nil
+	}
+	t := time.NewTimer(connWaitTimeout)
+	defer t.Stop()
+	select {
+	case <-connected:
+		return nil
+	case <-t.C:
+		return errors.New("peer reported a connection, but none was established")
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.ctx.Done():
+		return ErrClosed
+	}
+}
+
+func (s *Service) requestConnectBack(ctx context.Context, p peer.ID, addrs []ma.Multiaddr) error {
+	// When called as a direct dial fallback, ctx has ForceDirectDial set, which would prevent
+	// us from opening the stream on the relayed connection.
+	streamCtx, cancel := withoutValues(ctx)
+	defer cancel()
+	streamCtx = network.WithNoDial(streamCtx, "connect-back")
+	streamCtx = network.WithUseTransient(streamCtx, "connect-back")
+	str, err := s.host.NewStream(streamCtx, p, Protocol)
+	if err != nil {
+		return fmt.Errorf("failed to open connect-back stream: %w", err)
+	}
+	defer str.Close()
+
+	if err := str.Scope().SetService(ServiceName); err != nil {
+		str.Reset()
+		return fmt.Errorf("error attaching stream to connect-back service: %w", err)
+	}
+	if err := str.Scope().ReserveMemory(maxMsgSize, network.ReservationPriorityAlways); err != nil {
+		str.Reset()
+		return fmt.Errorf("error reserving memory for stream: %w", err)
+	}
+	defer str.Scope().ReleaseMemory(maxMsgSize)
+
+	deadline := time.Now().Add(StreamTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	str.SetDeadline(deadline)
+
+	w := pbio.NewDelimitedWriter(str)
+	rd := pbio.NewDelimitedReader(str, maxMsgSize)
+
+	if err := w.WriteMsg(&pb.ConnectBackRequest{Addrs: addrsToBytes(addrs)}); err != nil {
+		str.Reset()
+		return fmt.Errorf("failed to send connect-back request: %w", err)
+	}
+	var resp pb.ConnectBackResponse
+	if err := rd.ReadMsg(&resp); err != nil {
+		str.Reset()
+		return fmt.Errorf("failed to read connect-back response: %w", err)
+	}
+	if st := resp.GetStatus(); st != pb.ConnectBackResponse_OK {
+		return fmt.Errorf("connect-back request failed: %s (%s)", st, resp.GetStatusText())
+	}
+	return nil
+}
+
+func (s *Service) handleNewStream(str network.Stream) {
+	if err := str.Scope().SetService(ServiceName); err != nil {
+		log.Debugf("error attaching stream to connect-back service: %s", err)
+		str.Reset()
+		return
+	}
+
+	rp := str.Conn().RemotePeer()
+	if err := s.handleRequest(str); err != nil {
+		log.Debugw("error handling connect-back stream", "peer", rp, "error", err)
+		str.Reset()
+		return
+	}
+	str.Close()
+}
+
+func (s *Service) handleRequest(str network.Stream) error {
+	if err := str.Scope().ReserveMemory(maxMsgSize, network.ReservationPriorityAlways); err != nil {
+		return fmt.Errorf("error reserving memory for stream: %w", err)
+	}
+	defer str.Scope().ReleaseMemory(maxMsgSize)
+
+	str.SetDeadline(time.Now().Add(StreamTimeout))
+
+	w := pbio.NewDelimitedWriter(str)
+	rd := pbio.NewDelimitedReader(str, maxMsgSize)
+
+	var req pb.ConnectBackRequest
+	if err := rd.ReadMsg(&req); err != nil {
+		return fmt.Errorf("failed to read connect-back request: %w", err)
+	}
+
+	rp := str.Conn().RemotePeer()
+	status, err := s.dialBack(rp, addrsFromBytes(req.GetAddrs()))
+	resp := &pb.ConnectBackResponse{Status: status.Enum()}
+	if err != nil {
+		log.Debugw("failed to connect back", "peer", rp, "error", err)
+		resp.StatusText = new(string)
+		*resp.StatusText = err.Error()
+	}
+	if err := w.WriteMsg(resp); err != nil {
+		return fmt.Errorf("failed to write connect-back response: %w", err)
+	}
+	return nil
+}
+
+// dialBack dials peer p on the addresses it requested us to connect back to.
+func (s *Service) dialBack(p peer.ID, addrs []ma.Multiaddr) (pb.ConnectBackResponse_Status, error) {
+	if getDirectConnection(s.host, p) != nil {
+		return pb.ConnectBackResponse_OK, nil
+	}
+
+	addrs = removeRelayAddrs(addrs)
+	if s.filter != nil {
+		addrs = s.filter.FilterRemote(p, addrs)
+	} else {
+		addrs = publicAddrs(addrs)
+	}
+	addrs = s.verifiedAddrs(p, addrs)
+	if len(addrs) > maxAddrs {
+		addrs = addrs[:maxAddrs]
+	}
+	if len(addrs) == 0 {
+		return pb.ConnectBackResponse_E_NO_ADDRS, errors.New("no dialable addresses")
+	}
+
+	if !s.acquire(p) {
+		return pb.ConnectBackResponse_E_RATE_LIMITED, errors.New("too many connect-back requests")
+	}
+	defer s.release(p)
+
+	ctx := context.WithValue(s.ctx, dialBackKey{}, struct{}{})
+	ctx = network.WithForceDirectDial(ctx, "connect-back")
+	// Only dial the addresses we verified, and don't add them to the peerstore.
+	ctx = network.WithDialAddrs(ctx, addrs)
+	ctx, cancel := context.WithTimeout(ctx, s.dialTimeout)
+	defer cancel()
+	// Not using host.Connect here, as it might consider the relayed connection good enough.
+	if _, err := s.host.Network().DialPeer(ctx, p); err != nil {
+		return pb.ConnectBackResponse_E_DIAL_FAILED, err
+	}
+	return pb.ConnectBackResponse_OK, nil
+}
+
+// acquire reserves a slot for dialing back p. Only a single request per peer, and
+// maxConcurrentDialBacks requests in total are handled at the same time.
+func (s *Service) acquire(p peer.ID) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.ctx.Err() != nil {
+		return false
+	}
+	if _, ok := s.inflight[p]; ok || len(s.inflight) >= maxConcurrentDialBacks {
+		return false
+	}
+	s.inflight[p] = struct{}{}
+	s.refCount.Add(1)
+	return true
+}
+
+func (s *Service) release(p peer.ID) {
+	s.mx.Lock()
+	delete(s.inflight, p)
+	s.mx.Unlock()
+	s.refCount.Done()
+}
+
+// observeConn records the remote IP of c, if it is a direct connection.
+func (s *Service) observeConn(c network.Conn) {
+	if isRelayAddress(c.RemoteMultiaddr()) {
+		return
+	}
+	ip, err := manet.ToIP(c.RemoteMultiaddr())
+	if err != nil {
+		return
+	}
+	p := c.RemotePeer()
+	now := time.Now()
+
+	s.observedMx.Lock()
+	defer s.observedMx.Unlock()
+	ips, ok := s.observed[p]
+	if !ok {
+		if len(s.observed) >= maxObservedPeers {
+			s.gcObservedLocked(now)
+		}
+		if len(s.observed) >= maxObservedPeers {
+			return
+		}
+		ips = make(map[string]time.Time)
+		s.observed[p] = ips
+	}
+	if _, ok := ips[ip.String()]; !ok && len(ips) >= maxObservedIPsPerPeer {
+		// Replace the IP we haven't seen for the longest time.
+		var oldest string
+		for k, t := range ips {
+			if oldest == "" || t.Before(ips[oldest]) {
+				oldest = k
+			}
+		}
+		delete(ips, oldest)
+	}
+	ips[ip.String()] = now
+}
+
+func (s *Service) gcObservedLocked(now time.Time) {
+	for p, ips := range s.observed {
+		for ip, t := range ips {
+			if now.Sub(t) > observedIPTTL {
+				delete(ips, ip)
+			}
+		}
+		if len(ips) == 0 {
+			delete(s.observed, p)
+		}
+	}
+}
+
+// verifiedAddrs returns the addresses in addrs that we may dial back p on: those whose IP we
+// have observed for p on a direct connection, and those in the allowlist.
+func (s *Service) verifiedAddrs(p peer.ID, addrs []ma.Multiaddr) []ma.Multiaddr {
+	now := time.Now()
+	s.observedMx.Lock()
+	defer s.observedMx.Unlock()
+	ips := s.observed[p]
+
+	result := make([]ma.Multiaddr, 0, len(addrs))
+	for _, a := range addrs {
+		ip, err := manet.ToIP(a)
+		if err != nil {
+			// We don't resolve DNS addresses, the resolved IP might not be the peer's.
+			continue
+		}
+		if t, ok := ips[ip.String()]; ok && now.Sub(t) <= observedIPTTL {
+			result = append(result, a)
+			continue
+		}
+		for _, n := range s.allowlist {
+			if n.Contains(ip) {
+				result = append(result, a)
+				break
+			}
+		}
+	}
+	return result
+}
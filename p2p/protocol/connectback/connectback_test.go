@@ -0,0 +1,227 @@
+package connectback_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/host/blank"
+	"github.com/libp2p/go-libp2p/p2p/host/eventbus"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	swarmt "github.com/libp2p/go-libp2p/p2p/net/swarm/testing"
+	"github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/client"
+	"github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/relay"
+	"github.com/libp2p/go-libp2p/p2p/protocol/connectback"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMaddrFilter struct {
+	filterLocal  func(remoteID peer.ID, maddrs []ma.Multiaddr) []ma.Multiaddr
+	filterRemote func(remoteID peer.ID, maddrs []ma.Multiaddr) []ma.Multiaddr
+}
+
+func (m mockMaddrFilter) FilterLocal(remoteID peer.ID, maddrs []ma.Multiaddr) []ma.Multiaddr {
+	return m.filterLocal(remoteID, maddrs)
+}
+
+func (m mockMaddrFilter) FilterRemote(remoteID peer.ID, maddrs []ma.Multiaddr) []ma.Multiaddr {
+	return m.filterRemote(remoteID, maddrs)
+}
+
+var _ connectback.AddrFilter = &mockMaddrFilter{}
+
+func allowAll(_ peer.ID, maddrs []ma.Multiaddr) []ma.Multiaddr { return maddrs }
+
+// allowLoopback lets the service use the loopback addresses of the test hosts.
+var allowLoopback = mockMaddrFilter{filterLocal: allowAll, filterRemote: allowAll}
+
+// loopbackNet is allowlisted for dialing back, as the test hosts are connected via a relay
+// and never observe each other's IP.
+var loopbackNet = &net.IPNet{IP: net.IPv4(127, 0, 0, 0), Mask: net.CIDRMask(8, 32)}
+
+// loopbackOpts are the options a service needs to dial back the test hosts.
+var loopbackOpts = []connectback.Option{
+	connectback.WithAddrFilter(allowLoopback),
+	connectback.WithDialBackAllowlist(loopbackNet),
+}
+
+func mkHost(t *testing.T) host.Host {
+	t.Helper()
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	id, err := peer.IDFromPrivateKey(priv)
+	require.NoError(t, err)
+	ps, err := pstoremem.NewPeerstore()
+	require.NoError(t, err)
+	require.NoError(t, ps.AddPrivKey(id, priv))
+	t.Cleanup(func() { ps.Close() })
+
+	sw, err := swarm.NewSwarm(id, ps, eventbus.NewBus())
+	require.NoError(t, err)
+	t.Cleanup(func() { sw.Close() })
+	upgrader := swarmt.GenUpgrader(t, sw, nil)
+	tpt, err := tcp.NewTCPTransport(upgrader, nil)
+	require.NoError(t, err)
+	require.NoError(t, sw.AddTransport(tpt))
+	require.NoError(t, sw.Listen(ma.StringCast("/ip4/127.0.0.1/tcp/0")))
+	h := blankhost.NewBlankHost(sw)
+	require.NoError(t, client.AddTransport(h, upgrader))
+	return h
+}
+
+// makeRelayedHosts returns two hosts that are connected via a relay. h1 doesn't know any
+// direct addresses of h2.
+func makeRelayedHosts(t *testing.T) (h1, h2 host.Host) {
+	t.Helper()
+	h1, h2, raddr := makeHostsWithRelay(t)
+	require.NoError(t, h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: []ma.Multiaddr{raddr}}))
+	conns := h1.Network().ConnsToPeer(h2.ID())
+	require.Len(t, conns, 1)
+	require.True(t, conns[0].Stat().Transient)
+	return h1, h2
+}
+
+// makeHostsWithRelay returns two hosts, and the relay address h1 can reach h2 on.
+func makeHostsWithRelay(t *testing.T) (h1, h2 host.Host, raddr ma.Multiaddr) {
+	t.Helper()
+	h1, h2 = mkHost(t), mkHost(t)
+	r := mkHost(t)
+	rs, err := relay.New(r)
+	require.NoError(t, err)
+	t.Cleanup(func() { rs.Close() })
+
+	rinfo := peer.AddrInfo{ID: r.ID(), Addrs: r.Addrs()}
+	require.NoError(t, h1.Connect(context.Background(), rinfo))
+	_, err = client.Reserve(context.Background(), h2, rinfo)
+	require.NoError(t, err)
+	return h1, h2, ma.StringCast(fmt.Sprintf("/p2p/%s/p2p-circuit", r.ID()))
+}
+
+func hasDirectConn(h host.Host, p peer.ID) bool {
+	for _, c := range h.Network().ConnsToPeer(p) {
+		if isDirect(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func addService(t *testing.T, h host.Host, opts ...connectback.Option) *connectback.Service {
+	t.Helper()
+	s, err := connectback.NewService(h, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func isDirect(c network.Conn) bool {
+	_, err := c.RemoteMultiaddr().ValueForProtocol(ma.P_CIRCUIT)
+	return err != nil
+}
+
+func TestDialPeerConnectsBack(t *testing.T) {
+	h1, h2 := makeRelayedHosts(t)
+	addService(t, h1, loopbackOpts...)
+	addService(t, h2, loopbackOpts...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c, err := h1.Network().DialPeer(network.WithForceDirectDial(ctx, "test"), h2.ID())
+	require.NoError(t, err)
+	require.True(t, isDirect(c))
+	require.Equal(t, network.DirInbound, c.Stat().Direction)
+
+	require.Eventually(t, func() bool { return hasDirectConn(h2, h1.ID()) }, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestDialPeerConnectsBackInBackground(t *testing.T) {
+	h1, h2, raddr := makeHostsWithRelay(t)
+	addService(t, h1, loopbackOpts...)
+	addService(t, h2, loopbackOpts...)
+
+	// Without ForceDirectDial, we get the relayed connection right away.
+	h1.Peerstore().AddAddr(h2.ID(), raddr, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c, err := h1.Network().DialPeer(ctx, h2.ID())
+	require.NoError(t, err)
+	require.False(t, isDirect(c))
+
+	require.Eventually(t, func() bool { return hasDirectConn(h1, h2.ID()) }, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestConnectBackNoPublicAddrs(t *testing.T) {
+	h1, h2 := makeRelayedHosts(t)
+	s := addService(t, h1)
+	addService(t, h2, loopbackOpts...)
+
+	require.ErrorIs(t, s.ConnectBack(context.Background(), h2.ID()), connectback.ErrNoAddrs)
+}
+
+func TestConnectBackResponderFailures(t *testing.T) {
+	t.Run("private addresses", func(t *testing.T) {
+		h1, h2 := makeRelayedHosts(t)
+		s := addService(t, h1, connectback.WithAddrFilter(allowLoopback))
+		addService(t, h2)
+
+		err := s.ConnectBack(context.Background(), h2.ID())
+		require.ErrorContains(t, err, "E_NO_ADDRS")
+	})
+
+	t.Run("unobserved addresses", func(t *testing.T) {
+		h1, h2 := makeRelayedHosts(t)
+		s := addService(t, h1, connectback.WithAddrFilter(allowLoopback))
+		addService(t, h2, connectback.WithAddrFilter(allowLoopback))
+
+		err := s.ConnectBack(context.Background(), h2.ID())
+		require.ErrorContains(t, err, "E_NO_ADDRS")
+		require.Empty(t, h2.Peerstore().Addrs(h1.ID()))
+	})
+
+	t.Run("dial failure", func(t *testing.T) {
+		h1, h2 := makeRelayedHosts(t)
+		s := addService(t, h1, connectback.WithAddrFilter(mockMaddrFilter{
+			filterLocal: func(peer.ID, []ma.Multiaddr) []ma.Multiaddr {
+				return []ma.Multiaddr{ma.StringCast("/ip4/127.0.0.1/tcp/1")}
+			},
+			filterRemote: allowAll,
+		}))
+		addService(t, h2, append(loopbackOpts, connectback.WithDialTimeout(time.Second))...)
+
+		err := s.ConnectBack(context.Background(), h2.ID())
+		require.ErrorContains(t, err, "E_DIAL_FAILED")
+		for _, c := range h1.Network().ConnsToPeer(h2.ID()) {
+			require.False(t, isDirect(c))
+		}
+	})
+}
+
+func TestConnectBackObservedIP(t *testing.T) {
+	h1, h2 := makeRelayedHosts(t)
+	s := addService(t, h1, connectback.WithAddrFilter(allowLoopback))
+	addService(t, h2, connectback.WithAddrFilter(allowLoopback))
+
+	// Let h2 observe h1's IP on a direct connection, then close that connection again.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	h2.Peerstore().AddAddrs(h1.ID(), h1.Addrs(), time.Hour)
+	c, err := h2.Network().DialPeer(network.WithForceDirectDial(ctx, "test"), h1.ID())
+	require.NoError(t, err)
+	require.True(t, isDirect(c))
+	require.NoError(t, c.Close())
+	h2.Peerstore().ClearAddrs(h1.ID())
+	require.Eventually(t, func() bool { return !hasDirectConn(h1, h2.ID()) }, 5*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, s.ConnectBack(ctx, h2.ID()))
+	require.True(t, hasDirectConn(h1, h2.ID()))
+}
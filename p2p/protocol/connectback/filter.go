@@ -0,0 +1,49 @@
+package connectback
+
+import (
+	"errors"
+	"net"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// WithAddrFilter is a Service option that enables multiaddress filtering.
+// It allows to only send a subset of our addresses to the remote peer when asking it to
+// connect back, and to only dial a subset of the addresses a remote peer asked us to
+// connect back to.
+//
+// By default, only public addresses are sent and dialed. If a filter is set, it replaces
+// this check, relay addresses are removed nonetheless.
+func WithAddrFilter(f AddrFilter) Option {
+	return func(s *Service) error {
+		s.filter = f
+		return nil
+	}
+}
+
+// WithDialBackAllowlist is a Service option that allows dialing back peers on addresses in
+// the given networks, even if we have never observed a direct connection from that IP.
+//
+// By default, we only dial back a peer on addresses whose IP we have observed for that peer,
+// to prevent connect-back requests from making us dial arbitrary hosts. Only use this option
+// for networks you trust, for example in a private deployment.
+func WithDialBackAllowlist(nets ...*net.IPNet) Option {
+	return func(s *Service) error {
+		for _, n := range nets {
+			if n == nil {
+				return errors.New("nil network in dial-back allowlist")
+			}
+		}
+		s.allowlist = append(s.allowlist, nets...)
+		return nil
+	}
+}
+
+// AddrFilter defines the interface for the multi address filtering.
+type AddrFilter interface {
+	// FilterLocal filters the multi addresses that are sent to the remote peer.
+	FilterLocal(remoteID peer.ID, maddrs []ma.Multiaddr) []ma.Multiaddr
+	// FilterRemote filters the multi addresses received from the remote peer.
+	FilterRemote(remoteID peer.ID, maddrs []ma.Multiaddr) []ma.Multiaddr
+}
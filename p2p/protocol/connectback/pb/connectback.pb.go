@@ -0,0 +1,289 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v3.21.12
+// source: pb/connectback.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ConnectBackResponse_Status int32
+
+const (
+	ConnectBackResponse_OK             ConnectBackResponse_Status = 100
+	ConnectBackResponse_E_NO_ADDRS     ConnectBackResponse_Status = 200
+	ConnectBackResponse_E_DIAL_FAILED  ConnectBackResponse_Status = 201
+	ConnectBackResponse_E_RATE_LIMITED ConnectBackResponse_Status = 202
+)
+
+// Enum value maps for ConnectBackResponse_Status.
+var (
+	ConnectBackResponse_Status_name = map[int32]string{
+		100: "OK",
+		200: "E_NO_ADDRS",
+		201: "E_DIAL_FAILED",
+		202: "E_RATE_LIMITED",
+	}
+	ConnectBackResponse_Status_value = map[string]int32{
+		"OK":             100,
+		"E_NO_ADDRS":     200,
+		"E_DIAL_FAILED":  201,
+		"E_RATE_LIMITED": 202,
+	}
+)
+
+func (x ConnectBackResponse_Status) Enum() *ConnectBackResponse_Status {
+	p := new(ConnectBackResponse_Status)
+	*p = x
+	return p
+}
+
+func (x ConnectBackResponse_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ConnectBackResponse_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_connectback_proto_enumTypes[0].Descriptor()
+}
+
+func (ConnectBackResponse_Status) Type() protoreflect.EnumType {
+	return &file_pb_connectback_proto_enumTypes[0]
+}
+
+func (x ConnectBackResponse_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *ConnectBackResponse_Status) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = ConnectBackResponse_Status(num)
+	return nil
+}
+
+// Deprecated: Use ConnectBackResponse_Status.Descriptor instead.
+func (ConnectBackResponse_Status) EnumDescriptor() ([]byte, []int) {
+	return file_pb_connectback_proto_rawDescGZIP(), []int{1, 0}
+}
+
+type ConnectBackRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// addrs are the addresses the receiver should dial the sender on
+	Addrs [][]byte `protobuf:"bytes,1,rep,name=addrs" json:"addrs,omitempty"`
+}
+
+func (x *ConnectBackRequest) Reset() {
+	*x = ConnectBackRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_connectback_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ConnectBackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectBackRequest) ProtoMessage() {}
+
+func (x *ConnectBackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_connectback_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectBackRequest.ProtoReflect.Descriptor instead.
+func (*ConnectBackRequest) Descriptor() ([]byte, []int) {
+	return file_pb_connectback_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ConnectBackRequest) GetAddrs() [][]byte {
+	if x != nil {
+		return x.Addrs
+	}
+	return nil
+}
+
+type ConnectBackResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status     *ConnectBackResponse_Status `protobuf:"varint,1,req,name=status,enum=connectback.pb.ConnectBackResponse_Status" json:"status,omitempty"`
+	StatusText *string                     `protobuf:"bytes,2,opt,name=statusText" json:"statusText,omitempty"`
+}
+
+func (x *ConnectBackResponse) Reset() {
+	*x = ConnectBackResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_connectback_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ConnectBackResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectBackResponse) ProtoMessage() {}
+
+func (x *ConnectBackResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_connectback_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectBackResponse.ProtoReflect.Descriptor instead.
+func (*ConnectBackResponse) Descriptor() ([]byte, []int) {
+	return file_pb_connectback_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ConnectBackResponse) GetStatus() ConnectBackResponse_Status {
+	if x != nil && x.Status != nil {
+		return *x.Status
+	}
+	return ConnectBackResponse_OK
+}
+
+func (x *ConnectBackResponse) GetStatusText() string {
+	if x != nil && x.StatusText != nil {
+		return *x.StatusText
+	}
+	return ""
+}
+
+var File_pb_connectback_proto protoreflect.FileDescriptor
+
+var file_pb_connectback_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x70, 0x62, 0x2f, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x62, 0x61, 0x63, 0x6b,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x62,
+	0x61, 0x63, 0x6b, 0x2e, 0x70, 0x62, 0x22, 0x2a, 0x0a, 0x12, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
+	0x74, 0x42, 0x61, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05,
+	0x61, 0x64, 0x64, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x05, 0x61, 0x64, 0x64,
+	0x72, 0x73, 0x22, 0xc5, 0x01, 0x0a, 0x13, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x42, 0x61,
+	0x63, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x02, 0x28, 0x0e, 0x32, 0x2a, 0x2e, 0x63, 0x6f, 0x6e,
+	0x6e, 0x65, 0x63, 0x74, 0x62, 0x61, 0x63, 0x6b, 0x2e, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6e, 0x6e,
+	0x65, 0x63, 0x74, 0x42, 0x61, 0x63, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1e,
+	0x0a, 0x0a, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x54, 0x65, 0x78, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x54, 0x65, 0x78, 0x74, 0x22, 0x4a,
+	0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x64,
+	0x12, 0x0f, 0x0a, 0x0a, 0x45, 0x5f, 0x4e, 0x4f, 0x5f, 0x41, 0x44, 0x44, 0x52, 0x53, 0x10, 0xc8,
+	0x01, 0x12, 0x12, 0x0a, 0x0d, 0x45, 0x5f, 0x44, 0x49, 0x41, 0x4c, 0x5f, 0x46, 0x41, 0x49, 0x4c,
+	0x45, 0x44, 0x10, 0xc9, 0x01, 0x12, 0x13, 0x0a, 0x0e, 0x45, 0x5f, 0x52, 0x41, 0x54, 0x45, 0x5f,
+	0x4c, 0x49, 0x4d, 0x49, 0x54, 0x45, 0x44, 0x10, 0xca, 0x01,
+}
+
+var (
+	file_pb_connectback_proto_rawDescOnce sync.Once
+	file_pb_connectback_proto_rawDescData = file_pb_connectback_proto_rawDesc
+)
+
+func file_pb_connectback_proto_rawDescGZIP() []byte {
+	file_pb_connectback_proto_rawDescOnce.Do(func() {
+		file_pb_connectback_proto_rawDescData = protoimpl.X.CompressGZIP(file_pb_connectback_proto_rawDescData)
+	})
+	return file_pb_connectback_proto_rawDescData
+}
+
+var file_pb_connectback_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pb_connectback_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_pb_connectback_proto_goTypes = []interface{}{
+	(ConnectBackResponse_Status)(0), // 0: connectback.pb.ConnectBackResponse.Status
+	(*ConnectBackRequest)(nil),      // 1: connectback.pb.ConnectBackRequest
+	(*ConnectBackResponse)(nil),     // 2: connectback.pb.ConnectBackResponse
+}
+var file_pb_connectback_proto_depIdxs = []int32{
+	0, // 0: connectback.pb.ConnectBackResponse.status:type_name -> connectback.pb.ConnectBackResponse.Status
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_pb_connectback_proto_init() }
+func file_pb_connectback_proto_init() {
+	if File_pb_connectback_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pb_connectback_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConnectBackRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pb_connectback_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConnectBackResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pb_connectback_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_pb_connectback_proto_goTypes,
+		DependencyIndexes: file_pb_connectback_proto_depIdxs,
+		EnumInfos:         file_pb_connectback_proto_enumTypes,
+		MessageInfos:      file_pb_connectback_proto_msgTypes,
+	}.Build()
+	File_pb_connectback_proto = out.File
+	file_pb_connectback_proto_rawDesc = nil
+	file_pb_connectback_proto_goTypes = nil
+	file_pb_connectback_proto_depIdxs = nil
+}
@@ -0,0 +1,258 @@
+package identify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/core/record"
+	"github.com/libp2p/go-libp2p/p2p/protocol/identify/pb"
+
+	"github.com/libp2p/go-msgio/pbio"
+	msmux "github.com/multiformats/go-multistream"
+	"google.golang.org/protobuf/proto"
+)
+
+// snapshotWithSeqLocked returns the snapshot with the given sequence number, if it is still
+// in the history. The caller must hold the currentSnapshot lock.
+func (ids *idService) snapshotWithSeqLocked(seq uint64) (identifySnapshot, bool) {
+	if seq == 0 {
+		return identifySnapshot{}, false
+	}
+	for _, s := range ids.currentSnapshot.history {
+		if s.seq == seq {
+			return s, true
+		}
+	}
+	return identifySnapshot{}, false
+}
+
+func (ids *idService) supportsDeltaPush(p peer.ID) bool {
+	if !ids.deltaPush {
+		return false
+	}
+	sup, err := ids.Host.Peerstore().SupportsProtocols(p, IDPushDelta)
+	return err == nil && len(sup) > 0
+}
+
+// createDelta creates a delta message describing the changes from base to snapshot.
+// It returns nil if the change can't be expressed as a delta.
+func (ids *idService) createDelta(c network.Conn, base, snapshot *identifySnapshot) *pb.Delta {
+	if base.record != nil && snapshot.record == nil {
+		// There's no way to tell the peer to forget the signed peer record.
+		return nil
+	}
+	for _, r := range base.records {
+		if !slices.ContainsFunc(snapshot.records, func(other *record.Envelope) bool { return bytes.Equal(r.PayloadType, other.PayloadType) }) {
+			// There's no way to tell the peer to forget a signed record.
+			return nil
+		}
+	}
+	delta := &pb.Delta{
+		BaseSeq: &base.seq,
+		Seq:     &snapshot.seq,
+	}
+
+	addedProtos, removedProtos := diff(base.protocols, snapshot.protocols)
+	delta.AddedProtocols = protocol.ConvertToStrings(addedProtos)
+	delta.RemovedProtocols = protocol.ConvertToStrings(removedProtos)
+
+	delta.AddedListenAddrs, delta.RemovedListenAddrs = diffBytes(
+		listenAddrsForConn(c, base.addrs),
+		listenAddrsForConn(c, snapshot.addrs),
+	)
+
+	if snapshot.record != nil && (base.record == nil || !base.record.Equal(snapshot.record)) {
+		delta.SignedPeerRecord = ids.getSignedRecord(snapshot)
+	}
+	for _, r := range snapshot.records {
+		if slices.ContainsFunc(base.records, r.Equal) {
+			continue
+		}
+		b, err := r.Marshal()
+		if err != nil {
+			log.Errorw("failed to marshal signed record", "err", err)
+			return nil
+		}
+		delta.SignedRecords = append(delta.SignedRecords, b)
+	}
+	return delta
+}
+
+// sendDeltaPush sends the changes between base and snapshot on the connection.
+// The peer resets the stream if it can't apply the delta, in which case an error is returned,
+// and a full push needs to be sent.
+func (ids *idService) sendDeltaPush(ctx context.Context, c network.Conn, base, snapshot *identifySnapshot) error {
+	delta := ids.createDelta(c, base, snapshot)
+	if delta == nil {
+		return errors.New("cannot express change as delta")
+	}
+
+	s, err := c.NewStream(ctx)
+	if err != nil {
+		return err
+	}
+	setHighPriority(s)
+	if deadline, ok := ctx.Deadline(); ok {
+		s.SetDeadline(deadline)
+	}
+	if err := s.SetProtocol(IDPushDelta); err != nil {
+		s.Reset()
+		return err
+	}
+	if err := msmux.SelectProtoOrFail(IDPushDelta, s); err != nil {
+		s.Reset()
+		return err
+	}
+	if err := s.Scope().SetService(ServiceName); err != nil {
+		s.Reset()
+		return fmt.Errorf("failed to attaching stream to identify service: %w", err)
+	}
+
+	log.Debugw("sending delta", "peer", c.RemotePeer(), "base", base.seq, "seq", snapshot.seq)
+	if err := pbio.NewDelimitedWriter(s).WriteMsg(delta); err != nil {
+		s.Reset()
+		return err
+	}
+	if err := s.CloseWrite(); err != nil {
+		s.Reset()
+		return err
+	}
+	// Wait for the peer to acknowledge the delta by closing the stream.
+	if _, err := s.Read(make([]byte, 1)); err != io.EOF {
+		s.Reset()
+		return fmt.Errorf("peer didn't accept delta: %w", err)
+	}
+	s.Close()
+
+	if ids.metricsTracer != nil {
+		ids.metricsTracer.IdentifySent(true, len(delta.AddedProtocols), len(delta.AddedListenAddrs))
+	}
+
+	ids.connsMu.Lock()
+	defer ids.connsMu.Unlock()
+	if e, ok := ids.conns[c]; ok {
+		e.Sequence = snapshot.seq
+		ids.conns[c] = e
+	}
+	return nil
+}
+
+func (ids *idService) handleDeltaPush(s network.Stream) {
+	s.SetDeadline(time.Now().Add(Timeout))
+	if err := s.Scope().SetService(ServiceName); err != nil {
+		log.Warnf("error attaching stream to identify service: %s", err)
+		s.Reset()
+		return
+	}
+	if err := s.Scope().ReserveMemory(ids.maxMessageSize, network.ReservationPriorityAlways); err != nil {
+		log.Warnf("error reserving memory for identify stream: %s", err)
+		s.Reset()
+		return
+	}
+	defer s.Scope().ReleaseMemory(ids.maxMessageSize)
+
+	c := s.Conn()
+	delta := &pb.Delta{}
+	if err := pbio.NewDelimitedReader(s, ids.maxMessageSize).ReadMsg(delta); err != nil {
+		log.Debugw("error reading identify delta", "peer", c.RemotePeer(), "error", err)
+		s.Reset()
+		return
+	}
+
+	ids.connsMu.Lock()
+	e, ok := ids.conns[c]
+	if !ok || e.Received == nil || e.Received.GetSeq() != delta.GetBaseSeq() {
+		ids.connsMu.Unlock()
+		// Signal to the peer that it needs to send a full push.
+		log.Debugw("can't apply identify delta", "peer", c.RemotePeer(), "base", delta.GetBaseSeq())
+		s.Reset()
+		return
+	}
+	mes := applyDelta(e.Received, delta)
+	ids.limitMessage(mes, c)
+	e.Received = mes
+	ids.conns[c] = e
+	ids.connsMu.Unlock()
+
+	log.Debugf("%s received delta from %s %s", s.Protocol(), c.RemotePeer(), c.RemoteMultiaddr())
+	ids.consumeMessage(mes, c, true)
+	s.Close()
+
+	if ids.metricsTracer != nil {
+		ids.metricsTracer.IdentifyReceived(true, len(delta.AddedProtocols), len(delta.AddedListenAddrs))
+	}
+}
+
+// applyDelta returns a new message that is the result of applying delta to base.
+func applyDelta(base *pb.Identify, delta *pb.Delta) *pb.Identify {
+	mes := proto.Clone(base).(*pb.Identify)
+	seq := delta.GetSeq()
+	mes.Seq = &seq
+
+	protos := make([]string, 0, len(base.Protocols)+len(delta.AddedProtocols))
+	for _, p := range base.Protocols {
+		if !slices.Contains(delta.RemovedProtocols, p) {
+			protos = append(protos, p)
+		}
+	}
+	for _, p := range delta.AddedProtocols {
+		if !slices.Contains(protos, p) {
+			protos = append(protos, p)
+		}
+	}
+	mes.Protocols = protos
+
+	addrs := make([][]byte, 0, len(base.ListenAddrs)+len(delta.AddedListenAddrs))
+	for _, a := range base.ListenAddrs {
+		if !containsBytes(delta.RemovedListenAddrs, a) {
+			addrs = append(addrs, a)
+		}
+	}
+	for _, a := range delta.AddedListenAddrs {
+		if !containsBytes(addrs, a) {
+			addrs = append(addrs, a)
+		}
+	}
+	mes.ListenAddrs = addrs
+
+	if delta.SignedPeerRecord != nil {
+		mes.SignedPeerRecord = delta.SignedPeerRecord
+	}
+	// The signed records that didn't change were already consumed,
+	// so only the updated records need to be processed.
+	mes.SignedRecords = delta.SignedRecords
+	return mes
+}
+
+// diffBytes computes which elements were added and removed in b, compared to a.
+func diffBytes(a, b [][]byte) (added, removed [][]byte) {
+	for _, x := range b {
+		if !containsBytes(a, x) {
+			added = append(added, x)
+		}
+	}
+	for _, x := range a {
+		if !containsBytes(b, x) {
+			removed = append(removed, x)
+		}
+	}
+	return
+}
+
+func containsBytes(s [][]byte, b []byte) bool {
+	for _, x := range s {
+		if bytes.Equal(x, b) {
+			return true
+		}
+	}
+	return false
+}
@@ -40,12 +40,19 @@ const (
 	// IDPush is the protocol.ID of the Identify push protocol.
 	// It sends full identify messages containing the current state of the peer.
 	IDPush = "/ipfs/id/push/1.0.0"
+	// IDPushDelta is the protocol.ID of the Identify delta push protocol.
+	// It sends only the changes since the last identify message sent to the peer.
+	IDPushDelta = "/libp2p/id/push-delta/1.0.0"
 )
 
 const ServiceName = "libp2p.identify"
 
 const maxPushConcurrency = 32
 
+// snapshotHistorySize is the number of previous snapshots kept to compute deltas from.
+// Peers that are further behind receive a full snapshot.
+const snapshotHistorySize = 16
+
 var Timeout = 60 * time.Second // timeout on all incoming Identify interactions
 
 const (
@@ -54,6 +61,13 @@ const (
 	maxMessages  = 10
 )
 
+// maxKeySuccessionRecords is the maximum number of key succession records sent and accepted
+// in an Identify message.
+const maxKeySuccessionRecords = 8
+
+// maxSignedRecords is the maximum number of signed records sent and accepted in an Identify message.
+const maxSignedRecords = 16
+
 var defaultUserAgent = "github.com/libp2p/go-libp2p"
 
 type identifySnapshot struct {
@@ -61,6 +75,8 @@ type identifySnapshot struct {
 	protocols []protocol.ID
 	addrs     []ma.Multiaddr
 	record    *record.Envelope
+	// records are the signed records published by the application, sorted by payload type.
+	records []*record.Envelope
 }
 
 // Equal says if two snapshots are identical.
@@ -74,6 +90,14 @@ func (s identifySnapshot) Equal(other *identifySnapshot) bool {
 	if hasRecord && !s.record.Equal(other.record) {
 		return false
 	}
+	if len(s.records) != len(other.records) {
+		return false
+	}
+	for i, r := range s.records {
+		if !r.Equal(other.records[i]) {
+			return false
+		}
+	}
 	if !slices.Equal(s.protocols, other.protocols) {
 		return false
 	}
@@ -103,6 +127,11 @@ type IDService interface {
 	// ObservedAddrsFor returns the addresses peers have reported we've dialed from,
 	// for a specific local address.
 	ObservedAddrsFor(local ma.Multiaddr) []ma.Multiaddr
+	// PublishRecord signs a record with the host's key, stores it in the peerstore and sends it
+	// to all connected peers, as well as to peers connecting in the future.
+	// A record replaces the previously published record of the same type, and therefore needs
+	// to have a higher sequence number. Published records can't be withdrawn.
+	PublishRecord(rec record.SequencedRecord) error
 	Start()
 	io.Closer
 }
@@ -125,6 +154,9 @@ type entry struct {
 	PushSupport identifyPushSupport
 	// Sequence is the sequence number of the last snapshot we sent to this peer.
 	Sequence uint64
+	// Received is the last state we received from this peer, with delta pushes applied.
+	// It is only tracked if delta pushes are enabled.
+	Received *pb.Identify
 }
 
 // idService is a structure that implements ProtocolIdentify.
@@ -149,6 +181,10 @@ type idService struct {
 	refCount sync.WaitGroup
 
 	disableSignedPeerRecord bool
+	deltaPush               bool
+	maxMessageSize          int
+	maxProtocols            int
+	maxAddrs                int
 
 	connsMu sync.RWMutex
 	// The conns map contains all connections we're currently handling.
@@ -165,11 +201,19 @@ type idService struct {
 		evtPeerProtocolsUpdated        event.Emitter
 		evtPeerIdentificationCompleted event.Emitter
 		evtPeerIdentificationFailed    event.Emitter
+		evtPeerKeySuccession           event.Emitter
+		evtPeerSignedRecordUpdated     event.Emitter
 	}
 
+	// recordsUpdated is used to notify the event loop when a signed record is published.
+	recordsUpdated chan struct{}
+
 	currentSnapshot struct {
 		sync.Mutex
 		snapshot identifySnapshot
+		// history contains the previous snapshots, oldest first.
+		// It is only populated if delta pushes are enabled.
+		history []identifySnapshot
 	}
 }
 
@@ -185,6 +229,10 @@ func NewIDService(h host.Host, opts ...Option) (*idService, error) {
 	if cfg.userAgent != "" {
 		userAgent = cfg.userAgent
 	}
+	maxMessageSize := signedIDSize
+	if cfg.maxMessageSize > 0 {
+		maxMessageSize = cfg.maxMessageSize
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	s := &idService{
@@ -195,7 +243,12 @@ func NewIDService(h host.Host, opts ...Option) (*idService, error) {
 		ctxCancel:               cancel,
 		conns:                   make(map[network.Conn]entry),
 		disableSignedPeerRecord: cfg.disableSignedPeerRecord,
+		deltaPush:               cfg.deltaPush,
+		maxMessageSize:          maxMessageSize,
+		maxProtocols:            cfg.maxProtocols,
+		maxAddrs:                cfg.maxAddrs,
 		setupCompleted:          make(chan struct{}),
+		recordsUpdated:          make(chan struct{}, 1),
 		metricsTracer:           cfg.metricsTracer,
 	}
 
@@ -217,6 +270,14 @@ func NewIDService(h host.Host, opts ...Option) (*idService, error) {
 	if err != nil {
 		log.Warnf("identify service not emitting identification failed events; err: %s", err)
 	}
+	s.emitters.evtPeerKeySuccession, err = h.EventBus().Emitter(&event.EvtPeerKeySuccession{})
+	if err != nil {
+		log.Warnf("identify service not emitting key succession events; err: %s", err)
+	}
+	s.emitters.evtPeerSignedRecordUpdated, err = h.EventBus().Emitter(&event.EvtPeerSignedRecordUpdated{})
+	if err != nil {
+		log.Warnf("identify service not emitting signed record events; err: %s", err)
+	}
 	return s, nil
 }
 
@@ -224,6 +285,9 @@ func (ids *idService) Start() {
 	ids.Host.Network().Notify((*netNotifiee)(ids))
 	ids.Host.SetStreamHandler(ID, ids.handleIdentifyRequest)
 	ids.Host.SetStreamHandler(IDPush, ids.handlePush)
+	if ids.deltaPush {
+		ids.Host.SetStreamHandler(IDPushDelta, ids.handleDeltaPush)
+	}
 	ids.updateSnapshot()
 	close(ids.setupCompleted)
 
@@ -265,24 +329,28 @@ func (ids *idService) loop(ctx context.Context) {
 	}()
 
 	for {
+		var e any
 		select {
-		case e, ok := <-sub.Out():
+		case ev, ok := <-sub.Out():
 			if !ok {
 				return
 			}
-			if updated := ids.updateSnapshot(); !updated {
-				continue
-			}
-			if ids.metricsTracer != nil {
-				ids.metricsTracer.TriggeredPushes(e)
-			}
-			select {
-			case triggerPush <- struct{}{}:
-			default: // we already have one more push queued, no need to queue another one
-			}
+			e = ev
+		case <-ids.recordsUpdated:
+			e = signedRecordsUpdated{}
 		case <-ctx.Done():
 			return
 		}
+		if updated := ids.updateSnapshot(); !updated {
+			continue
+		}
+		if ids.metricsTracer != nil {
+			ids.metricsTracer.TriggeredPushes(e)
+		}
+		select {
+		case triggerPush <- struct{}{}:
+		default: // we already have one more push queued, no need to queue another one
+		}
 	}
 }
 
@@ -311,6 +379,7 @@ func (ids *idService) sendPushes(ctx context.Context) {
 		// check if we already sent the current snapshot to this peer
 		ids.currentSnapshot.Lock()
 		snapshot := ids.currentSnapshot.snapshot
+		base, hasBase := ids.snapshotWithSeqLocked(e.Sequence)
 		ids.currentSnapshot.Unlock()
 		if e.Sequence >= snapshot.seq {
 			log.Debugw("already sent this snapshot to peer", "peer", c.RemotePeer(), "seq", snapshot.seq)
@@ -324,10 +393,18 @@ func (ids *idService) sendPushes(ctx context.Context) {
 			defer func() { <-sem }()
 			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 			defer cancel()
+			if hasBase && ids.supportsDeltaPush(c.RemotePeer()) {
+				err := ids.sendDeltaPush(ctx, c, &base, &snapshot)
+				if err == nil {
+					return
+				}
+				log.Debugw("failed to send identify delta push, falling back to full push", "peer", c.RemotePeer(), "error", err)
+			}
 			str, err := ids.Host.NewStream(ctx, c.RemotePeer(), IDPush)
 			if err != nil { // connection might have been closed recently
 				return
 			}
+			setHighPriority(str)
 			// TODO: find out if the peer supports push if we didn't have any information about push support
 			if err := ids.sendIdentifyResp(str, true); err != nil {
 				log.Debugw("failed to send identify push", "peer", c.RemotePeer(), "error", err)
@@ -338,6 +415,14 @@ func (ids *idService) sendPushes(ctx context.Context) {
 	wg.Wait()
 }
 
+// setHighPriority prioritizes a push stream, so that address updates aren't delayed by
+// bulk transfers on the same connection.
+func setHighPriority(s network.Stream) {
+	if ps, ok := s.(network.PrioritizedStream); ok {
+		_ = ps.SetPriority(network.StreamPriorityHigh)
+	}
+}
+
 // Close shuts down the idService
 func (ids *idService) Close() error {
 	ids.ctxCancel()
@@ -354,6 +439,36 @@ func (ids *idService) ObservedAddrsFor(local ma.Multiaddr) []ma.Multiaddr {
 	return ids.observedAddrs.AddrsFor(local)
 }
 
+// signedRecordsUpdated is passed to the metrics tracer when a push is triggered by a published record.
+type signedRecordsUpdated struct{}
+
+func (ids *idService) PublishRecord(rec record.SequencedRecord) error {
+	srb, ok := peerstore.GetSignedRecordBook(ids.Host.Peerstore())
+	if !ok {
+		return errors.New("peerstore doesn't support signed records")
+	}
+	key := ids.Host.Peerstore().PrivKey(ids.Host.ID())
+	if key == nil {
+		return errors.New("missing private key")
+	}
+	env, err := record.Seal(rec, key)
+	if err != nil {
+		return err
+	}
+	accepted, err := srb.ConsumeSignedRecord(env)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return fmt.Errorf("a %s record with the same or a higher sequence number was already published", rec.Domain())
+	}
+	select {
+	case ids.recordsUpdated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
 // IdentifyConn runs the Identify protocol on a connection.
 // It returns when we've received the peer's Identify message (or the request fails).
 // If successful, the peer store will contain the peer's addresses and supported protocols.
@@ -457,6 +572,8 @@ func (ids *idService) sendIdentifyResp(s network.Stream, isPush bool) error {
 
 	mes := ids.createBaseIdentifyResponse(s.Conn(), &snapshot)
 	mes.SignedPeerRecord = ids.getSignedRecord(&snapshot)
+	mes.KeySuccessionRecords = ids.getKeySuccessionRecords()
+	mes.SignedRecords = getSignedRecords(&snapshot)
 
 	log.Debugf("%s sending message to %s %s", ID, s.Conn().RemotePeer(), s.Conn().RemoteMultiaddr())
 	if err := ids.writeChunkedIdentifyMsg(s, mes); err != nil {
@@ -489,16 +606,16 @@ func (ids *idService) handleIdentifyResponse(s network.Stream, isPush bool) erro
 		return err
 	}
 
-	if err := s.Scope().ReserveMemory(signedIDSize, network.ReservationPriorityAlways); err != nil {
+	if err := s.Scope().ReserveMemory(ids.maxMessageSize, network.ReservationPriorityAlways); err != nil {
 		log.Warnf("error reserving memory for identify stream: %s", err)
 		s.Reset()
 		return err
 	}
-	defer s.Scope().ReleaseMemory(signedIDSize)
+	defer s.Scope().ReleaseMemory(ids.maxMessageSize)
 
 	c := s.Conn()
 
-	r := pbio.NewDelimitedReader(s, signedIDSize)
+	r := pbio.NewDelimitedReader(s, ids.maxMessageSize)
 	mes := &pb.Identify{}
 
 	if err := readAllIDMessages(r, mes); err != nil {
@@ -511,6 +628,18 @@ func (ids *idService) handleIdentifyResponse(s network.Stream, isPush bool) erro
 
 	log.Debugf("%s received message from %s %s", s.Protocol(), c.RemotePeer(), c.RemoteMultiaddr())
 
+	ids.limitMessage(mes, c)
+	if ids.deltaPush {
+		received := proto.Clone(mes).(*pb.Identify)
+		received.ObservedAddr = nil
+		ids.connsMu.Lock()
+		if e, ok := ids.conns[c]; ok {
+			e.Received = received
+			ids.conns[c] = e
+		}
+		ids.connsMu.Unlock()
+	}
+
 	ids.consumeMessage(mes, c, isPush)
 
 	if ids.metricsTracer != nil {
@@ -569,6 +698,10 @@ func (ids *idService) updateSnapshot() (updated bool) {
 			snapshot.record = cab.GetPeerRecord(ids.Host.ID())
 		}
 	}
+	if srb, ok := peerstore.GetSignedRecordBook(ids.Host.Peerstore()); ok {
+		snapshot.records = srb.SignedRecords(ids.Host.ID())
+		slices.SortFunc(snapshot.records, func(a, b *record.Envelope) int { return bytes.Compare(a.PayloadType, b.PayloadType) })
+	}
 
 	ids.currentSnapshot.Lock()
 	defer ids.currentSnapshot.Unlock()
@@ -577,6 +710,12 @@ func (ids *idService) updateSnapshot() (updated bool) {
 		return false
 	}
 
+	if ids.deltaPush && ids.currentSnapshot.snapshot.seq > 0 {
+		if len(ids.currentSnapshot.history) >= snapshotHistorySize {
+			ids.currentSnapshot.history = slices.Delete(ids.currentSnapshot.history, 0, 1)
+		}
+		ids.currentSnapshot.history = append(ids.currentSnapshot.history, ids.currentSnapshot.snapshot)
+	}
 	snapshot.seq = ids.currentSnapshot.snapshot.seq + 1
 	ids.currentSnapshot.snapshot = snapshot
 
@@ -587,24 +726,27 @@ func (ids *idService) updateSnapshot() (updated bool) {
 func (ids *idService) writeChunkedIdentifyMsg(s network.Stream, mes *pb.Identify) error {
 	writer := pbio.NewDelimitedWriter(s)
 
-	if mes.SignedPeerRecord == nil || proto.Size(mes) <= legacyIDSize {
+	if (mes.SignedPeerRecord == nil && mes.KeySuccessionRecords == nil && mes.SignedRecords == nil) || proto.Size(mes) <= legacyIDSize {
 		return writer.WriteMsg(mes)
 	}
 
 	sr := mes.SignedPeerRecord
+	ksr := mes.KeySuccessionRecords
+	recs := mes.SignedRecords
 	mes.SignedPeerRecord = nil
+	mes.KeySuccessionRecords = nil
+	mes.SignedRecords = nil
 	if err := writer.WriteMsg(mes); err != nil {
 		return err
 	}
-	// then write just the signed record
-	return writer.WriteMsg(&pb.Identify{SignedPeerRecord: sr})
+	// then write just the signed records
+	return writer.WriteMsg(&pb.Identify{SignedPeerRecord: sr, KeySuccessionRecords: ksr, SignedRecords: recs})
 }
 
 func (ids *idService) createBaseIdentifyResponse(conn network.Conn, snapshot *identifySnapshot) *pb.Identify {
 	mes := &pb.Identify{}
 
 	remoteAddr := conn.RemoteMultiaddr()
-	localAddr := conn.LocalMultiaddr()
 
 	// set protocols this node is currently handling
 	mes.Protocols = protocol.ConvertToStrings(snapshot.protocols)
@@ -615,15 +757,7 @@ func (ids *idService) createBaseIdentifyResponse(conn network.Conn, snapshot *id
 
 	// populate unsigned addresses.
 	// peers that do not yet support signed addresses will need this.
-	// Note: LocalMultiaddr is sometimes 0.0.0.0
-	viaLoopback := manet.IsIPLoopback(localAddr) || manet.IsIPLoopback(remoteAddr)
-	mes.ListenAddrs = make([][]byte, 0, len(snapshot.addrs))
-	for _, addr := range snapshot.addrs {
-		if !viaLoopback && manet.IsIPLoopback(addr) {
-			continue
-		}
-		mes.ListenAddrs = append(mes.ListenAddrs, addr.Bytes())
-	}
+	mes.ListenAddrs = listenAddrsForConn(conn, snapshot.addrs)
 	// set our public key
 	ownKey := ids.Host.Peerstore().PubKey(ids.Host.ID())
 
@@ -649,9 +783,40 @@ func (ids *idService) createBaseIdentifyResponse(conn network.Conn, snapshot *id
 	mes.ProtocolVersion = &ids.ProtocolVersion
 	mes.AgentVersion = &ids.UserAgent
 
+	if ids.deltaPush {
+		mes.Seq = &snapshot.seq
+	}
+
 	return mes
 }
 
+// listenAddrsForConn returns the serialized addresses to send on a connection.
+// Loopback addresses are only sent on loopback connections.
+func listenAddrsForConn(conn network.Conn, addrs []ma.Multiaddr) [][]byte {
+	// Note: LocalMultiaddr is sometimes 0.0.0.0
+	viaLoopback := manet.IsIPLoopback(conn.LocalMultiaddr()) || manet.IsIPLoopback(conn.RemoteMultiaddr())
+	out := make([][]byte, 0, len(addrs))
+	for _, addr := range addrs {
+		if !viaLoopback && manet.IsIPLoopback(addr) {
+			continue
+		}
+		out = append(out, addr.Bytes())
+	}
+	return out
+}
+
+// limitMessage enforces the configured limits on the number of protocols and addresses.
+func (ids *idService) limitMessage(mes *pb.Identify, c network.Conn) {
+	if ids.maxProtocols > 0 && len(mes.Protocols) > ids.maxProtocols {
+		log.Debugw("peer sent too many protocols", "peer", c.RemotePeer(), "count", len(mes.Protocols))
+		mes.Protocols = mes.Protocols[:ids.maxProtocols]
+	}
+	if ids.maxAddrs > 0 && len(mes.ListenAddrs) > ids.maxAddrs {
+		log.Debugw("peer sent too many addresses", "peer", c.RemotePeer(), "count", len(mes.ListenAddrs))
+		mes.ListenAddrs = mes.ListenAddrs[:ids.maxAddrs]
+	}
+}
+
 func (ids *idService) getSignedRecord(snapshot *identifySnapshot) []byte {
 	if ids.disableSignedPeerRecord || snapshot.record == nil {
 		return nil
@@ -666,6 +831,49 @@ func (ids *idService) getSignedRecord(snapshot *identifySnapshot) []byte {
 	return recBytes
 }
 
+// getKeySuccessionRecords returns the key succession records proving that we are the successor
+// of other peers.
+func (ids *idService) getKeySuccessionRecords() [][]byte {
+	ksb, ok := peerstore.GetKeySuccessionBook(ids.Host.Peerstore())
+	if !ok {
+		return nil
+	}
+	envs := ksb.KeySuccessionRecords(ids.Host.ID())
+	if len(envs) > maxKeySuccessionRecords {
+		log.Warnw("too many key succession records, only sending the most recent ones", "count", len(envs))
+		envs = envs[:maxKeySuccessionRecords]
+	}
+	var recs [][]byte
+	for _, env := range envs {
+		b, err := env.Marshal()
+		if err != nil {
+			log.Errorw("failed to marshal key succession record", "err", err)
+			continue
+		}
+		recs = append(recs, b)
+	}
+	return recs
+}
+
+// getSignedRecords returns the signed records published by the application.
+func getSignedRecords(snapshot *identifySnapshot) [][]byte {
+	envs := snapshot.records
+	if len(envs) > maxSignedRecords {
+		log.Warnw("too many signed records, only sending some of them", "count", len(envs))
+		envs = envs[:maxSignedRecords]
+	}
+	var recs [][]byte
+	for _, env := range envs {
+		b, err := env.Marshal()
+		if err != nil {
+			log.Errorw("failed to marshal signed record", "err", err)
+			continue
+		}
+		recs = append(recs, b)
+	}
+	return recs
+}
+
 // diff takes two slices of strings (a and b) and computes which elements were added and removed in b
 func diff(a, b []protocol.ID) (added, removed []protocol.ID) {
 	// This is O(n^2), but it's fine because the slices are small.
@@ -786,6 +994,106 @@ func (ids *idService) consumeMessage(mes *pb.Identify, c network.Conn, isPush bo
 
 	// get the key from the other side. we may not have it (no-auth transport)
 	ids.consumeReceivedPubKey(c, mes.PublicKey)
+
+	ids.consumeKeySuccessionRecords(p, mes.KeySuccessionRecords)
+	ids.consumeSignedRecords(p, mes.SignedRecords)
+}
+
+// consumeSignedRecords stores the signed records sent by p.
+// Records of unknown types, and records not signed by p are ignored.
+func (ids *idService) consumeSignedRecords(p peer.ID, recs [][]byte) {
+	if len(recs) == 0 {
+		return
+	}
+	srb, ok := peerstore.GetSignedRecordBook(ids.Host.Peerstore())
+	if !ok {
+		return
+	}
+	if len(recs) > maxSignedRecords {
+		log.Debugw("peer sent too many signed records", "peer", p, "count", len(recs))
+		recs = recs[:maxSignedRecords]
+	}
+
+	for _, b := range recs {
+		env, rec, err := record.ConsumeRegisteredEnvelope(b)
+		if err != nil {
+			if !errors.Is(err, record.ErrPayloadTypeNotRegistered) {
+				log.Debugw("invalid signed record", "peer", p, "error", err)
+			}
+			continue
+		}
+		id, err := peer.IDFromPublicKey(env.PublicKey)
+		if err != nil || id != p {
+			log.Debugw("received signed record for unexpected peer ID", "peer", p, "signer", id)
+			continue
+		}
+		accepted, err := srb.ConsumeSignedRecord(env)
+		if err != nil {
+			log.Debugw("failed to consume signed record", "peer", p, "error", err)
+			continue
+		}
+		if accepted {
+			ids.emitters.evtPeerSignedRecordUpdated.Emit(event.EvtPeerSignedRecordUpdated{Peer: p, Domain: rec.Domain(), Envelope: env})
+		}
+	}
+}
+
+// consumeKeySuccessionRecords stores the key succession records sent by p.
+// Only records that lead to p are accepted: records announcing p as the successor,
+// and records announcing one of the predecessors of p as the successor.
+func (ids *idService) consumeKeySuccessionRecords(p peer.ID, recs [][]byte) {
+	if len(recs) == 0 {
+		return
+	}
+	ksb, ok := peerstore.GetKeySuccessionBook(ids.Host.Peerstore())
+	if !ok {
+		return
+	}
+	if len(recs) > maxKeySuccessionRecords {
+		log.Debugw("peer sent too many key succession records", "peer", p, "count", len(recs))
+		recs = recs[:maxKeySuccessionRecords]
+	}
+
+	type succession struct {
+		env *record.Envelope
+		rec *peer.KeySuccessionRecord
+	}
+	bySuccessor := make(map[peer.ID][]succession, len(recs))
+	for _, b := range recs {
+		env, rec, err := peer.ConsumeKeySuccession(b)
+		if err != nil {
+			log.Debugw("invalid key succession record", "peer", p, "error", err)
+			continue
+		}
+		successor, err := rec.SuccessorID()
+		if err != nil {
+			continue
+		}
+		bySuccessor[successor] = append(bySuccessor[successor], succession{env: env, rec: rec})
+	}
+
+	visited := map[peer.ID]struct{}{p: {}}
+	queue := []peer.ID{p}
+	for len(queue) > 0 {
+		successor := queue[0]
+		queue = queue[1:]
+		for _, s := range bySuccessor[successor] {
+			if _, ok := visited[s.rec.PeerID]; ok {
+				continue
+			}
+			visited[s.rec.PeerID] = struct{}{}
+			queue = append(queue, s.rec.PeerID)
+
+			accepted, err := ksb.ConsumeKeySuccession(s.env)
+			if err != nil {
+				log.Debugw("failed to consume key succession record", "peer", p, "error", err)
+				continue
+			}
+			if accepted {
+				ids.emitters.evtPeerKeySuccession.Emit(event.EvtPeerKeySuccession{Peer: s.rec.PeerID, Successor: successor})
+			}
+		}
+	}
 }
 
 func (ids *idService) consumeSignedPeerRecord(p peer.ID, signedPeerRecord *record.Envelope) ([]ma.Multiaddr, error) {
@@ -813,6 +1121,10 @@ func (ids *idService) consumeSignedPeerRecord(p peer.ID, signedPeerRecord *recor
 	// Don't put the signed peer record into the peer store.
 	// They're not used anywhere.
 	// All we care about are the addresses.
+	if ids.maxAddrs > 0 && len(rec.Addrs) > ids.maxAddrs {
+		log.Debugw("peer sent too many addresses in signed peer record", "peer", p, "count", len(rec.Addrs))
+		return rec.Addrs[:ids.maxAddrs], nil
+	}
 	return rec.Addrs, nil
 }
 
@@ -2,6 +2,7 @@ package identify_test
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"sync"
@@ -914,3 +915,349 @@ func waitForDisconnectNotification(swarm *swarm.Swarm) <-chan struct{} {
 
 	return done
 }
+
+func TestDeltaPush(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h1 := blhost.NewBlankHost(swarmt.GenSwarm(t, swarmt.OptDisableQUIC))
+	h2 := blhost.NewBlankHost(swarmt.GenSwarm(t, swarmt.OptDisableQUIC))
+	defer h2.Close()
+	defer h1.Close()
+
+	ids1, err := identify.NewIDService(h1, identify.EnableDeltaPush())
+	require.NoError(t, err)
+	defer ids1.Close()
+	ids1.Start()
+
+	ids2, err := identify.NewIDService(h2, identify.EnableDeltaPush())
+	require.NoError(t, err)
+	defer ids2.Close()
+	ids2.Start()
+
+	require.NoError(t, h1.Connect(ctx, peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+	ids1.IdentifyConn(h1.Network().ConnsToPeer(h2.ID())[0])
+	ids2.IdentifyConn(h2.Network().ConnsToPeer(h1.ID())[0])
+	sup, err := h2.Peerstore().SupportsProtocols(h1.ID(), identify.IDPushDelta)
+	require.NoError(t, err)
+	require.Equal(t, []protocol.ID{identify.IDPushDelta}, sup)
+
+	// Make sure that updates can only be received via delta pushes.
+	h2.RemoveStreamHandler(identify.IDPush)
+
+	h1.SetStreamHandler("rand", func(network.Stream) {})
+	require.Eventually(t, func() bool {
+		sup, err := h2.Peerstore().SupportsProtocols(h1.ID(), "rand")
+		return err == nil && len(sup) == 1
+	}, time.Second, 10*time.Millisecond)
+	// the other protocols are still there
+	sup, err = h2.Peerstore().SupportsProtocols(h1.ID(), identify.ID, identify.IDPushDelta)
+	require.NoError(t, err)
+	require.Len(t, sup, 2)
+
+	h1.RemoveStreamHandler("rand")
+	require.Eventually(t, func() bool {
+		sup, err := h2.Peerstore().SupportsProtocols(h1.ID(), "rand")
+		return err == nil && len(sup) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestDeltaPushFallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h1 := blhost.NewBlankHost(swarmt.GenSwarm(t, swarmt.OptDisableQUIC))
+	h2 := blhost.NewBlankHost(swarmt.GenSwarm(t, swarmt.OptDisableQUIC))
+	defer h2.Close()
+	defer h1.Close()
+
+	ids1, err := identify.NewIDService(h1, identify.EnableDeltaPush())
+	require.NoError(t, err)
+	defer ids1.Close()
+	ids1.Start()
+
+	ids2, err := identify.NewIDService(h2, identify.EnableDeltaPush())
+	require.NoError(t, err)
+	defer ids2.Close()
+	ids2.Start()
+
+	require.NoError(t, h1.Connect(ctx, peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+	ids1.IdentifyConn(h1.Network().ConnsToPeer(h2.ID())[0])
+	ids2.IdentifyConn(h2.Network().ConnsToPeer(h1.ID())[0])
+
+	// Reject all delta pushes. h1 needs to fall back to sending full pushes.
+	h2.SetStreamHandler(identify.IDPushDelta, func(s network.Stream) { s.Reset() })
+
+	h1.SetStreamHandler("rand", func(network.Stream) {})
+	require.Eventually(t, func() bool {
+		sup, err := h2.Peerstore().SupportsProtocols(h1.ID(), "rand")
+		return err == nil && len(sup) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestIdentifyLimits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h1 := blhost.NewBlankHost(swarmt.GenSwarm(t, swarmt.OptDisableQUIC))
+	h2 := blhost.NewBlankHost(swarmt.GenSwarm(t, swarmt.OptDisableQUIC))
+	defer h2.Close()
+	defer h1.Close()
+
+	for i := 0; i < 10; i++ {
+		h1.SetStreamHandler(protocol.ID(fmt.Sprintf("proto%d", i)), func(network.Stream) {})
+	}
+
+	ids1, err := identify.NewIDService(h1)
+	require.NoError(t, err)
+	defer ids1.Close()
+	ids1.Start()
+
+	ids2, err := identify.NewIDService(h2, identify.WithMaxProtocols(5))
+	require.NoError(t, err)
+	defer ids2.Close()
+	ids2.Start()
+
+	require.NoError(t, h2.Connect(ctx, peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+	ids2.IdentifyConn(h2.Network().ConnsToPeer(h1.ID())[0])
+
+	protos, err := h2.Peerstore().GetProtocols(h1.ID())
+	require.NoError(t, err)
+	require.Len(t, protos, 5)
+}
+
+func TestKeySuccession(t *testing.T) {
+	h1 := blhost.NewBlankHost(swarmt.GenSwarm(t))
+	h2 := blhost.NewBlankHost(swarmt.GenSwarm(t))
+	defer h1.Close()
+	defer h2.Close()
+
+	// h2 replaced the keys of two other peers: old1 -> old2 -> h2
+	old1, _, err := ic.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	old2, _, err := ic.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	old1ID, err := peer.IDFromPrivateKey(old1)
+	require.NoError(t, err)
+	old2ID, err := peer.IDFromPrivateKey(old2)
+	require.NoError(t, err)
+	env1, err := peer.NewKeySuccession(old1, old2)
+	require.NoError(t, err)
+	env2, err := peer.NewKeySuccession(old2, h2.Peerstore().PrivKey(h2.ID()))
+	require.NoError(t, err)
+	ksb2, ok := peerstore.GetKeySuccessionBook(h2.Peerstore())
+	require.True(t, ok)
+	for _, env := range []*record.Envelope{env1, env2} {
+		_, err := ksb2.ConsumeKeySuccession(env)
+		require.NoError(t, err)
+	}
+
+	sub, err := h1.EventBus().Subscribe(new(event.EvtPeerKeySuccession))
+	require.NoError(t, err)
+	defer sub.Close()
+
+	ids1, err := identify.NewIDService(h1)
+	require.NoError(t, err)
+	defer ids1.Close()
+	ids1.Start()
+	ids2, err := identify.NewIDService(h2)
+	require.NoError(t, err)
+	defer ids2.Close()
+	ids2.Start()
+
+	require.NoError(t, h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+	select {
+	case <-ids1.IdentifyWait(h1.Network().ConnsToPeer(h2.ID())[0]):
+	case <-time.After(5 * time.Second):
+		t.Fatal("identify timed out")
+	}
+
+	ksb1, ok := peerstore.GetKeySuccessionBook(h1.Peerstore())
+	require.True(t, ok)
+	successor, ok := ksb1.Successor(old1ID)
+	require.True(t, ok)
+	require.Equal(t, old2ID, successor)
+	successor, ok = ksb1.Successor(old2ID)
+	require.True(t, ok)
+	require.Equal(t, h2.ID(), successor)
+
+	var evts []event.EvtPeerKeySuccession
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-sub.Out():
+			evts = append(evts, e.(event.EvtPeerKeySuccession))
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for key succession event")
+		}
+	}
+	require.ElementsMatch(t, []event.EvtPeerKeySuccession{
+		{Peer: old2ID, Successor: h2.ID()},
+		{Peer: old1ID, Successor: old2ID},
+	}, evts)
+}
+
+func TestKeySuccessionUnrelatedRecords(t *testing.T) {
+	h1 := blhost.NewBlankHost(swarmt.GenSwarm(t))
+	h2 := blhost.NewBlankHost(swarmt.GenSwarm(t))
+	defer h1.Close()
+	defer h2.Close()
+
+	// h2 has a record that doesn't lead to h2. It must not be accepted by h1.
+	old, _, err := ic.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	other, _, err := ic.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	oldID, err := peer.IDFromPrivateKey(old)
+	require.NoError(t, err)
+	env, err := peer.NewKeySuccession(old, other)
+	require.NoError(t, err)
+	b, err := env.Marshal()
+	require.NoError(t, err)
+
+	ids1, err := identify.NewIDService(h1)
+	require.NoError(t, err)
+	defer ids1.Close()
+	ids1.Start()
+
+	// Send an identify message containing the record by hand.
+	h2.SetStreamHandler(identify.ID, func(s network.Stream) {
+		defer s.Close()
+		pbio.NewDelimitedWriter(s).WriteMsg(&pb.Identify{KeySuccessionRecords: [][]byte{b}})
+	})
+	require.NoError(t, h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+	select {
+	case <-ids1.IdentifyWait(h1.Network().ConnsToPeer(h2.ID())[0]):
+	case <-time.After(5 * time.Second):
+		t.Fatal("identify timed out")
+	}
+
+	ksb1, ok := peerstore.GetKeySuccessionBook(h1.Peerstore())
+	require.True(t, ok)
+	_, ok = ksb1.Successor(oldID)
+	require.False(t, ok)
+}
+
+// capabilityRecord is a signed record used to test the distribution of signed records.
+type capabilityRecord struct {
+	Seq          uint64
+	Capabilities string
+}
+
+func init() {
+	record.RegisterType(&capabilityRecord{})
+}
+
+func (r *capabilityRecord) Domain() string         { return "libp2p-identify-test-capabilities" }
+func (r *capabilityRecord) Codec() []byte          { return []byte("/libp2p/identify-test-capabilities") }
+func (r *capabilityRecord) SequenceNumber() uint64 { return r.Seq }
+
+func (r *capabilityRecord) MarshalRecord() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d %s", r.Seq, r.Capabilities)), nil
+}
+
+func (r *capabilityRecord) UnmarshalRecord(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "%d %s", &r.Seq, &r.Capabilities)
+	return err
+}
+
+func TestPublishRecord(t *testing.T) {
+	for _, deltaPush := range []bool{false, true} {
+		t.Run(fmt.Sprintf("delta push: %t", deltaPush), func(t *testing.T) {
+			var opts []identify.Option
+			if deltaPush {
+				opts = append(opts, identify.EnableDeltaPush())
+			}
+			h1 := blhost.NewBlankHost(swarmt.GenSwarm(t))
+			h2 := blhost.NewBlankHost(swarmt.GenSwarm(t))
+			defer h1.Close()
+			defer h2.Close()
+
+			ids1, err := identify.NewIDService(h1, opts...)
+			require.NoError(t, err)
+			defer ids1.Close()
+			ids1.Start()
+			ids2, err := identify.NewIDService(h2, opts...)
+			require.NoError(t, err)
+			defer ids2.Close()
+			ids2.Start()
+
+			sub, err := h1.EventBus().Subscribe(new(event.EvtPeerSignedRecordUpdated))
+			require.NoError(t, err)
+			defer sub.Close()
+
+			require.NoError(t, ids2.PublishRecord(&capabilityRecord{Seq: 1, Capabilities: "foo"}))
+			require.Error(t, ids2.PublishRecord(&capabilityRecord{Seq: 1, Capabilities: "bar"}))
+
+			require.NoError(t, h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+			select {
+			case <-ids1.IdentifyWait(h1.Network().ConnsToPeer(h2.ID())[0]):
+			case <-time.After(5 * time.Second):
+				t.Fatal("identify timed out")
+			}
+
+			srb, ok := peerstore.GetSignedRecordBook(h1.Peerstore())
+			require.True(t, ok)
+			getCapabilities := func() string {
+				env := srb.GetSignedRecord(h2.ID(), (&capabilityRecord{}).Domain())
+				if env == nil {
+					return ""
+				}
+				rec, err := env.Record()
+				require.NoError(t, err)
+				return rec.(*capabilityRecord).Capabilities
+			}
+			require.Equal(t, "foo", getCapabilities())
+			select {
+			case e := <-sub.Out():
+				evt := e.(event.EvtPeerSignedRecordUpdated)
+				require.Equal(t, h2.ID(), evt.Peer)
+				require.Equal(t, (&capabilityRecord{}).Domain(), evt.Domain)
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for signed record event")
+			}
+
+			// Updated records are pushed to connected peers.
+			require.NoError(t, ids2.PublishRecord(&capabilityRecord{Seq: 2, Capabilities: "bar"}))
+			require.Eventually(t, func() bool { return getCapabilities() == "bar" }, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestSignedRecordsForeignSigner(t *testing.T) {
+	h1 := blhost.NewBlankHost(swarmt.GenSwarm(t))
+	h2 := blhost.NewBlankHost(swarmt.GenSwarm(t))
+	defer h1.Close()
+	defer h2.Close()
+
+	// h2 sends a record signed by another peer. It must not be accepted by h1.
+	other, _, err := ic.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	otherID, err := peer.IDFromPrivateKey(other)
+	require.NoError(t, err)
+	env, err := record.Seal(&capabilityRecord{Seq: 1, Capabilities: "foo"}, other)
+	require.NoError(t, err)
+	b, err := env.Marshal()
+	require.NoError(t, err)
+
+	ids1, err := identify.NewIDService(h1)
+	require.NoError(t, err)
+	defer ids1.Close()
+	ids1.Start()
+
+	// Send an identify message containing the record by hand.
+	h2.SetStreamHandler(identify.ID, func(s network.Stream) {
+		defer s.Close()
+		pbio.NewDelimitedWriter(s).WriteMsg(&pb.Identify{SignedRecords: [][]byte{b}})
+	})
+	require.NoError(t, h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+	select {
+	case <-ids1.IdentifyWait(h1.Network().ConnsToPeer(h2.ID())[0]):
+	case <-time.After(5 * time.Second):
+		t.Fatal("identify timed out")
+	}
+
+	srb, ok := peerstore.GetSignedRecordBook(h1.Peerstore())
+	require.True(t, ok)
+	require.Empty(t, srb.SignedRecords(h2.ID()))
+	require.Empty(t, srb.SignedRecords(otherID))
+}
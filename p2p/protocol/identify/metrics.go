@@ -141,6 +141,8 @@ func (t *metricsTracer) TriggeredPushes(ev any) {
 		typ = "protocols_updated"
 	case event.EvtLocalAddressesUpdated:
 		typ = "addresses_updated"
+	case signedRecordsUpdated:
+		typ = "signed_records_updated"
 	}
 	*tags = append(*tags, typ)
 	pushesTriggered.WithLabelValues(*tags...).Inc()
@@ -1,8 +1,10 @@
 package identify
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"math"
 	"sync"
 	"time"
 
@@ -56,10 +58,12 @@ type observation struct {
 //   - have been observed at least once recently (10 minutes), because our position in the
 //     network, or network port mapppings, may have changed.
 type observedAddr struct {
-	addr       ma.Multiaddr
-	seenBy     map[string]observation // peer(observer) address -> observation info
-	lastSeen   time.Time
-	numInbound int
+	addr            ma.Multiaddr
+	seenBy          map[string]observation // peer(observer) address -> observation info
+	firstSeen       time.Time
+	lastSeen        time.Time
+	numInbound      int
+	numObservations int
 }
 
 func (oa *observedAddr) activated() bool {
@@ -70,6 +74,80 @@ func (oa *observedAddr) activated() bool {
 	return len(oa.seenBy) >= ActivationThresh
 }
 
+func (oa *observedAddr) copy() *observedAddr {
+	c := *oa
+	c.seenBy = make(map[string]observation, len(oa.seenBy))
+	for k, v := range oa.seenBy {
+		c.seenBy[k] = v
+	}
+	return &c
+}
+
+// ObservedAddr describes an address that our peers observed for us.
+type ObservedAddr struct {
+	// Addr is the observed (external) address.
+	Addr ma.Multiaddr
+	// Local is the local address the observations were made on.
+	Local ma.Multiaddr
+	// Observations is the total number of observations recorded for this address,
+	// including observations repeated on active connections.
+	Observations int
+	// ObserverGroups are the distinct observer groups (usually IP addresses) that
+	// observed this address within the last OwnObservedAddrTTL * ActivationThresh.
+	// Older observations are thinned out.
+	ObserverGroups []ma.Multiaddr
+	// InboundObservers is the number of observer groups that made the observation
+	// on an inbound connection.
+	InboundObservers int
+	FirstSeen        time.Time
+	LastSeen         time.Time
+	// Activated is true if the address has been observed by enough observer groups
+	// (ActivationThresh) to be advertised, or has been confirmed by the AddrConfirmer.
+	Activated bool
+	// Confirmation is the result of the AddrConfirmer, if any.
+	Confirmation AddrConfirmation
+	// Confidence is a value between 0 and 1. It is the fraction of the ActivationThresh
+	// distinct observer groups that recently observed the address. Confirmed addresses
+	// have a confidence of 1, refuted and expired addresses a confidence of 0.
+	Confidence float64
+}
+
+// AddrConfirmation is the result of confirming an observed address using an
+// external source.
+type AddrConfirmation int
+
+const (
+	// AddrConfirmationUnknown means that there's no information about the address.
+	AddrConfirmationUnknown AddrConfirmation = iota
+	// AddrConfirmationConfirmed means that the address was confirmed to be reachable.
+	AddrConfirmationConfirmed
+	// AddrConfirmationRefuted means that the address was found to be unreachable.
+	AddrConfirmationRefuted
+)
+
+func (c AddrConfirmation) String() string {
+	switch c {
+	case AddrConfirmationConfirmed:
+		return "confirmed"
+	case AddrConfirmationRefuted:
+		return "refuted"
+	default:
+		return "unknown"
+	}
+}
+
+// AddrConfirmer is an external source of information about the reachability of
+// observed addresses, for example the results of AutoNAT dial backs.
+//
+// Confirmed addresses are advertised even if they haven't been observed by
+// ActivationThresh observer groups, refuted addresses are never advertised.
+//
+// ConfirmAddr is never called while the ObservedAddrManager holds its lock, so
+// implementations may call back into the ObservedAddrManager or the host.
+type AddrConfirmer interface {
+	ConfirmAddr(addr ma.Multiaddr) AddrConfirmation
+}
+
 // GroupKey returns the group in which this observation belongs. Currently, an
 // observed address's group is just the address with all ports set to 0. This
 // means we can advertise the most commonly observed external ports without
@@ -126,6 +204,8 @@ type ObservedAddrManager struct {
 	currentUDPNATDeviceType  network.NATDeviceType
 	currentTCPNATDeviceType  network.NATDeviceType
 	emitNATDeviceTypeChanged event.Emitter
+
+	confirmer AddrConfirmer // may be nil, protected by mu
 }
 
 // NewObservedAddrManager returns a new address manager using
@@ -163,44 +243,158 @@ func NewObservedAddrManager(host host.Host) (*ObservedAddrManager, error) {
 // AddrsFor return all activated observed addresses associated with the given
 // (resolved) listen address.
 func (oas *ObservedAddrManager) AddrsFor(addr ma.Multiaddr) (addrs []ma.Multiaddr) {
-	oas.mu.RLock()
-	defer oas.mu.RUnlock()
+	snap := oas.snapshot(func(local string) bool { return local == string(addr.Bytes()) })
+	if len(snap.addrs) == 0 {
+		return nil
+	}
+	return snap.filter(snap.addrs[string(addr.Bytes())])
+}
 
-	if len(oas.addrs) == 0 {
+// Addrs return all activated observed addresses
+func (oas *ObservedAddrManager) Addrs() []ma.Multiaddr {
+	snap := oas.snapshot(nil)
+	if len(snap.addrs) == 0 {
 		return nil
 	}
+	return snap.filter(snap.all())
+}
 
-	observedAddrs, ok := oas.addrs[string(addr.Bytes())]
-	if !ok {
-		return
+// ObservedAddrs returns information about all addresses that our peers observed for us,
+// including the ones that haven't been activated yet.
+func (oas *ObservedAddrManager) ObservedAddrs() []ObservedAddr {
+	snap := oas.snapshot(nil)
+	now := time.Now()
+	var out []ObservedAddr
+	for local, addrs := range snap.addrs {
+		localAddr, err := ma.NewMultiaddrBytes([]byte(local))
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			out = append(out, snap.describe(a, localAddr, now))
+		}
 	}
+	return out
+}
 
-	return oas.filter(observedAddrs)
+// SetAddrConfirmer sets a source of external confirmation for observed addresses.
+func (oas *ObservedAddrManager) SetAddrConfirmer(c AddrConfirmer) {
+	oas.mu.Lock()
+	defer oas.mu.Unlock()
+	oas.confirmer = c
 }
 
-// Addrs return all activated observed addresses
-func (oas *ObservedAddrManager) Addrs() []ma.Multiaddr {
+// addrsSnapshot is a copy of the observed addresses. The AddrConfirmer is only
+// consulted on a snapshot, so that it's never called while holding the lock.
+type addrsSnapshot struct {
+	// local(internal) address -> list of observed(external) addresses
+	addrs         map[string][]*observedAddr
+	ttl           time.Duration
+	confirmer     AddrConfirmer
+	confirmations map[*observedAddr]AddrConfirmation
+}
+
+// snapshot copies the observed addresses for all local addresses selected by
+// include. If include is nil, all local addresses are included.
+func (oas *ObservedAddrManager) snapshot(include func(local string) bool) *addrsSnapshot {
 	oas.mu.RLock()
 	defer oas.mu.RUnlock()
+	return oas.snapshotUnlocked(include)
+}
 
-	if len(oas.addrs) == 0 {
-		return nil
+func (oas *ObservedAddrManager) snapshotUnlocked(include func(local string) bool) *addrsSnapshot {
+	snap := &addrsSnapshot{
+		addrs:         make(map[string][]*observedAddr, len(oas.addrs)),
+		ttl:           oas.ttl,
+		confirmer:     oas.confirmer,
+		confirmations: make(map[*observedAddr]AddrConfirmation),
+	}
+	for local, addrs := range oas.addrs {
+		if include != nil && !include(local) {
+			continue
+		}
+		copies := make([]*observedAddr, 0, len(addrs))
+		for _, a := range addrs {
+			copies = append(copies, a.copy())
+		}
+		snap.addrs[local] = copies
 	}
+	return snap
+}
 
-	var allObserved []*observedAddr
-	for _, addrs := range oas.addrs {
-		allObserved = append(allObserved, addrs...)
+func (s *addrsSnapshot) all() []*observedAddr {
+	var all []*observedAddr
+	for _, addrs := range s.addrs {
+		all = append(all, addrs...)
 	}
-	return oas.filter(allObserved)
+	return all
 }
 
-func (oas *ObservedAddrManager) filter(observedAddrs []*observedAddr) []ma.Multiaddr {
+func (s *addrsSnapshot) describe(a *observedAddr, local ma.Multiaddr, now time.Time) ObservedAddr {
+	groups := make([]ma.Multiaddr, 0, len(a.seenBy))
+	for g := range a.seenBy {
+		if addr, err := ma.NewMultiaddrBytes([]byte(g)); err == nil {
+			groups = append(groups, addr)
+		}
+	}
+	slices.SortFunc(groups, func(a, b ma.Multiaddr) int { return bytes.Compare(a.Bytes(), b.Bytes()) })
+
+	confirmation := s.confirm(a)
+	var confidence float64
+	switch {
+	case confirmation == AddrConfirmationRefuted || now.Sub(a.lastSeen) > s.ttl:
+	case confirmation == AddrConfirmationConfirmed:
+		confidence = 1
+	default:
+		confidence = math.Min(1, float64(len(a.seenBy))/float64(ActivationThresh))
+	}
+	return ObservedAddr{
+		Addr:             a.addr,
+		Local:            local,
+		Observations:     a.numObservations,
+		ObserverGroups:   groups,
+		InboundObservers: a.numInbound,
+		FirstSeen:        a.firstSeen,
+		LastSeen:         a.lastSeen,
+		Activated:        s.activated(a),
+		Confirmation:     confirmation,
+		Confidence:       confidence,
+	}
+}
+
+// confirm consults the AddrConfirmer. The result is cached for the lifetime of the snapshot.
+func (s *addrsSnapshot) confirm(a *observedAddr) AddrConfirmation {
+	if s.confirmer == nil {
+		return AddrConfirmationUnknown
+	}
+	c, ok := s.confirmations[a]
+	if !ok {
+		c = s.confirmer.ConfirmAddr(a.addr)
+		s.confirmations[a] = c
+	}
+	return c
+}
+
+// activated says if an address should be used. Unless refuted or confirmed by
+// the AddrConfirmer, this depends on the number of observers.
+func (s *addrsSnapshot) activated(a *observedAddr) bool {
+	switch s.confirm(a) {
+	case AddrConfirmationConfirmed:
+		return true
+	case AddrConfirmationRefuted:
+		return false
+	default:
+		return a.activated()
+	}
+}
+
+func (s *addrsSnapshot) filter(observedAddrs []*observedAddr) []ma.Multiaddr {
 	pmap := make(map[string][]*observedAddr)
 	now := time.Now()
 
 	for i := range observedAddrs {
 		a := observedAddrs[i]
-		if now.Sub(a.lastSeen) <= oas.ttl && a.activated() {
+		if now.Sub(a.lastSeen) <= s.ttl && s.activated(a) {
 			// group addresses by their IPX/Transport Protocol(TCP or UDP) pattern.
 			pat := a.groupKey()
 			pmap[pat] = append(pmap[pat], a)
@@ -448,11 +642,15 @@ func (oas *ObservedAddrManager) maybeRecordObservation(conn network.Conn, observ
 		defer oas.addConn(conn, observed)
 
 		oas.mu.Lock()
-		defer oas.mu.Unlock()
 		oas.recordObservationUnlocked(conn, observed)
-
+		var snap *addrsSnapshot
 		if oas.reachability == network.ReachabilityPrivate {
-			oas.emitAllNATTypes()
+			snap = oas.snapshotUnlocked(nil)
+		}
+		oas.mu.Unlock()
+
+		if snap != nil {
+			oas.emitAllNATTypes(snap)
 		}
 	}
 }
@@ -481,6 +679,7 @@ func (oas *ObservedAddrManager) recordObservationUnlocked(conn network.Conn, obs
 
 			observedAddr.seenBy[observerString] = ob
 			observedAddr.lastSeen = now
+			observedAddr.numObservations++
 			return
 		}
 	}
@@ -491,7 +690,9 @@ func (oas *ObservedAddrManager) recordObservationUnlocked(conn network.Conn, obs
 		seenBy: map[string]observation{
 			observerString: ob,
 		},
-		lastSeen: now,
+		firstSeen:       now,
+		lastSeen:        now,
+		numObservations: 1,
 	}
 	if ob.inbound {
 		oa.numInbound++
@@ -510,18 +711,13 @@ func (oas *ObservedAddrManager) recordObservationUnlocked(conn network.Conn, obs
 //
 // Please see the documentation on the enumerations for `network.NATDeviceType` for more details about these NAT Device types
 // and how they relate to NAT traversal via Hole Punching.
-func (oas *ObservedAddrManager) emitAllNATTypes() {
-	var allObserved []*observedAddr
-	for _, addrs := range oas.addrs {
-		allObserved = append(allObserved, addrs...)
-	}
-
-	hasChanged, natType := oas.emitSpecificNATType(allObserved, ma.P_TCP, network.NATTransportTCP, oas.currentTCPNATDeviceType)
+func (oas *ObservedAddrManager) emitAllNATTypes(snap *addrsSnapshot) {
+	hasChanged, natType := oas.emitSpecificNATType(snap, ma.P_TCP, network.NATTransportTCP, oas.currentTCPNATDeviceType)
 	if hasChanged {
 		oas.currentTCPNATDeviceType = natType
 	}
 
-	hasChanged, natType = oas.emitSpecificNATType(allObserved, ma.P_UDP, network.NATTransportUDP, oas.currentUDPNATDeviceType)
+	hasChanged, natType = oas.emitSpecificNATType(snap, ma.P_UDP, network.NATTransportUDP, oas.currentUDPNATDeviceType)
 	if hasChanged {
 		oas.currentUDPNATDeviceType = natType
 	}
@@ -529,54 +725,92 @@ func (oas *ObservedAddrManager) emitAllNATTypes() {
 
 // returns true along with the new NAT device type if the NAT device type for the given protocol has changed.
 // returns false otherwise.
-func (oas *ObservedAddrManager) emitSpecificNATType(addrs []*observedAddr, protoCode int, transportProto network.NATTransportProtocol,
+func (oas *ObservedAddrManager) emitSpecificNATType(snap *addrsSnapshot, protoCode int, transportProto network.NATTransportProtocol,
 	currentNATType network.NATDeviceType) (bool, network.NATDeviceType) {
+	natType, _ := snap.classifyNAT(snap.all(), protoCode)
+	if natType == network.NATDeviceTypeUnknown || natType == currentNATType {
+		return false, 0
+	}
+	oas.emitNATDeviceTypeChanged.Emit(event.EvtNATDeviceTypeChanged{
+		TransportProtocol: transportProto,
+		NatDeviceType:     natType,
+	})
+	return true, natType
+}
+
+// classifyNAT determines the NAT device type for the given transport protocol,
+// and returns the observed addresses this classification is based on.
+func (s *addrsSnapshot) classifyNAT(addrs []*observedAddr, protoCode int) (network.NATDeviceType, []*observedAddr) {
 	now := time.Now()
 	seenBy := make(map[string]struct{})
-	cnt := 0
+	var activated, outboundOnly []*observedAddr
 
 	for _, oa := range addrs {
 		_, err := oa.addr.ValueForProtocol(protoCode)
 		if err != nil {
 			continue
 		}
+		if now.Sub(oa.lastSeen) > s.ttl {
+			continue
+		}
 
 		// if we have an activated addresses, it's a Cone NAT.
-		if now.Sub(oa.lastSeen) <= oas.ttl && oa.activated() {
-			if currentNATType != network.NATDeviceTypeCone {
-				oas.emitNATDeviceTypeChanged.Emit(event.EvtNATDeviceTypeChanged{
-					TransportProtocol: transportProto,
-					NatDeviceType:     network.NATDeviceTypeCone,
-				})
-				return true, network.NATDeviceTypeCone
-			}
-
-			// our current NAT Device Type is already CONE, nothing to do here.
-			return false, 0
+		if s.activated(oa) {
+			activated = append(activated, oa)
+			continue
 		}
 
 		// An observed address on an outbound connection that has ONLY been seen by one peer
-		if now.Sub(oa.lastSeen) <= oas.ttl && oa.numInbound == 0 && len(oa.seenBy) == 1 {
-			cnt++
+		if oa.numInbound == 0 && len(oa.seenBy) == 1 {
+			outboundOnly = append(outboundOnly, oa)
 			for s := range oa.seenBy {
 				seenBy[s] = struct{}{}
 			}
 		}
 	}
 
+	if len(activated) > 0 {
+		return network.NATDeviceTypeCone, activated
+	}
 	// If four different peers observe a different address for us on each of four outbound connections, we
 	// are MOST probably behind a Symmetric NAT.
-	if cnt >= ActivationThresh && len(seenBy) >= ActivationThresh {
-		if currentNATType != network.NATDeviceTypeSymmetric {
-			oas.emitNATDeviceTypeChanged.Emit(event.EvtNATDeviceTypeChanged{
-				TransportProtocol: transportProto,
-				NatDeviceType:     network.NATDeviceTypeSymmetric,
-			})
-			return true, network.NATDeviceTypeSymmetric
-		}
+	if len(outboundOnly) >= ActivationThresh && len(seenBy) >= ActivationThresh {
+		return network.NATDeviceTypeSymmetric, outboundOnly
+	}
+	return network.NATDeviceTypeUnknown, nil
+}
+
+// NATDeviceType returns the NAT device type inferred from the current observations for the
+// given transport protocol, together with the observed addresses that led to this inference:
+//   - for a Cone NAT, the activated addresses
+//   - for a Symmetric NAT, the addresses that were each only observed by a single peer on an
+//     outbound connection
+func (oas *ObservedAddrManager) NATDeviceType(proto network.NATTransportProtocol) (network.NATDeviceType, []ObservedAddr) {
+	protoCode := ma.P_TCP
+	if proto == network.NATTransportUDP {
+		protoCode = ma.P_UDP
 	}
 
-	return false, 0
+	snap := oas.snapshot(nil)
+	var allObserved []*observedAddr
+	locals := make(map[*observedAddr]string)
+	for local, addrs := range snap.addrs {
+		allObserved = append(allObserved, addrs...)
+		for _, a := range addrs {
+			locals[a] = local
+		}
+	}
+	natType, evidence := snap.classifyNAT(allObserved, protoCode)
+	now := time.Now()
+	out := make([]ObservedAddr, 0, len(evidence))
+	for _, a := range evidence {
+		local, err := ma.NewMultiaddrBytes([]byte(locals[a]))
+		if err != nil {
+			continue
+		}
+		out = append(out, snap.describe(a, local, now))
+	}
+	return natType, out
 }
 
 func (oas *ObservedAddrManager) Close() error {
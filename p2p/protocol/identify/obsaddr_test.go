@@ -2,6 +2,8 @@ package identify_test
 
 import (
 	"crypto/rand"
+	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -434,3 +436,128 @@ func TestObserveWebtransport(t *testing.T) {
 	require.Equal(t, 1, len(harness.oas.Addrs()))
 	require.Equal(t, "/ip4/1.2.3.4/udp/1231/quic-v1/webtransport", harness.oas.Addrs()[0].String())
 }
+
+func TestObservedAddrsDetails(t *testing.T) {
+	harness := newHarness(t)
+	observed := ma.StringCast("/ip4/1.2.3.4/tcp/1231")
+
+	p1 := harness.add(ma.StringCast("/ip4/1.2.3.6/tcp/1236"))
+	p2 := harness.add(ma.StringCast("/ip4/1.2.3.7/tcp/1237"))
+	harness.observe(observed, p1)
+	harness.observeInbound(observed, p2)
+
+	addrs := harness.oas.ObservedAddrs()
+	require.Len(t, addrs, 1)
+	oa := addrs[0]
+	require.True(t, oa.Addr.Equal(observed))
+	require.True(t, oa.Local.Equal(ma.StringCast("/ip4/127.0.0.1/tcp/10086")))
+	require.Equal(t, 2, oa.Observations)
+	require.Len(t, oa.ObserverGroups, 2)
+	require.Equal(t, 1, oa.InboundObservers)
+	require.False(t, oa.Activated)
+	require.Equal(t, identify.AddrConfirmationUnknown, oa.Confirmation)
+	require.InDelta(t, 2/float64(identify.ActivationThresh), oa.Confidence, 1e-9)
+	require.False(t, oa.FirstSeen.After(oa.LastSeen))
+	require.Empty(t, harness.oas.Addrs())
+}
+
+type mockConfirmer map[string]identify.AddrConfirmation
+
+func (c mockConfirmer) ConfirmAddr(a ma.Multiaddr) identify.AddrConfirmation {
+	return c[string(a.Bytes())]
+}
+
+func TestObservedAddrConfirmer(t *testing.T) {
+	harness := newHarness(t)
+	confirmed := ma.StringCast("/ip4/1.2.3.4/tcp/1231")
+	refuted := ma.StringCast("/ip4/1.2.3.5/tcp/1232")
+	harness.oas.SetAddrConfirmer(mockConfirmer{
+		string(confirmed.Bytes()): identify.AddrConfirmationConfirmed,
+		string(refuted.Bytes()):   identify.AddrConfirmationRefuted,
+	})
+
+	// a single observation is enough for a confirmed address
+	harness.observe(confirmed, harness.add(ma.StringCast("/ip4/1.2.3.6/tcp/1236")))
+	require.Equal(t, []ma.Multiaddr{confirmed}, harness.oas.Addrs())
+
+	// a refuted address is never used, no matter how many peers observe it
+	for i := 0; i < identify.ActivationThresh+1; i++ {
+		harness.observe(refuted, harness.add(ma.StringCast(fmt.Sprintf("/ip4/1.2.4.%d/tcp/1236", i))))
+	}
+	require.Equal(t, []ma.Multiaddr{confirmed}, harness.oas.Addrs())
+
+	for _, oa := range harness.oas.ObservedAddrs() {
+		switch {
+		case oa.Addr.Equal(confirmed):
+			require.True(t, oa.Activated)
+			require.Equal(t, 1.0, oa.Confidence)
+		case oa.Addr.Equal(refuted):
+			require.False(t, oa.Activated)
+			require.Equal(t, identify.AddrConfirmationRefuted, oa.Confirmation)
+			require.Zero(t, oa.Confidence)
+		default:
+			t.Fatalf("unexpected address: %s", oa.Addr)
+		}
+	}
+}
+
+// reentrantConfirmer calls back into the ObservedAddrManager.
+type reentrantConfirmer struct {
+	oas   *identify.ObservedAddrManager
+	calls atomic.Int32
+}
+
+func (c *reentrantConfirmer) ConfirmAddr(a ma.Multiaddr) identify.AddrConfirmation {
+	c.calls.Add(1)
+	c.oas.TTL()
+	return identify.AddrConfirmationConfirmed
+}
+
+func TestObservedAddrConfirmerReentrant(t *testing.T) {
+	harness := newHarness(t)
+	emitter, err := harness.host.EventBus().Emitter(new(event.EvtLocalReachabilityChanged), eventbus.Stateful)
+	require.NoError(t, err)
+	require.NoError(t, emitter.Emit(event.EvtLocalReachabilityChanged{Reachability: network.ReachabilityPrivate}))
+	confirmer := &reentrantConfirmer{oas: harness.oas}
+	harness.oas.SetAddrConfirmer(confirmer)
+
+	observed := ma.StringCast("/ip4/1.2.3.4/tcp/1231")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// classifying the NAT type on the worker consults the confirmer
+		harness.observe(observed, harness.add(ma.StringCast("/ip4/1.2.3.6/tcp/1236")))
+		require.Equal(t, []ma.Multiaddr{observed}, harness.oas.Addrs())
+		harness.oas.NATDeviceType(network.NATTransportTCP)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock")
+	}
+	require.NotZero(t, confirmer.calls.Load())
+}
+
+func TestNATDeviceTypeEvidence(t *testing.T) {
+	harness := newHarness(t)
+	natType, evidence := harness.oas.NATDeviceType(network.NATTransportTCP)
+	require.Equal(t, network.NATDeviceTypeUnknown, natType)
+	require.Empty(t, evidence)
+
+	for i := 0; i < identify.ActivationThresh; i++ {
+		observer := harness.add(ma.StringCast(fmt.Sprintf("/ip4/1.2.3.%d/tcp/1236", 10+i)))
+		harness.observe(ma.StringCast(fmt.Sprintf("/ip4/1.2.3.4/tcp/%d", 1231+i)), observer)
+	}
+
+	natType, evidence = harness.oas.NATDeviceType(network.NATTransportTCP)
+	require.Equal(t, network.NATDeviceTypeSymmetric, natType)
+	require.Len(t, evidence, identify.ActivationThresh)
+	for _, oa := range evidence {
+		require.Len(t, oa.ObserverGroups, 1)
+		require.Zero(t, oa.InboundObservers)
+	}
+
+	natType, evidence = harness.oas.NATDeviceType(network.NATTransportUDP)
+	require.Equal(t, network.NATDeviceTypeUnknown, natType)
+	require.Empty(t, evidence)
+}
@@ -5,6 +5,10 @@ type config struct {
 	userAgent               string
 	disableSignedPeerRecord bool
 	metricsTracer           MetricsTracer
+	deltaPush               bool
+	maxMessageSize          int
+	maxProtocols            int
+	maxAddrs                int
 }
 
 // Option is an option function for identify.
@@ -38,3 +42,38 @@ func WithMetricsTracer(tr MetricsTracer) Option {
 		cfg.metricsTracer = tr
 	}
 }
+
+// EnableDeltaPush enables the delta push protocol. When the remote peer supports it,
+// pushes only contain the protocols and addresses that were added or removed since
+// the last message sent to that peer. If the peer doesn't support delta pushes, or
+// the delta can't be applied, a full identify push is sent.
+func EnableDeltaPush() Option {
+	return func(cfg *config) {
+		cfg.deltaPush = true
+	}
+}
+
+// WithMaxMessageSize sets the maximum size of a single incoming identify message.
+// Identify responses may be split over multiple messages.
+// Defaults to 8 KiB.
+func WithMaxMessageSize(n int) Option {
+	return func(cfg *config) {
+		cfg.maxMessageSize = n
+	}
+}
+
+// WithMaxProtocols sets the maximum number of protocols accepted from a peer.
+// Any protocols beyond this limit are ignored. By default, there's no limit.
+func WithMaxProtocols(n int) Option {
+	return func(cfg *config) {
+		cfg.maxProtocols = n
+	}
+}
+
+// WithMaxAddrs sets the maximum number of addresses accepted from a peer.
+// Any addresses beyond this limit are ignored. By default, there's no limit.
+func WithMaxAddrs(n int) Option {
+	return func(cfg *config) {
+		cfg.maxAddrs = n
+	}
+}
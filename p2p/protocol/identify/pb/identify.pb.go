@@ -48,6 +48,19 @@ type Identify struct {
 	// see github.com/libp2p/go-libp2p/core/record/pb/envelope.proto and
 	// github.com/libp2p/go-libp2p/core/peer/pb/peer_record.proto for message definitions.
 	SignedPeerRecord []byte `protobuf:"bytes,8,opt,name=signedPeerRecord" json:"signedPeerRecord,omitempty"`
+	// seq is the sequence number of the sender's state described by this message.
+	// It is only used by peers that support delta pushes, as the base for
+	// subsequent Delta messages.
+	Seq *uint64 `protobuf:"varint,9,opt,name=seq" json:"seq,omitempty"`
+	// keySuccessionRecords contain serialized SignedEnvelopes containing KeySuccessionRecords,
+	// proving that the sender is the successor of other peers that replaced their keys.
+	// see github.com/libp2p/go-libp2p/core/peer/pb/key_succession.proto for the message definition.
+	KeySuccessionRecords [][]byte `protobuf:"bytes,10,rep,name=keySuccessionRecords" json:"keySuccessionRecords,omitempty"`
+	// signedRecords contain serialized SignedEnvelopes containing application-defined records,
+	// signed by the sending node, e.g. capability advertisements. Receivers only process records
+	// of the record types they know about.
+	// see github.com/libp2p/go-libp2p/core/record/pb/envelope.proto for the message definition.
+	SignedRecords [][]byte `protobuf:"bytes,11,rep,name=signedRecords" json:"signedRecords,omitempty"`
 }
 
 func (x *Identify) Reset() {
@@ -131,12 +144,142 @@ func (x *Identify) GetSignedPeerRecord() []byte {
 	return nil
 }
 
+func (x *Identify) GetSeq() uint64 {
+	if x != nil && x.Seq != nil {
+		return *x.Seq
+	}
+	return 0
+}
+
+func (x *Identify) GetKeySuccessionRecords() [][]byte {
+	if x != nil {
+		return x.KeySuccessionRecords
+	}
+	return nil
+}
+
+func (x *Identify) GetSignedRecords() [][]byte {
+	if x != nil {
+		return x.SignedRecords
+	}
+	return nil
+}
+
+// Delta describes the changes to the sender's state since the message with
+// sequence number baseSeq, and is sent on the delta push protocol.
+type Delta struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// baseSeq is the sequence number of the state the changes apply to.
+	BaseSeq *uint64 `protobuf:"varint,1,opt,name=baseSeq" json:"baseSeq,omitempty"`
+	// seq is the sequence number of the resulting state.
+	Seq                *uint64  `protobuf:"varint,2,opt,name=seq" json:"seq,omitempty"`
+	AddedProtocols     []string `protobuf:"bytes,3,rep,name=addedProtocols" json:"addedProtocols,omitempty"`
+	RemovedProtocols   []string `protobuf:"bytes,4,rep,name=removedProtocols" json:"removedProtocols,omitempty"`
+	AddedListenAddrs   [][]byte `protobuf:"bytes,5,rep,name=addedListenAddrs" json:"addedListenAddrs,omitempty"`
+	RemovedListenAddrs [][]byte `protobuf:"bytes,6,rep,name=removedListenAddrs" json:"removedListenAddrs,omitempty"`
+	// signedPeerRecord is only set if the signed peer record changed.
+	SignedPeerRecord []byte `protobuf:"bytes,7,opt,name=signedPeerRecord" json:"signedPeerRecord,omitempty"`
+	// signedRecords contains the signed records that were added or updated.
+	SignedRecords [][]byte `protobuf:"bytes,8,rep,name=signedRecords" json:"signedRecords,omitempty"`
+}
+
+func (x *Delta) Reset() {
+	*x = Delta{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pb_identify_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Delta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Delta) ProtoMessage() {}
+
+func (x *Delta) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_identify_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Delta.ProtoReflect.Descriptor instead.
+func (*Delta) Descriptor() ([]byte, []int) {
+	return file_pb_identify_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Delta) GetBaseSeq() uint64 {
+	if x != nil && x.BaseSeq != nil {
+		return *x.BaseSeq
+	}
+	return 0
+}
+
+func (x *Delta) GetSeq() uint64 {
+	if x != nil && x.Seq != nil {
+		return *x.Seq
+	}
+	return 0
+}
+
+func (x *Delta) GetAddedProtocols() []string {
+	if x != nil {
+		return x.AddedProtocols
+	}
+	return nil
+}
+
+func (x *Delta) GetRemovedProtocols() []string {
+	if x != nil {
+		return x.RemovedProtocols
+	}
+	return nil
+}
+
+func (x *Delta) GetAddedListenAddrs() [][]byte {
+	if x != nil {
+		return x.AddedListenAddrs
+	}
+	return nil
+}
+
+func (x *Delta) GetRemovedListenAddrs() [][]byte {
+	if x != nil {
+		return x.RemovedListenAddrs
+	}
+	return nil
+}
+
+func (x *Delta) GetSignedPeerRecord() []byte {
+	if x != nil {
+		return x.SignedPeerRecord
+	}
+	return nil
+}
+
+func (x *Delta) GetSignedRecords() [][]byte {
+	if x != nil {
+		return x.SignedRecords
+	}
+	return nil
+}
+
 var File_pb_identify_proto protoreflect.FileDescriptor
 
 var file_pb_identify_proto_rawDesc = []byte{
 	0x0a, 0x11, 0x70, 0x62, 0x2f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x79, 0x2e, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x79, 0x2e, 0x70, 0x62,
-	0x22, 0x86, 0x02, 0x0a, 0x08, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x79, 0x12, 0x28, 0x0a,
+	0x22, 0xf2, 0x02, 0x0a, 0x08, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x79, 0x12, 0x28, 0x0a,
 	0x0f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
 	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
 	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x61, 0x67, 0x65, 0x6e, 0x74,
@@ -152,7 +295,33 @@ var file_pb_identify_proto_rawDesc = []byte{
 	0x28, 0x09, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73, 0x12, 0x2a, 0x0a,
 	0x10, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x63, 0x6f, 0x72,
 	0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x10, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x50,
-	0x65, 0x65, 0x72, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64,
+	0x65, 0x65, 0x72, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x32, 0x0a, 0x14, 0x6b,
+	0x65, 0x79, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x63, 0x6f,
+	0x72, 0x64, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x14, 0x6b, 0x65, 0x79, 0x53, 0x75,
+	0x63, 0x63, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12,
+	0x24, 0x0a, 0x0d, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73,
+	0x18, 0x0b, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0d, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x73, 0x22, 0xb5, 0x02, 0x0a, 0x05, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x12,
+	0x18, 0x0a, 0x07, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x71, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x07, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x71, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x26, 0x0a, 0x0e, 0x61,
+	0x64, 0x64, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x64, 0x64, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x6f, 0x6c, 0x73, 0x12, 0x2a, 0x0a, 0x10, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x50, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x10, 0x72,
+	0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73, 0x12,
+	0x2a, 0x0a, 0x10, 0x61, 0x64, 0x64, 0x65, 0x64, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x41, 0x64,
+	0x64, 0x72, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x10, 0x61, 0x64, 0x64, 0x65, 0x64,
+	0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x41, 0x64, 0x64, 0x72, 0x73, 0x12, 0x2e, 0x0a, 0x12, 0x72,
+	0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x41, 0x64, 0x64, 0x72,
+	0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x12, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64,
+	0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x41, 0x64, 0x64, 0x72, 0x73, 0x12, 0x2a, 0x0a, 0x10, 0x73,
+	0x69, 0x67, 0x6e, 0x65, 0x64, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x10, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x50, 0x65, 0x65,
+	0x72, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x24, 0x0a, 0x0d, 0x73, 0x69, 0x67, 0x6e, 0x65,
+	0x64, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0d,
+	0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73,
 }
 
 var (
@@ -167,9 +336,10 @@ func file_pb_identify_proto_rawDescGZIP() []byte {
 	return file_pb_identify_proto_rawDescData
 }
 
-var file_pb_identify_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_pb_identify_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_pb_identify_proto_goTypes = []interface{}{
 	(*Identify)(nil), // 0: identify.pb.Identify
+	(*Delta)(nil),    // 1: identify.pb.Delta
 }
 var file_pb_identify_proto_depIdxs = []int32{
 	0, // [0:0] is the sub-list for method output_type
@@ -197,6 +367,18 @@ func file_pb_identify_proto_init() {
 				return nil
 			}
 		}
+		file_pb_identify_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Delta); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -204,7 +386,7 @@ func file_pb_identify_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pb_identify_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
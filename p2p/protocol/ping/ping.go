@@ -115,6 +115,10 @@ func Ping(ctx context.Context, h host.Host, p peer.ID) <-chan Result {
 	if err != nil {
 		return pingError(err)
 	}
+	// Prioritize pings, so that the measured RTT isn't inflated by other streams.
+	if ps, ok := s.(network.PrioritizedStream); ok {
+		_ = ps.SetPriority(network.StreamPriorityHigh)
+	}
 
 	if err := s.Scope().SetService(ServiceName); err != nil {
 		log.Debugf("error attaching stream to ping service: %s", err)
@@ -78,6 +78,8 @@ func (s *secureSession) runHandshake(ctx context.Context) (err error) {
 	hbuf := pool.Get(2 << 10)
 	defer pool.Put(hbuf)
 
+	hc, _ := sec.GetHandshakeCredentials(ctx)
+
 	if s.initiator {
 		// stage 0 //
 		// Handshake Msg Len = len(DH ephemeral key)
@@ -94,6 +96,9 @@ func (s *secureSession) runHandshake(ctx context.Context) (err error) {
 		if err != nil {
 			return err
 		}
+		if err := s.handleRemoteCredential(hc, rcvdEd); err != nil {
+			return err
+		}
 		if s.initiatorEarlyDataHandler != nil {
 			if err := s.initiatorEarlyDataHandler.Received(ctx, s.insecureConn, rcvdEd); err != nil {
 				return err
@@ -106,7 +111,7 @@ func (s *secureSession) runHandshake(ctx context.Context) (err error) {
 		if s.initiatorEarlyDataHandler != nil {
 			ed = s.initiatorEarlyDataHandler.Send(ctx, s.insecureConn, s.remoteID)
 		}
-		payload, err := s.generateHandshakePayload(kp, ed)
+		payload, err := s.generateHandshakePayload(kp, addLocalCredential(hc, ed))
 		if err != nil {
 			return err
 		}
@@ -127,7 +132,7 @@ func (s *secureSession) runHandshake(ctx context.Context) (err error) {
 		if s.responderEarlyDataHandler != nil {
 			ed = s.responderEarlyDataHandler.Send(ctx, s.insecureConn, s.remoteID)
 		}
-		payload, err := s.generateHandshakePayload(kp, ed)
+		payload, err := s.generateHandshakePayload(kp, addLocalCredential(hc, ed))
 		if err != nil {
 			return err
 		}
@@ -144,6 +149,9 @@ func (s *secureSession) runHandshake(ctx context.Context) (err error) {
 		if err != nil {
 			return err
 		}
+		if err := s.handleRemoteCredential(hc, rcvdEd); err != nil {
+			return err
+		}
 		if s.responderEarlyDataHandler != nil {
 			if err := s.responderEarlyDataHandler.Received(ctx, s.insecureConn, rcvdEd); err != nil {
 				return err
@@ -295,3 +303,30 @@ func (s *secureSession) handleRemoteHandshakePayload(payload []byte, remoteStati
 	s.remoteKey = remotePubKey
 	return nhp.Extensions, nil
 }
+
+// addLocalCredential adds the local handshake credential to the extensions, if there is one.
+func addLocalCredential(hc sec.HandshakeCredentials, ed *pb.NoiseExtensions) *pb.NoiseExtensions {
+	if hc == nil {
+		return ed
+	}
+	cred := hc.LocalCredential()
+	if cred == nil {
+		return ed
+	}
+	if ed == nil {
+		ed = &pb.NoiseExtensions{}
+	}
+	ed.Credential = cred
+	return ed
+}
+
+// handleRemoteCredential passes the credential received from the authenticated remote peer to hc.
+func (s *secureSession) handleRemoteCredential(hc sec.HandshakeCredentials, ed *pb.NoiseExtensions) error {
+	if hc == nil {
+		return nil
+	}
+	if err := hc.HandleRemoteCredential(s.remoteID, ed.GetCredential()); err != nil {
+		return fmt.Errorf("handshake credential rejected: %w", err)
+	}
+	return nil
+}
@@ -714,3 +714,67 @@ func TestHandshakeWithTransportEarlyData(t *testing.T) {
 		})
 	}
 }
+
+type testCredentials struct {
+	local    []byte
+	received chan []byte
+	err      error
+}
+
+func (c *testCredentials) LocalCredential() []byte { return c.local }
+
+func (c *testCredentials) HandleRemoteCredential(_ peer.ID, cred []byte) error {
+	c.received <- cred
+	return c.err
+}
+
+func TestHandshakeCredentials(t *testing.T) {
+	handshake := func(t *testing.T, client, server *testCredentials) (clientErr, serverErr error) {
+		t.Helper()
+		initTransport := newTestTransport(t, crypto.Ed25519, 2048)
+		respTransport := newTestTransport(t, crypto.Ed25519, 2048)
+		initConn, respConn := newConnPair(t)
+
+		errChan := make(chan error, 1)
+		go func() {
+			conn, err := respTransport.SecureInbound(sec.WithHandshakeCredentials(context.Background(), server), respConn, "")
+			if err == nil {
+				conn.Close()
+			}
+			errChan <- err
+		}()
+		conn, clientErr := initTransport.SecureOutbound(sec.WithHandshakeCredentials(context.Background(), client), initConn, respTransport.localID)
+		if clientErr == nil {
+			defer conn.Close()
+		} else {
+			initConn.Close()
+		}
+		return clientErr, <-errChan
+	}
+
+	t.Run("exchanged", func(t *testing.T) {
+		client := &testCredentials{local: []byte("client"), received: make(chan []byte, 1)}
+		server := &testCredentials{local: []byte("server"), received: make(chan []byte, 1)}
+		clientErr, serverErr := handshake(t, client, server)
+		require.NoError(t, clientErr)
+		require.NoError(t, serverErr)
+		require.Equal(t, []byte("server"), <-client.received)
+		require.Equal(t, []byte("client"), <-server.received)
+	})
+
+	t.Run("no local credential", func(t *testing.T) {
+		client := &testCredentials{received: make(chan []byte, 1)}
+		server := &testCredentials{local: []byte("server"), received: make(chan []byte, 1)}
+		clientErr, serverErr := handshake(t, client, server)
+		require.NoError(t, clientErr)
+		require.NoError(t, serverErr)
+		require.Nil(t, <-server.received)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		client := &testCredentials{local: []byte("client"), received: make(chan []byte, 1)}
+		server := &testCredentials{local: []byte("server"), received: make(chan []byte, 1), err: errors.New("not a member")}
+		_, serverErr := handshake(t, client, server)
+		require.ErrorContains(t, serverErr, "not a member")
+	})
+}
@@ -0,0 +1,56 @@
+package libp2ptls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/sec"
+)
+
+// credentialExtensionID is the ID of the extension carrying a handshake credential (see sec.HandshakeCredentials).
+// This extension is not part of the libp2p TLS specification.
+var credentialExtensionID = getPrefixedExtensionID([]int{1, 3})
+
+// certificateWithCredential returns a certificate carrying the credential.
+// The certificate for the most recently used credential is cached.
+func (i *Identity) certificateWithCredential(cred []byte) (*tls.Certificate, error) {
+	i.credCert.Lock()
+	defer i.credCert.Unlock()
+
+	if i.credCert.cert != nil && bytes.Equal(i.credCert.credential, cred) {
+		return i.credCert.cert, nil
+	}
+	tmpl := i.certTemplate
+	tmpl.ExtraExtensions = append(append([]pkix.Extension{}, i.certTemplate.ExtraExtensions...), pkix.Extension{Id: credentialExtensionID, Value: cred})
+	cert, err := keyToCertificate(i.privKey, &tmpl)
+	if err != nil {
+		return nil, err
+	}
+	i.credCert.credential = append([]byte{}, cred...)
+	i.credCert.cert = cert
+	return cert, nil
+}
+
+// handleRemoteCredential passes the credential contained in the peer's certificate to hc.
+func handleRemoteCredential(hc sec.HandshakeCredentials, cert *x509.Certificate, pubKey ic.PubKey) error {
+	p, err := peer.IDFromPublicKey(pubKey)
+	if err != nil {
+		return err
+	}
+	var cred []byte
+	for _, ext := range cert.Extensions {
+		if extensionIDEqual(ext.Id, credentialExtensionID) {
+			cred = ext.Value
+			break
+		}
+	}
+	if err := hc.HandleRemoteCredential(p, cred); err != nil {
+		return fmt.Errorf("handshake credential rejected: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package libp2ptls
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCredentials struct {
+	local    []byte
+	received chan []byte
+	from     chan peer.ID
+	err      error
+}
+
+func newTestCredentials(local []byte) *testCredentials {
+	return &testCredentials{
+		local:    local,
+		received: make(chan []byte, 1),
+		from:     make(chan peer.ID, 1),
+	}
+}
+
+func (c *testCredentials) LocalCredential() []byte { return c.local }
+
+func (c *testCredentials) HandleRemoteCredential(p peer.ID, cred []byte) error {
+	c.from <- p
+	c.received <- cred
+	return c.err
+}
+
+func TestHandshakeCredentials(t *testing.T) {
+	clientPeer, clientKey := createPeer(t)
+	serverPeer, serverKey := createPeer(t)
+	clientID, err := NewIdentity(clientKey)
+	require.NoError(t, err)
+	serverID, err := NewIdentity(serverKey)
+	require.NoError(t, err)
+
+	handshake := func(t *testing.T, client, server *testCredentials) (clientErr, serverErr error) {
+		t.Helper()
+		clientConf, _ := clientID.ConfigForPeerWithCredentials(serverPeer, client)
+		serverConf, _ := serverID.ConfigForPeerWithCredentials("", server)
+		return handshakeConfigs(t, clientConf, serverConf)
+	}
+
+	t.Run("exchanged", func(t *testing.T) {
+		client := newTestCredentials([]byte("client"))
+		server := newTestCredentials([]byte("server"))
+		clientErr, serverErr := handshake(t, client, server)
+		require.NoError(t, clientErr)
+		require.NoError(t, serverErr)
+		require.Equal(t, []byte("server"), <-client.received)
+		require.Equal(t, serverPeer, <-client.from)
+		require.Equal(t, []byte("client"), <-server.received)
+		require.Equal(t, clientPeer, <-server.from)
+	})
+
+	t.Run("credential changes", func(t *testing.T) {
+		server := newTestCredentials([]byte("server"))
+		for _, cred := range []string{"foo", "bar"} {
+			client := newTestCredentials([]byte(cred))
+			clientErr, serverErr := handshake(t, client, server)
+			require.NoError(t, clientErr)
+			require.NoError(t, serverErr)
+			require.Equal(t, []byte(cred), <-server.received)
+			<-server.from
+		}
+	})
+
+	t.Run("no local credential", func(t *testing.T) {
+		client := newTestCredentials(nil)
+		server := newTestCredentials([]byte("server"))
+		clientErr, serverErr := handshake(t, client, server)
+		require.NoError(t, clientErr)
+		require.NoError(t, serverErr)
+		require.Nil(t, <-server.received)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		client := newTestCredentials([]byte("client"))
+		server := newTestCredentials([]byte("server"))
+		server.err = errors.New("not a member")
+		_, serverErr := handshake(t, client, server)
+		require.ErrorContains(t, serverErr, "not a member")
+	})
+}
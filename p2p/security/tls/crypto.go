@@ -14,10 +14,12 @@ import (
 	"math/big"
 	"os"
 	"runtime/debug"
+	"sync"
 	"time"
 
 	ic "github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/pnet"
 	"github.com/libp2p/go-libp2p/core/sec"
 )
 
@@ -36,11 +38,22 @@ type signedKey struct {
 // Identity is used to secure connections
 type Identity struct {
 	config tls.Config
+	psks   pnet.PSKSet
+
+	// privKey and certTemplate are used to generate certificates carrying a handshake credential.
+	privKey      ic.PrivKey
+	certTemplate x509.Certificate
+	credCert     struct {
+		sync.Mutex
+		credential []byte
+		cert       *tls.Certificate
+	}
 }
 
 // IdentityConfig is used to configure an Identity
 type IdentityConfig struct {
 	CertTemplate *x509.Certificate
+	PSKs         pnet.PSKSet
 }
 
 // IdentityOption transforms an IdentityConfig to apply optional settings.
@@ -68,11 +81,26 @@ func NewIdentity(privKey ic.PrivKey, opts ...IdentityOption) (*Identity, error)
 		}
 	}
 
+	if len(config.PSKs) > 0 {
+		ext, err := generatePSKExtension(config.PSKs, privKey.GetPublic())
+		if err != nil {
+			return nil, err
+		}
+		config.CertTemplate.ExtraExtensions = append(config.CertTemplate.ExtraExtensions, ext)
+	}
+
+	// keyToCertificate modifies the template, so save a copy first
+	certTemplate := *config.CertTemplate
+	certTemplate.ExtraExtensions = append([]pkix.Extension(nil), config.CertTemplate.ExtraExtensions...)
+
 	cert, err := keyToCertificate(privKey, config.CertTemplate)
 	if err != nil {
 		return nil, err
 	}
 	return &Identity{
+		psks:         config.PSKs,
+		privKey:      privKey,
+		certTemplate: certTemplate,
 		config: tls.Config{
 			MinVersion:         tls.VersionTLS13,
 			InsecureSkipVerify: true, // This is not insecure here. We will verify the cert chain ourselves.
@@ -94,11 +122,51 @@ func NewIdentity(privKey ic.PrivKey, opts ...IdentityOption) (*Identity, error)
 // It should be used to create a new tls.Config before securing either an
 // incoming or outgoing connection.
 func (i *Identity) ConfigForPeer(remote peer.ID) (*tls.Config, <-chan ic.PubKey) {
+	return i.ConfigForPeerWithCredentials(remote, nil)
+}
+
+// ConfigForPeerWithCredentials is like ConfigForPeer, but additionally exchanges handshake
+// credentials with the peer (see sec.HandshakeCredentials). If hc is nil, it is equivalent to
+// ConfigForPeer.
+func (i *Identity) ConfigForPeerWithCredentials(remote peer.ID, hc sec.HandshakeCredentials) (*tls.Config, <-chan ic.PubKey) {
 	keyCh := make(chan ic.PubKey, 1)
 	// We need to check the peer ID in the VerifyPeerCertificate callback.
 	// The tls.Config it is also used for listening, and we might also have concurrent dials.
 	// Clone it so we can check for the specific peer ID we're dialing here.
 	conf := i.config.Clone()
+	verify := func(chain []*x509.Certificate) error {
+		pubKey, err := PubKeyFromCertChain(chain)
+		if err != nil {
+			return err
+		}
+		if remote != "" && !remote.MatchesPublicKey(pubKey) {
+			peerID, err := peer.IDFromPublicKey(pubKey)
+			if err != nil {
+				peerID = peer.ID(fmt.Sprintf("(not determined: %s)", err.Error()))
+			}
+			return sec.ErrPeerIDMismatch{Expected: remote, Actual: peerID}
+		}
+		if len(i.psks) > 0 {
+			if err := verifyPSKExtension(chain[0], pubKey, i.psks); err != nil {
+				return err
+			}
+		}
+		if hc != nil {
+			if err := handleRemoteCredential(hc, chain[0], pubKey); err != nil {
+				return err
+			}
+		}
+		keyCh <- pubKey
+		return nil
+	}
+	if hc != nil {
+		if cred := hc.LocalCredential(); cred != nil {
+			getCert := func() (*tls.Certificate, error) { return i.certificateWithCredential(cred) }
+			conf.Certificates = nil
+			conf.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return getCert() }
+			conf.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return getCert() }
+		}
+	}
 	// We're using InsecureSkipVerify, so the verifiedChains parameter will always be empty.
 	// We need to parse the certificates ourselves from the raw certs.
 	conf.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) (err error) {
@@ -120,20 +188,24 @@ func (i *Identity) ConfigForPeer(remote peer.ID) (*tls.Config, <-chan ic.PubKey)
 			}
 			chain[i] = cert
 		}
-
-		pubKey, err := PubKeyFromCertChain(chain)
-		if err != nil {
-			return err
+		return verify(chain)
+	}
+	// VerifyPeerCertificate is not called when resuming a session (only possible if session
+	// tickets were enabled on the config). The peer's certificate is restored from the
+	// session state instead, and we need to check it here.
+	conf.VerifyConnection = func(cs tls.ConnectionState) (err error) {
+		if !cs.DidResume {
+			return nil
 		}
-		if remote != "" && !remote.MatchesPublicKey(pubKey) {
-			peerID, err := peer.IDFromPublicKey(pubKey)
-			if err != nil {
-				peerID = peer.ID(fmt.Sprintf("(not determined: %s)", err.Error()))
+		defer func() {
+			if rerr := recover(); rerr != nil {
+				fmt.Fprintf(os.Stderr, "panic when processing peer certificate in TLS handshake: %s\n%s\n", rerr, debug.Stack())
+				err = fmt.Errorf("panic when processing peer certificate in TLS handshake: %s", rerr)
 			}
-			return sec.ErrPeerIDMismatch{Expected: remote, Actual: peerID}
-		}
-		keyCh <- pubKey
-		return nil
+		}()
+
+		defer close(keyCh)
+		return verify(cs.PeerCertificates)
 	}
 	return conf, keyCh
 }
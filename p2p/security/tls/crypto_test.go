@@ -1,11 +1,16 @@
 package libp2ptls
 
 import (
+	"crypto/rand"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/hex"
+	"net"
 	"testing"
 
+	ic "github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/sec"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -104,3 +109,75 @@ func TestVectors(t *testing.T) {
 		})
 	}
 }
+
+func TestSessionResumptionVerifiesPeer(t *testing.T) {
+	serverID, serverKey := createPeer(t)
+	clientID, clientKey := createPeer(t)
+	otherID, _ := createPeer(t)
+	serverIdentity, err := NewIdentity(serverKey)
+	require.NoError(t, err)
+	clientIdentity, err := NewIdentity(clientKey)
+	require.NoError(t, err)
+
+	var ticketKey [32]byte
+	_, err = rand.Read(ticketKey[:])
+	require.NoError(t, err)
+	cache := tls.NewLRUClientSessionCache(1)
+	// Not using net.Pipe, the handshake needs buffering: both sides write concurrently.
+	// Sessions are cached by server address, so all handshakes need to use the same listener.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	handshake := func(remote peer.ID) (resumed bool, clientErr error, serverKey, clientKey ic.PubKey) {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		require.NoError(t, err)
+		s, err := ln.Accept()
+		require.NoError(t, err)
+		defer c.Close()
+		defer s.Close()
+
+		serverConf, serverKeyCh := serverIdentity.ConfigForPeer("")
+		serverConf.SessionTicketsDisabled = false
+		serverConf.SetSessionTicketKeys([][32]byte{ticketKey})
+		clientConf, clientKeyCh := clientIdentity.ConfigForPeer(remote)
+		clientConf.SessionTicketsDisabled = false
+		clientConf.ClientSessionCache = cache
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			sconn := tls.Server(s, serverConf)
+			if sconn.Handshake() == nil {
+				sconn.Write([]byte("x")) // makes the client process the session ticket
+			}
+		}()
+		cconn := tls.Client(c, clientConf)
+		if err := cconn.Handshake(); err != nil {
+			c.Close()
+			<-done
+			return false, err, nil, nil
+		}
+		_, err = cconn.Read(make([]byte, 1))
+		require.NoError(t, err)
+		<-done
+		return cconn.ConnectionState().DidResume, nil, <-clientKeyCh, <-serverKeyCh
+	}
+
+	resumed, err, serverPub, clientPub := handshake(serverID)
+	require.NoError(t, err)
+	require.False(t, resumed)
+	require.True(t, serverID.MatchesPublicKey(serverPub))
+	require.True(t, clientID.MatchesPublicKey(clientPub))
+
+	resumed, err, serverPub, clientPub = handshake(serverID)
+	require.NoError(t, err)
+	require.True(t, resumed)
+	require.True(t, serverID.MatchesPublicKey(serverPub))
+	require.True(t, clientID.MatchesPublicKey(clientPub))
+
+	// the session was established with serverID, it must not be accepted when expecting otherID
+	_, err, _, _ = handshake(otherID)
+	require.Error(t, err)
+	require.ErrorAs(t, err, &sec.ErrPeerIDMismatch{})
+}
@@ -0,0 +1,102 @@
+package libp2ptls
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"time"
+
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/pnet"
+)
+
+// pskExtensionID is the ID of the extension proving knowledge of the PSKs of a private network.
+// This extension is not part of the libp2p TLS specification.
+var pskExtensionID = getPrefixedExtensionID([]int{1, 2})
+
+const pskProofPrefix = "libp2p-tls-psk-proof:"
+
+// errNoPSKProof is returned when the peer's certificate doesn't prove knowledge of an accepted PSK.
+var errNoPSKProof = pnet.NewError("peer certificate doesn't prove knowledge of an accepted PSK")
+
+// WithPSKSet only allows connections to peers that are part of the same private network.
+// Peers prove knowledge of the PSKs they accept in their certificate, bound to their libp2p
+// public key. This allows protecting transports that can't use a pnet protected connection,
+// like QUIC.
+//
+// Since the certificate contains a proof for every key that is not expired, two peers can connect
+// as long as they share an accepted key, regardless of the key that is currently active.
+//
+// The proof is only checked after the certificates were exchanged. Unlike a pnet protected
+// connection, peers that are not part of the private network can therefore complete the TLS
+// handshake, and learn the peer ID of the node they connect to, before the connection is closed.
+func WithPSKSet(psks pnet.PSKSet) IdentityOption {
+	return func(c *IdentityConfig) {
+		c.PSKs = psks
+	}
+}
+
+// pskProof calculates the proof of knowledge of a PSK for a libp2p public key.
+func pskProof(psk pnet.PSK, pubKey ic.PubKey) ([]byte, error) {
+	keyBytes, err := ic.MarshalPublicKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, psk)
+	mac.Write([]byte(pskProofPrefix))
+	mac.Write(keyBytes)
+	return mac.Sum(nil), nil
+}
+
+// generatePSKExtension generates an extension containing a proof for every PSK that is not expired.
+func generatePSKExtension(psks pnet.PSKSet, pubKey ic.PubKey) (pkix.Extension, error) {
+	var proofs [][]byte
+	for _, psk := range psks.Accepted(time.Now()) {
+		proof, err := pskProof(psk, pubKey)
+		if err != nil {
+			return pkix.Extension{}, err
+		}
+		proofs = append(proofs, proof)
+	}
+	if len(proofs) == 0 {
+		return pkix.Extension{}, pnet.ErrNoActivePSK
+	}
+	value, err := asn1.Marshal(proofs)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: pskExtensionID, Value: value}, nil
+}
+
+// verifyPSKExtension checks that the certificate proves knowledge of one of the accepted PSKs.
+func verifyPSKExtension(cert *x509.Certificate, pubKey ic.PubKey, psks pnet.PSKSet) error {
+	var proofs [][]byte
+	var found bool
+	for _, ext := range cert.Extensions {
+		if extensionIDEqual(ext.Id, pskExtensionID) {
+			if _, err := asn1.Unmarshal(ext.Value, &proofs); err != nil {
+				return errors.New("failed to unmarshal PSK extension")
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errNoPSKProof
+	}
+	for _, psk := range psks.Accepted(time.Now()) {
+		expected, err := pskProof(psk, pubKey)
+		if err != nil {
+			return err
+		}
+		for _, proof := range proofs {
+			if hmac.Equal(proof, expected) {
+				return nil
+			}
+		}
+	}
+	return errNoPSKProof
+}
@@ -0,0 +1,92 @@
+package libp2ptls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/pnet"
+
+	"github.com/stretchr/testify/require"
+)
+
+func handshakeIdentities(t *testing.T, clientID, serverID *Identity) (clientErr, serverErr error) {
+	t.Helper()
+	clientConf, _ := clientID.ConfigForPeer("")
+	serverConf, _ := serverID.ConfigForPeer("")
+	return handshakeConfigs(t, clientConf, serverConf)
+}
+
+func handshakeConfigs(t *testing.T, clientConf, serverConf *tls.Config) (clientErr, serverErr error) {
+	t.Helper()
+	clientConn, serverConn := connect(t)
+	clientConn.SetDeadline(time.Now().Add(5 * time.Second))
+	serverConn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	done := make(chan error, 1)
+	go func() {
+		// The server only verifies the client's certificate after it sent its own handshake messages.
+		// Exchange some application data to make sure both sides completed verification.
+		sconn := tls.Server(serverConn, serverConf)
+		defer sconn.Close()
+		b := make([]byte, 1)
+		_, err := sconn.Read(b)
+		if err == nil {
+			_, err = sconn.Write(b)
+		}
+		done <- err
+	}()
+	cconn := tls.Client(clientConn, clientConf)
+	defer cconn.Close()
+	_, clientErr = cconn.Write([]byte{0})
+	if clientErr == nil {
+		_, clientErr = cconn.Read(make([]byte, 1))
+	}
+	return clientErr, <-done
+}
+
+func TestPSKProof(t *testing.T) {
+	_, clientKey := createPeer(t)
+	_, serverKey := createPeer(t)
+	oldKey := pnet.PSK(bytes.Repeat([]byte{1}, 32))
+	newKey := pnet.PSK(bytes.Repeat([]byte{2}, 32))
+
+	t.Run("same PSK", func(t *testing.T) {
+		clientID, err := NewIdentity(clientKey, WithPSKSet(pnet.NewPSKSet(oldKey)))
+		require.NoError(t, err)
+		serverID, err := NewIdentity(serverKey, WithPSKSet(pnet.NewPSKSet(oldKey)))
+		require.NoError(t, err)
+		clientErr, serverErr := handshakeIdentities(t, clientID, serverID)
+		require.NoError(t, clientErr)
+		require.NoError(t, serverErr)
+	})
+
+	t.Run("overlapping PSK sets", func(t *testing.T) {
+		clientID, err := NewIdentity(clientKey, WithPSKSet(pnet.NewPSKSet(oldKey)))
+		require.NoError(t, err)
+		serverID, err := NewIdentity(serverKey, WithPSKSet(pnet.PSKSet{{PSK: oldKey}, {PSK: newKey, NotBefore: time.Now().Add(-time.Hour)}}))
+		require.NoError(t, err)
+		clientErr, serverErr := handshakeIdentities(t, clientID, serverID)
+		require.NoError(t, clientErr)
+		require.NoError(t, serverErr)
+	})
+
+	t.Run("different PSK", func(t *testing.T) {
+		clientID, err := NewIdentity(clientKey, WithPSKSet(pnet.NewPSKSet(oldKey)))
+		require.NoError(t, err)
+		serverID, err := NewIdentity(serverKey, WithPSKSet(pnet.NewPSKSet(newKey)))
+		require.NoError(t, err)
+		clientErr, _ := handshakeIdentities(t, clientID, serverID)
+		require.ErrorIs(t, clientErr, errNoPSKProof)
+	})
+
+	t.Run("no PSK", func(t *testing.T) {
+		clientID, err := NewIdentity(clientKey)
+		require.NoError(t, err)
+		serverID, err := NewIdentity(serverKey, WithPSKSet(pnet.NewPSKSet(oldKey)))
+		require.NoError(t, err)
+		_, serverErr := handshakeIdentities(t, clientID, serverID)
+		require.ErrorIs(t, serverErr, errNoPSKProof)
+	})
+}
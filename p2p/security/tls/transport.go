@@ -63,7 +63,8 @@ func New(id protocol.ID, key ci.PrivKey, muxers []tptu.StreamMuxer) (*Transport,
 // SecureInbound runs the TLS handshake as a server.
 // If p is empty, connections from any peer are accepted.
 func (t *Transport) SecureInbound(ctx context.Context, insecure net.Conn, p peer.ID) (sec.SecureConn, error) {
-	config, keyCh := t.identity.ConfigForPeer(p)
+	hc, _ := sec.GetHandshakeCredentials(ctx)
+	config, keyCh := t.identity.ConfigForPeerWithCredentials(p, hc)
 	muxers := make([]string, 0, len(t.muxers))
 	for _, muxer := range t.muxers {
 		muxers = append(muxers, string(muxer))
@@ -108,7 +109,8 @@ func (t *Transport) SecureInbound(ctx context.Context, insecure net.Conn, p peer
 // If the handshake fails, the server will close the connection. The client will
 // notice this after 1 RTT when calling Read.
 func (t *Transport) SecureOutbound(ctx context.Context, insecure net.Conn, p peer.ID) (sec.SecureConn, error) {
-	config, keyCh := t.identity.ConfigForPeer(p)
+	hc, _ := sec.GetHandshakeCredentials(ctx)
+	config, keyCh := t.identity.ConfigForPeerWithCredentials(p, hc)
 	muxers := make([]string, 0, len(t.muxers))
 	for _, muxer := range t.muxers {
 		muxers = append(muxers, (string)(muxer))
@@ -0,0 +1,353 @@
+// Package signer delegates signing with a host's private key to a separate process, so that the
+// key doesn't need to be loaded into the host's process.
+//
+// The signing process runs a Server, usually listening on a Unix domain socket. The host
+// connects to it using Dial, and uses the Client as its identity:
+//
+//	s, err := signer.Dial("unix", "/run/libp2p/signer.sock")
+//	h, err := libp2p.New(libp2p.IdentitySigner(s))
+//
+// Without access to the key, the host can't derive the keys QUIC and WebTransport need, and
+// only uses TCP and WebSocket by default. To enable them, provide a secret seed instead:
+//
+//	sk, err := crypto.NewSignerPrivKeyWithSeed(s, seed)
+//	h, err := libp2p.New(libp2p.Identity(sk))
+//
+// Everyone who can connect to the server can sign arbitrary data with the key, so access to the
+// socket needs to be restricted, for example using file permissions.
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-msgio"
+)
+
+var log = logging.Logger("signer")
+
+const (
+	opGetPublic byte = iota + 1
+	opSign
+)
+
+const (
+	statusOK byte = iota
+	statusError
+)
+
+const (
+	// maxMessageSize is the maximum size of a request or a response.
+	maxMessageSize = 1 << 20
+	// requestTimeout is the time the server has to respond to a request.
+	requestTimeout = 10 * time.Second
+	// maxIdleConns is the maximum number of idle connections a Client keeps open.
+	maxIdleConns = 4
+)
+
+// Server signs data on behalf of clients.
+type Server struct {
+	key crypto.PrivKey
+
+	mx        sync.Mutex
+	closed    bool
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+}
+
+// NewServer creates a new server that signs with key.
+func NewServer(key crypto.PrivKey) *Server {
+	return &Server{
+		key:       key,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts connections on l and serves them. It blocks until the listener fails or the
+// server is closed.
+func (s *Server) Serve(l net.Listener) error {
+	s.mx.Lock()
+	if s.closed {
+		s.mx.Unlock()
+		return net.ErrClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mx.Unlock()
+	defer func() {
+		s.mx.Lock()
+		delete(s.listeners, l)
+		s.mx.Unlock()
+	}()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		s.mx.Lock()
+		if s.closed {
+			s.mx.Unlock()
+			c.Close()
+			return net.ErrClosed
+		}
+		s.conns[c] = struct{}{}
+		s.mx.Unlock()
+		go s.handle(c)
+	}
+}
+
+func (s *Server) handle(c net.Conn) {
+	defer func() {
+		c.Close()
+		s.mx.Lock()
+		delete(s.conns, c)
+		s.mx.Unlock()
+	}()
+
+	r := msgio.NewVarintReaderSize(c, maxMessageSize)
+	w := msgio.NewVarintWriter(c)
+	for {
+		req, err := r.ReadMsg()
+		if err != nil {
+			return
+		}
+		resp := s.respond(req)
+		r.ReleaseMsg(req)
+		if err := w.WriteMsg(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) respond(req []byte) []byte {
+	if len(req) == 0 {
+		return errorResponse(errors.New("empty request"))
+	}
+	switch req[0] {
+	case opGetPublic:
+		b, err := crypto.MarshalPublicKey(s.key.GetPublic())
+		if err != nil {
+			return errorResponse(err)
+		}
+		return append([]byte{statusOK}, b...)
+	case opSign:
+		sig, err := s.key.Sign(req[1:])
+		if err != nil {
+			log.Debugw("signing failed", "error", err)
+			return errorResponse(err)
+		}
+		return append([]byte{statusOK}, sig...)
+	default:
+		return errorResponse(fmt.Errorf("unknown operation: %d", req[0]))
+	}
+}
+
+func errorResponse(err error) []byte {
+	return append([]byte{statusError}, err.Error()...)
+}
+
+// Close stops all listeners and closes all connections.
+func (s *Server) Close() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	return nil
+}
+
+// Client is a crypto.Signer that signs using a Server.
+// Concurrent requests are sent on separate connections, a few of which are kept open for reuse.
+// It reconnects to the server if a connection breaks.
+type Client struct {
+	network, addr string
+	pub           crypto.PubKey
+
+	mx     sync.Mutex
+	closed bool
+	idle   []*clientConn
+	// conns contains all open connections, including the ones currently used for a request.
+	conns map[*clientConn]struct{}
+}
+
+var _ crypto.Signer = &Client{}
+
+type clientConn struct {
+	net.Conn
+	r msgio.ReadCloser
+	w msgio.WriteCloser
+}
+
+// Dial connects to a server listening on addr, and fetches its public key.
+func Dial(network, addr string) (*Client, error) {
+	c := &Client{network: network, addr: addr, conns: make(map[*clientConn]struct{})}
+	b, err := c.request(opGetPublic, nil)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	c.pub, err = crypto.UnmarshalPublicKey(b)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetPublic returns the public key of the server.
+func (c *Client) GetPublic() crypto.PubKey {
+	return c.pub
+}
+
+// Sign asks the server to sign data.
+func (c *Client) Sign(data []byte) ([]byte, error) {
+	sig, err := c.request(opSign, data)
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := c.pub.Verify(data, sig); err != nil || !ok {
+		return nil, errors.New("signer returned an invalid signature")
+	}
+	return sig, nil
+}
+
+// Close closes all connections to the server.
+func (c *Client) Close() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.closed = true
+	for conn := range c.conns {
+		conn.Close()
+	}
+	c.conns = nil
+	c.idle = nil
+	return nil
+}
+
+func (c *Client) request(op byte, data []byte) ([]byte, error) {
+	req := append([]byte{op}, data...)
+	conn, err := c.getConn()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := conn.roundTrip(req)
+	if err != nil {
+		// The server might have been restarted, in which case the idle connections are broken as
+		// well. Try again on a new connection.
+		log.Debugw("request to signer failed, reconnecting", "error", err)
+		c.discardConn(conn)
+		c.discardIdleConns()
+		conn, err = c.dialConn()
+		if err != nil {
+			return nil, err
+		}
+		resp, err = conn.roundTrip(req)
+		if err != nil {
+			c.discardConn(conn)
+			return nil, err
+		}
+	}
+	c.putConn(conn)
+
+	if len(resp) == 0 {
+		return nil, errors.New("empty response from signer")
+	}
+	if resp[0] != statusOK {
+		return nil, fmt.Errorf("signer error: %s", resp[1:])
+	}
+	return resp[1:], nil
+}
+
+// getConn returns an idle connection, or dials a new one if there is none.
+func (c *Client) getConn() (*clientConn, error) {
+	c.mx.Lock()
+	if c.closed {
+		c.mx.Unlock()
+		return nil, net.ErrClosed
+	}
+	if n := len(c.idle); n > 0 {
+		conn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mx.Unlock()
+		return conn, nil
+	}
+	c.mx.Unlock()
+	return c.dialConn()
+}
+
+func (c *Client) dialConn() (*clientConn, error) {
+	nc, err := net.DialTimeout(c.network, c.addr, requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &clientConn{
+		Conn: nc,
+		r:    msgio.NewVarintReaderSize(nc, maxMessageSize),
+		w:    msgio.NewVarintWriter(nc),
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.closed {
+		nc.Close()
+		return nil, net.ErrClosed
+	}
+	c.conns[conn] = struct{}{}
+	return conn, nil
+}
+
+// putConn returns a connection after a successful request. It is kept for reuse, unless there
+// already are maxIdleConns idle connections.
+func (c *Client) putConn(conn *clientConn) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.closed {
+		return
+	}
+	if len(c.idle) >= maxIdleConns {
+		delete(c.conns, conn)
+		conn.Close()
+		return
+	}
+	c.idle = append(c.idle, conn)
+}
+
+func (c *Client) discardConn(conn *clientConn) {
+	c.mx.Lock()
+	delete(c.conns, conn)
+	c.mx.Unlock()
+	conn.Close()
+}
+
+func (c *Client) discardIdleConns() {
+	c.mx.Lock()
+	idle := c.idle
+	c.idle = nil
+	for _, conn := range idle {
+		delete(c.conns, conn)
+	}
+	c.mx.Unlock()
+	for _, conn := range idle {
+		conn.Close()
+	}
+}
+
+func (c *clientConn) roundTrip(req []byte) ([]byte, error) {
+	c.SetDeadline(time.Now().Add(requestTimeout))
+	defer c.SetDeadline(time.Time{})
+	if err := c.w.WriteMsg(req); err != nil {
+		return nil, err
+	}
+	return c.r.ReadMsg()
+}
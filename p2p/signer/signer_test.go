@@ -0,0 +1,161 @@
+package signer
+
+import (
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+
+	"github.com/stretchr/testify/require"
+)
+
+func startServer(t *testing.T, key crypto.PrivKey, path string) *Server {
+	t.Helper()
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	s := NewServer(key)
+	go s.Serve(l)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSign(t *testing.T) {
+	priv, pub, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	startServer(t, priv, path)
+
+	c, err := Dial("unix", path)
+	require.NoError(t, err)
+	defer c.Close()
+	require.True(t, c.GetPublic().Equals(pub))
+
+	data := []byte("foobar")
+	sig, err := c.Sign(data)
+	require.NoError(t, err)
+	ok, err := pub.Verify(data, sig)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The client can be used as a private key.
+	sk := crypto.NewSignerPrivKey(c)
+	sig, err = sk.Sign(data)
+	require.NoError(t, err)
+	ok, err = pub.Verify(data, sig)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, c.Close())
+	_, err = c.Sign(data)
+	require.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestReconnect(t *testing.T) {
+	priv, pub, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	s := startServer(t, priv, path)
+
+	c, err := Dial("unix", path)
+	require.NoError(t, err)
+	defer c.Close()
+
+	// Restart the server.
+	require.NoError(t, s.Close())
+	_, err = c.Sign([]byte("foobar"))
+	require.Error(t, err)
+	startServer(t, priv, path)
+
+	sig, err := c.Sign([]byte("foobar"))
+	require.NoError(t, err)
+	ok, err := pub.Verify([]byte("foobar"), sig)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestWrongKey(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	s := startServer(t, priv, path)
+
+	c, err := Dial("unix", path)
+	require.NoError(t, err)
+	defer c.Close()
+
+	// The server is replaced by one using a different key.
+	require.NoError(t, s.Close())
+	other, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	startServer(t, other, path)
+	_, err = c.Sign([]byte("foobar"))
+	require.Error(t, err)
+}
+
+func TestUnknownOperation(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	startServer(t, priv, path)
+
+	c, err := Dial("unix", path)
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = c.request(42, nil)
+	require.ErrorContains(t, err, "unknown operation")
+}
+
+// slowSigner delays every signature.
+type slowSigner struct {
+	crypto.PrivKey
+	delay time.Duration
+}
+
+func (s slowSigner) Sign(data []byte) ([]byte, error) {
+	time.Sleep(s.delay)
+	return s.PrivKey.Sign(data)
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	priv, pub, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signer.sock")
+	const delay = 200 * time.Millisecond
+	startServer(t, crypto.NewSignerPrivKey(slowSigner{PrivKey: priv, delay: delay}), path)
+
+	c, err := Dial("unix", path)
+	require.NoError(t, err)
+	defer c.Close()
+
+	const num = 2 * maxIdleConns
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, num)
+	for i := 0; i < num; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data := []byte("foobar")
+			sig, err := c.Sign(data)
+			if err == nil {
+				_, err = pub.Verify(data, sig)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	// The requests were not serialized.
+	require.Less(t, time.Since(start), num*delay/2)
+
+	c.mx.Lock()
+	require.LessOrEqual(t, len(c.idle), maxIdleConns)
+	require.Len(t, c.conns, len(c.idle))
+	c.mx.Unlock()
+}
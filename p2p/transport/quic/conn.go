@@ -12,6 +12,11 @@ import (
 	"github.com/quic-go/quic-go"
 )
 
+// conn is bound to the quicreuse socket it was dialed from, or accepted on, for its entire
+// lifetime. Connection migration is not supported: the quic-go version in use disables
+// active migration on both sides (disable_active_migration transport parameter) and doesn't
+// offer an API to move a connection to a new local address. When the local address changes,
+// the connection times out and the swarm has to redial.
 type conn struct {
 	quicConn  quic.Connection
 	transport *transport
@@ -26,6 +31,11 @@ type conn struct {
 }
 
 var _ tpt.CapableConn = &conn{}
+var _ tpt.DatagramConn = &conn{}
+
+// maxDatagramSize is the maximum size of a datagram payload.
+// quic-go limits DATAGRAM frames to 1200 bytes, 3 bytes of which are used by the frame header.
+const maxDatagramSize = 1197
 
 // Close closes the connection.
 // It must be called even if the peer closed the connection in order for
@@ -62,6 +72,32 @@ func (c *conn) AcceptStream() (network.MuxedStream, error) {
 	return &stream{Stream: qstr}, err
 }
 
+// SupportsDatagrams says if both peers enabled QUIC datagrams.
+func (c *conn) SupportsDatagrams() bool {
+	return c.quicConn.ConnectionState().SupportsDatagrams
+}
+
+// MaxDatagramSize returns the maximum size of a datagram.
+func (c *conn) MaxDatagramSize() int {
+	return maxDatagramSize
+}
+
+// SendDatagram sends a QUIC datagram.
+func (c *conn) SendDatagram(b []byte) error {
+	if !c.SupportsDatagrams() {
+		return network.ErrDatagramsNotSupported
+	}
+	if len(b) > maxDatagramSize {
+		return network.ErrDatagramTooLarge
+	}
+	return c.quicConn.SendMessage(b)
+}
+
+// ReceiveDatagram receives a QUIC datagram.
+func (c *conn) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	return c.quicConn.ReceiveMessage(ctx)
+}
+
 // LocalPeer returns our peer ID
 func (c *conn) LocalPeer() peer.ID { return c.localPeer }
 
@@ -17,6 +17,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/network"
 	mocknetwork "github.com/libp2p/go-libp2p/core/network/mocks"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/pnet"
 	tpt "github.com/libp2p/go-libp2p/core/transport"
 	"github.com/libp2p/go-libp2p/p2p/transport/quicreuse"
 
@@ -305,6 +306,117 @@ func testStreams(t *testing.T, tc *connTestCase) {
 	require.Equal(t, data, []byte("foobar"))
 }
 
+func TestDatagrams(t *testing.T) {
+	for _, tc := range connTestCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			testDatagrams(t, tc)
+		})
+	}
+}
+
+func testDatagrams(t *testing.T, tc *connTestCase) {
+	serverID, serverKey := createPeer(t)
+	_, clientKey := createPeer(t)
+
+	serverTransport, err := NewTransport(serverKey, newConnManager(t, tc.Options...), nil, nil, nil)
+	require.NoError(t, err)
+	defer serverTransport.(io.Closer).Close()
+	ln := runServer(t, serverTransport, "/ip4/127.0.0.1/udp/0/quic-v1")
+	defer ln.Close()
+
+	clientTransport, err := NewTransport(clientKey, newConnManager(t, tc.Options...), nil, nil, nil)
+	require.NoError(t, err)
+	defer clientTransport.(io.Closer).Close()
+	conn, err := clientTransport.Dial(context.Background(), ln.Multiaddr(), serverID)
+	require.NoError(t, err)
+	defer conn.Close()
+	serverConn, err := ln.Accept()
+	require.NoError(t, err)
+	defer serverConn.Close()
+
+	dc := conn.(tpt.DatagramConn)
+	require.True(t, dc.SupportsDatagrams())
+	require.ErrorIs(t, dc.SendDatagram(make([]byte, dc.MaxDatagramSize()+1)), network.ErrDatagramTooLarge)
+	require.NoError(t, dc.SendDatagram([]byte("foobar")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	data, err := serverConn.(tpt.DatagramConn).ReceiveDatagram(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []byte("foobar"), data)
+}
+
+func TestSessionResumption(t *testing.T) {
+	for _, tc := range connTestCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			testSessionResumption(t, tc)
+		})
+	}
+}
+
+func testSessionResumption(t *testing.T, tc *connTestCase) {
+	serverID, serverKey := createPeer(t)
+	clientID, clientKey := createPeer(t)
+	opts := append([]quicreuse.Option{quicreuse.EnableSessionResumption()}, tc.Options...)
+
+	serverTransport, err := NewTransport(serverKey, newConnManager(t, opts...), nil, nil, nil)
+	require.NoError(t, err)
+	defer serverTransport.(io.Closer).Close()
+	ln := runServer(t, serverTransport, "/ip4/127.0.0.1/udp/0/quic-v1")
+	defer ln.Close()
+
+	clientTransport, err := NewTransport(clientKey, newConnManager(t, opts...), nil, nil, nil)
+	require.NoError(t, err)
+	defer clientTransport.(io.Closer).Close()
+
+	dial := func() (tpt.CapableConn, tpt.CapableConn) {
+		conn, err := clientTransport.Dial(context.Background(), ln.Multiaddr(), serverID)
+		require.NoError(t, err)
+		serverConn, err := ln.Accept()
+		require.NoError(t, err)
+		// Exchange some data, making sure the client received the session ticket.
+		str, err := conn.OpenStream(context.Background())
+		require.NoError(t, err)
+		_, err = str.Write([]byte("foobar"))
+		require.NoError(t, err)
+		sstr, err := serverConn.AcceptStream()
+		require.NoError(t, err)
+		_, err = sstr.Write([]byte("foobar"))
+		require.NoError(t, err)
+		_, err = io.ReadFull(str, make([]byte, 6))
+		require.NoError(t, err)
+		return conn, serverConn
+	}
+
+	didResume := func(c tpt.CapableConn) bool {
+		return c.(*conn).quicConn.ConnectionState().TLS.DidResume
+	}
+
+	clientConn, serverConn := dial()
+	require.False(t, didResume(clientConn))
+	clientConn.Close()
+	serverConn.Close()
+
+	clientConn, serverConn = dial()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	require.True(t, didResume(clientConn))
+	require.Equal(t, serverID, clientConn.RemotePeer())
+	require.True(t, serverKey.GetPublic().Equals(clientConn.RemotePublicKey()))
+	require.Equal(t, clientID, serverConn.RemotePeer())
+
+	// session resumption is opt-in
+	clientTransport, err = NewTransport(clientKey, newConnManager(t, tc.Options...), nil, nil, nil)
+	require.NoError(t, err)
+	defer clientTransport.(io.Closer).Close()
+	for i := 0; i < 2; i++ {
+		clientConn, serverConn := dial()
+		require.False(t, didResume(clientConn))
+		clientConn.Close()
+		serverConn.Close()
+	}
+}
+
 func TestHandshakeFailPeerIDMismatch(t *testing.T) {
 	for _, tc := range connTestCases {
 		t.Run(tc.Name, func(t *testing.T) {
@@ -347,6 +459,50 @@ func testHandshakeFailPeerIDMismatch(t *testing.T, tc *connTestCase) {
 	require.Error(t, <-acceptErr)
 }
 
+func TestPrivateNetwork(t *testing.T) {
+	oldKey := pnet.PSK(bytes.Repeat([]byte{1}, 32))
+	newKey := pnet.PSK(bytes.Repeat([]byte{2}, 32))
+
+	handshake := func(t *testing.T, serverPSK, clientPSK pnet.PSK, serverOpts ...Option) error {
+		t.Helper()
+		serverID, serverKey := createPeer(t)
+		_, clientKey := createPeer(t)
+		serverTransport, err := NewTransport(serverKey, newConnManager(t), serverPSK, nil, nil, serverOpts...)
+		require.NoError(t, err)
+		defer serverTransport.(io.Closer).Close()
+		ln := runServer(t, serverTransport, "/ip4/127.0.0.1/udp/0/quic-v1")
+		defer ln.Close()
+
+		clientTransport, err := NewTransport(clientKey, newConnManager(t), clientPSK, nil, nil)
+		require.NoError(t, err)
+		defer clientTransport.(io.Closer).Close()
+		conn, err := clientTransport.Dial(context.Background(), ln.Multiaddr(), serverID)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		return nil
+	}
+
+	t.Run("same PSK", func(t *testing.T) {
+		require.NoError(t, handshake(t, oldKey, oldKey))
+	})
+	t.Run("different PSK", func(t *testing.T) {
+		require.Error(t, handshake(t, oldKey, newKey))
+	})
+	t.Run("no PSK", func(t *testing.T) {
+		// The server only checks the client's certificate after the client completed the handshake,
+		// so only test the client's side.
+		require.Error(t, handshake(t, nil, oldKey))
+	})
+	t.Run("PSK set", func(t *testing.T) {
+		psks := pnet.PSKSet{{PSK: oldKey}, {PSK: newKey, NotBefore: time.Now().Add(time.Hour)}}
+		// WithPSKSet takes precedence over the PSK argument
+		require.NoError(t, handshake(t, bytes.Repeat([]byte{3}, 32), newKey, WithPSKSet(psks)))
+		require.NoError(t, handshake(t, nil, oldKey, WithPSKSet(psks)))
+	})
+}
+
 func TestConnectionGating(t *testing.T) {
 	for _, tc := range connTestCases {
 		t.Run(tc.Name, func(t *testing.T) {
@@ -15,6 +15,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/pnet"
+	"github.com/libp2p/go-libp2p/core/sec"
 	tpt "github.com/libp2p/go-libp2p/core/transport"
 	p2ptls "github.com/libp2p/go-libp2p/p2p/security/tls"
 	"github.com/libp2p/go-libp2p/p2p/transport/quicreuse"
@@ -39,6 +40,7 @@ type transport struct {
 	privKey     ic.PrivKey
 	localPeer   peer.ID
 	identity    *p2ptls.Identity
+	credentials sec.HandshakeCredentials // set if the connection gater exchanges handshake credentials
 	connManager *quicreuse.ConnManager
 	gater       connmgr.ConnectionGater
 	rcmgr       network.ResourceManager
@@ -69,17 +71,52 @@ type activeHolePunch struct {
 	fulfilled bool
 }
 
-// NewTransport creates a new QUIC transport
-func NewTransport(key ic.PrivKey, connManager *quicreuse.ConnManager, psk pnet.PSK, gater connmgr.ConnectionGater, rcmgr network.ResourceManager) (tpt.Transport, error) {
+// Option is an option for the QUIC transport.
+type Option func(*config) error
+
+type config struct {
+	psks pnet.PSKSet
+}
+
+// WithPSKSet restricts the transport to a private network using a set of PSKs, allowing the PSK to
+// be rotated (see pnet.PSKSet). It takes precedence over the PSK passed to NewTransport.
+//
+// Unlike a pnet protected TCP connection, QUIC can't encrypt the handshake with the PSK. Instead,
+// peers prove knowledge of the PSKs in their TLS certificate (see p2ptls.WithPSKSet). Peers that
+// are not part of the private network can still complete the TLS handshake and learn the peer ID of
+// the node they connect to, before the connection is rejected.
+func WithPSKSet(psks pnet.PSKSet) Option {
+	return func(c *config) error {
+		if len(psks) == 0 {
+			return errors.New("empty PSK set")
+		}
+		c.psks = psks
+		return nil
+	}
+}
+
+// NewTransport creates a new QUIC transport.
+// If a PSK is passed, peers are required to prove knowledge of the PSK during the TLS handshake.
+// Use WithPSKSet to rotate the PSK.
+func NewTransport(key ic.PrivKey, connManager *quicreuse.ConnManager, psk pnet.PSK, gater connmgr.ConnectionGater, rcmgr network.ResourceManager, opts ...Option) (tpt.Transport, error) {
+	var cfg config
 	if len(psk) > 0 {
-		log.Error("QUIC doesn't support private networks yet.")
-		return nil, errors.New("QUIC doesn't support private networks yet")
+		cfg.psks = pnet.NewPSKSet(psk)
+	}
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
 	}
 	localPeer, err := peer.IDFromPrivateKey(key)
 	if err != nil {
 		return nil, err
 	}
-	identity, err := p2ptls.NewIdentity(key)
+	var identityOpts []p2ptls.IdentityOption
+	if len(cfg.psks) > 0 {
+		identityOpts = append(identityOpts, p2ptls.WithPSKSet(cfg.psks))
+	}
+	identity, err := p2ptls.NewIdentity(key, identityOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -87,11 +124,13 @@ func NewTransport(key ic.PrivKey, connManager *quicreuse.ConnManager, psk pnet.P
 	if rcmgr == nil {
 		rcmgr = &network.NullResourceManager{}
 	}
+	credentials, _ := gater.(sec.HandshakeCredentials)
 
 	return &transport{
 		privKey:      key,
 		localPeer:    localPeer,
 		identity:     identity,
+		credentials:  credentials,
 		connManager:  connManager,
 		gater:        gater,
 		rcmgr:        rcmgr,
@@ -129,7 +168,14 @@ func (t *transport) dialWithScope(ctx context.Context, raddr ma.Multiaddr, p pee
 		return nil, err
 	}
 
-	tlsConf, keyCh := t.identity.ConfigForPeer(p)
+	tlsConf, keyCh := t.identity.ConfigForPeerWithCredentials(p, t.credentials)
+	// Resume the TLS session if we were recently connected to p and session resumption is enabled.
+	// We never send 0-RTT data: we only return the connection after the handshake completed, so
+	// there's no early data that could be replayed. The peer's identity is verified when resuming as well.
+	if cache := t.connManager.ClientSessionCache(p); cache != nil {
+		tlsConf.SessionTicketsDisabled = false
+		tlsConf.ClientSessionCache = cache
+	}
 	pconn, err := t.connManager.DialQUIC(ctx, raddr, tlsConf, t.allowWindowIncrease)
 	if err != nil {
 		return nil, err
@@ -284,7 +330,11 @@ func (t *transport) Listen(addr ma.Multiaddr) (tpt.Listener, error) {
 		// Note that since we have no way of associating an incoming QUIC connection with
 		// the peer ID calculated here, we don't actually receive the peer's public key
 		// from the key chan.
-		conf, _ := t.identity.ConfigForPeer("")
+		conf, _ := t.identity.ConfigForPeerWithCredentials("", t.credentials)
+		// Issue session tickets, so clients can resume the session when reconnecting.
+		// The ticket keys need to be shared by all configs for a ticket to be usable.
+		conf.SessionTicketsDisabled = false
+		conf.SetSessionTicketKeys(t.connManager.SessionTicketKeys())
 		return conf, nil
 	}
 	tlsConf.NextProtos = []string{"libp2p"}
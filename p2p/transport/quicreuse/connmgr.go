@@ -2,23 +2,40 @@ package quicreuse
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/tls"
 	"errors"
 	"net"
 	"sync"
 
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	lru "github.com/hashicorp/golang-lru/v2"
 	ma "github.com/multiformats/go-multiaddr"
 	manet "github.com/multiformats/go-multiaddr/net"
 	"github.com/quic-go/quic-go"
 	quiclogging "github.com/quic-go/quic-go/logging"
 )
 
+const (
+	// maxSessionCachePeers is the number of peers we keep TLS session tickets for.
+	maxSessionCachePeers = 1024
+	// maxSessionsPerPeer is the number of TLS session tickets we keep per peer.
+	maxSessionsPerPeer = 4
+)
+
 type ConnManager struct {
 	reuseUDP4       *reuse
 	reuseUDP6       *reuse
 	enableReuseport bool
 	enableMetrics   bool
 
+	enableSessionResumption bool
+	// sessionCaches contains a tls.ClientSessionCache for every peer, nil if session
+	// resumption is disabled
+	sessionCaches     *lru.Cache[peer.ID, tls.ClientSessionCache]
+	sessionTicketKeys [][32]byte
+
 	serverConfig *quic.Config
 	clientConfig *quic.Config
 
@@ -46,6 +63,21 @@ func NewConnManager(statelessResetKey quic.StatelessResetKey, opts ...Option) (*
 		}
 	}
 
+	// Session tickets are always issued, so that clients that enabled session resumption can
+	// resume sessions with us. Whether we resume sessions when dialing is up to the option.
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return nil, err
+	}
+	cm.sessionTicketKeys = [][32]byte{key}
+	if cm.enableSessionResumption {
+		cache, err := lru.New[peer.ID, tls.ClientSessionCache](maxSessionCachePeers)
+		if err != nil {
+			return nil, err
+		}
+		cm.sessionCaches = cache
+	}
+
 	quicConf := quicConfig.Clone()
 
 	if cm.enableMetrics {
@@ -72,6 +104,29 @@ func NewConnManager(statelessResetKey quic.StatelessResetKey, opts ...Option) (*
 	return cm, nil
 }
 
+// ClientSessionCache returns the TLS session cache to use when dialing peer p.
+// It returns nil if session resumption is disabled.
+func (c *ConnManager) ClientSessionCache(p peer.ID) tls.ClientSessionCache {
+	if c.sessionCaches == nil {
+		return nil
+	}
+	if cache, ok := c.sessionCaches.Get(p); ok {
+		return cache
+	}
+	cache := tls.NewLRUClientSessionCache(maxSessionsPerPeer)
+	if prev, ok, _ := c.sessionCaches.PeekOrAdd(p, cache); ok {
+		return prev
+	}
+	return cache
+}
+
+// SessionTicketKeys returns the keys to use to encrypt and decrypt TLS session tickets
+// issued to clients, see tls.Config.SetSessionTicketKeys.
+// Tickets are issued regardless of whether session resumption is enabled for outgoing connections.
+func (c *ConnManager) SessionTicketKeys() [][32]byte {
+	return c.sessionTicketKeys
+}
+
 func (c *ConnManager) getReuse(network string) (*reuse, error) {
 	switch network {
 	case "udp4":
@@ -259,3 +259,23 @@ func testListener(t *testing.T, enableReuseport bool) {
 
 	checkClosed(t, cm)
 }
+
+func TestClientSessionCache(t *testing.T) {
+	cm, err := NewConnManager(quic.StatelessResetKey{}, DisableReuseport(), EnableSessionResumption())
+	require.NoError(t, err)
+	defer cm.Close()
+
+	require.Len(t, cm.SessionTicketKeys(), 1)
+	c1 := cm.ClientSessionCache("peer1")
+	require.NotNil(t, c1)
+	require.Same(t, c1, cm.ClientSessionCache("peer1"))
+	require.NotSame(t, c1, cm.ClientSessionCache("peer2"))
+
+	t.Run("disabled by default", func(t *testing.T) {
+		cm, err := NewConnManager(quic.StatelessResetKey{}, DisableReuseport())
+		require.NoError(t, err)
+		defer cm.Close()
+		require.Len(t, cm.SessionTicketKeys(), 1)
+		require.Nil(t, cm.ClientSessionCache("peer1"))
+	})
+}
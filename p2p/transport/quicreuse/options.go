@@ -16,3 +16,17 @@ func EnableMetrics() Option {
 		return nil
 	}
 }
+
+// EnableSessionResumption enables TLS session resumption for outgoing QUIC connections.
+// Connections to a peer we were recently connected to then resume the TLS session using a
+// session ticket, which saves sending and verifying certificates. Session tickets are issued
+// to clients regardless of this option.
+//
+// The handshake still takes one round trip: 0-RTT is not used, since early data could be
+// replayed, and would be processed before the connection gater accepted the connection.
+func EnableSessionResumption() Option {
+	return func(m *ConnManager) error {
+		m.enableSessionResumption = true
+		return nil
+	}
+}
@@ -0,0 +1,205 @@
+package tcp
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/libp2p/go-libp2p/internal/mptcp"
+)
+
+// ControlFunc is called on every socket after it was created, and before it is bound or
+// connected. See net.Dialer.Control and net.ListenConfig.Control.
+type ControlFunc = func(network, address string, c syscall.RawConn) error
+
+// WithSocketControl sets a function that is called for every socket that the transport
+// creates, both for dialing and for listening. It can be used to set socket options
+// that are not covered by the other options of this package.
+//
+// When reuseport is used, the function is called after the reuseport socket options were set.
+func WithSocketControl(f ControlFunc) Option {
+	return func(tr *TcpTransport) error {
+		tr.socketControl = f
+		return nil
+	}
+}
+
+// WithKeepAlivePeriod sets the period between TCP keepalive probes.
+// A period of 0 disables keepalives. Defaults to 30s.
+func WithKeepAlivePeriod(d time.Duration) Option {
+	return func(tr *TcpTransport) error {
+		if d < 0 {
+			return errors.New("keepalive period must not be negative")
+		}
+		tr.keepAlivePeriod = d
+		return nil
+	}
+}
+
+// WithNoDelay sets TCP_NODELAY on all connections. Go enables it by default, disabling it
+// enables Nagle's algorithm.
+func WithNoDelay(noDelay bool) Option {
+	return func(tr *TcpTransport) error {
+		tr.noDelay = noDelay
+		return nil
+	}
+}
+
+// WithReadBuffer sets the size of the operating system's receive buffer for all connections.
+// If unset, the operating system's default is used.
+func WithReadBuffer(bytes int) Option {
+	return func(tr *TcpTransport) error {
+		if bytes <= 0 {
+			return errors.New("read buffer size must be positive")
+		}
+		tr.readBuffer = bytes
+		return nil
+	}
+}
+
+// WithWriteBuffer sets the size of the operating system's send buffer for all connections.
+// If unset, the operating system's default is used.
+func WithWriteBuffer(bytes int) Option {
+	return func(tr *TcpTransport) error {
+		if bytes <= 0 {
+			return errors.New("write buffer size must be positive")
+		}
+		tr.writeBuffer = bytes
+		return nil
+	}
+}
+
+// WithUserTimeout sets TCP_USER_TIMEOUT, the maximum time that transmitted data may remain
+// unacknowledged before the connection is closed. Combined with keepalives, this allows
+// detecting dead peers faster than the operating system's default.
+// This option is only supported on Linux.
+func WithUserTimeout(d time.Duration) Option {
+	return func(tr *TcpTransport) error {
+		if !hasUserTimeout {
+			return errors.New("TCP_USER_TIMEOUT is not supported on this platform")
+		}
+		if d <= 0 {
+			return errors.New("user timeout must be positive")
+		}
+		tr.userTimeout = d
+		return nil
+	}
+}
+
+// EnableFastOpen enables TCP Fast Open (RFC 7413), both for dialing and for listening.
+// Fast Open only takes effect if it is also enabled in the kernel (net.ipv4.tcp_fastopen).
+// This option is only supported on Linux.
+func EnableFastOpen() Option {
+	return func(tr *TcpTransport) error {
+		if !hasFastOpen {
+			return errors.New("TCP Fast Open is not supported on this platform")
+		}
+		tr.fastOpen = true
+		return nil
+	}
+}
+
+// WithMultipathTCP enables Multipath TCP (RFC 8684), both for dialing and for listening.
+// Connections fall back to regular TCP if the kernel or the remote peer doesn't support it.
+// This option requires Go 1.21 or later, and currently only has an effect on Linux.
+func WithMultipathTCP() Option {
+	return func(tr *TcpTransport) error {
+		if !mptcp.Available {
+			return errors.New("Multipath TCP requires Go 1.21 or later")
+		}
+		tr.multipathTCP = true
+		return nil
+	}
+}
+
+// dialControl returns the control function used for dialing, or nil if no socket options
+// need to be set.
+func (t *TcpTransport) dialControl() ControlFunc {
+	return t.control(false)
+}
+
+// listenControl returns the control function used for listening, or nil if no socket options
+// need to be set.
+func (t *TcpTransport) listenControl() ControlFunc {
+	return t.control(true)
+}
+
+func (t *TcpTransport) control(listen bool) ControlFunc {
+	if t.userTimeout == 0 && !t.fastOpen && t.socketControl == nil {
+		return nil
+	}
+	return func(network, address string, c syscall.RawConn) error {
+		var err error
+		if cerr := c.Control(func(fd uintptr) {
+			if t.userTimeout > 0 {
+				if err = setUserTimeout(fd, t.userTimeout); err != nil {
+					return
+				}
+			}
+			if t.fastOpen {
+				err = setFastOpen(fd, listen)
+			}
+		}); cerr != nil {
+			return cerr
+		}
+		if err != nil {
+			return err
+		}
+		if t.socketControl != nil {
+			return t.socketControl(network, address, c)
+		}
+		return nil
+	}
+}
+
+// hasConnOptions returns true if any options need to be applied to established connections,
+// in addition to the defaults.
+func (t *TcpTransport) hasConnOptions() bool {
+	return t.keepAlivePeriod != keepAlivePeriod || !t.noDelay || t.readBuffer > 0 || t.writeBuffer > 0
+}
+
+// setConnOptions applies the options to an established connection.
+func (t *TcpTransport) setConnOptions(conn net.Conn) {
+	tryKeepAlive(conn, t.keepAlivePeriod)
+	if !t.noDelay {
+		tryNoDelay(conn, false)
+	}
+	if t.readBuffer > 0 || t.writeBuffer > 0 {
+		tryBuffers(conn, t.readBuffer, t.writeBuffer)
+	}
+}
+
+func tryNoDelay(conn net.Conn, noDelay bool) {
+	type canNoDelay interface {
+		SetNoDelay(bool) error
+	}
+
+	if c, ok := conn.(canNoDelay); ok {
+		if err := c.SetNoDelay(noDelay); err != nil {
+			log.Debugw("failed to set TCP_NODELAY", "error", err)
+		}
+	}
+}
+
+func tryBuffers(conn net.Conn, readBuffer, writeBuffer int) {
+	type canSetBuffers interface {
+		SetReadBuffer(int) error
+		SetWriteBuffer(int) error
+	}
+
+	c, ok := conn.(canSetBuffers)
+	if !ok {
+		return
+	}
+	if readBuffer > 0 {
+		if err := c.SetReadBuffer(readBuffer); err != nil {
+			log.Debugw("failed to set read buffer", "error", err)
+		}
+	}
+	if writeBuffer > 0 {
+		if err := c.SetWriteBuffer(writeBuffer); err != nil {
+			log.Debugw("failed to set write buffer", "error", err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+//go:build linux
+
+package tcp
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	hasUserTimeout = true
+	hasFastOpen    = true
+)
+
+// fastOpenQueueLen is the maximum number of pending Fast Open requests on a listener.
+const fastOpenQueueLen = 256
+
+func setUserTimeout(fd uintptr, d time.Duration) error {
+	return unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_USER_TIMEOUT, int(d.Milliseconds()))
+}
+
+func setFastOpen(fd uintptr, listen bool) error {
+	if listen {
+		return unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_FASTOPEN, fastOpenQueueLen)
+	}
+	return unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_FASTOPEN_CONNECT, 1)
+}
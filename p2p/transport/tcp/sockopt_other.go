@@ -0,0 +1,21 @@
+//go:build !linux
+
+package tcp
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	hasUserTimeout = false
+	hasFastOpen    = false
+)
+
+func setUserTimeout(uintptr, time.Duration) error {
+	return errors.New("TCP_USER_TIMEOUT not supported")
+}
+
+func setFastOpen(uintptr, bool) error {
+	return errors.New("TCP Fast Open not supported")
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/internal/mptcp"
 	"github.com/libp2p/go-libp2p/p2p/net/reuseport"
 
 	logging "github.com/ipfs/go-log/v2"
@@ -24,6 +25,7 @@ const defaultConnectTimeout = 5 * time.Second
 
 var log = logging.Logger("tcp-tpt")
 
+// keepAlivePeriod is the default keepalive period, see WithKeepAlivePeriod.
 const keepAlivePeriod = 30 * time.Second
 
 type canKeepAlive interface {
@@ -33,13 +35,14 @@ type canKeepAlive interface {
 
 var _ canKeepAlive = &net.TCPConn{}
 
-func tryKeepAlive(conn net.Conn, keepAlive bool) {
+// tryKeepAlive enables keepalives with the given period. A period of 0 disables keepalives.
+func tryKeepAlive(conn net.Conn, period time.Duration) {
 	keepAliveConn, ok := conn.(canKeepAlive)
 	if !ok {
 		log.Errorf("Can't set TCP keepalives.")
 		return
 	}
-	if err := keepAliveConn.SetKeepAlive(keepAlive); err != nil {
+	if err := keepAliveConn.SetKeepAlive(period > 0); err != nil {
 		// Sometimes we seem to get "invalid argument" results from this function on Darwin.
 		// This might be due to a closed connection, but I can't reproduce that on Linux.
 		//
@@ -53,8 +56,8 @@ func tryKeepAlive(conn net.Conn, keepAlive bool) {
 		return
 	}
 
-	if runtime.GOOS != "openbsd" {
-		if err := keepAliveConn.SetKeepAlivePeriod(keepAlivePeriod); err != nil {
+	if period > 0 && runtime.GOOS != "openbsd" {
+		if err := keepAliveConn.SetKeepAlivePeriod(period); err != nil {
 			log.Errorw("failed set keepalive period", "error", err)
 		}
 	}
@@ -73,7 +76,8 @@ func tryLinger(conn net.Conn, sec int) {
 
 type tcpListener struct {
 	manet.Listener
-	sec int
+	sec int // linger timeout, a negative value keeps the OS default
+	tpt *TcpTransport
 }
 
 func (ll *tcpListener) Accept() (manet.Conn, error) {
@@ -81,8 +85,10 @@ func (ll *tcpListener) Accept() (manet.Conn, error) {
 	if err != nil {
 		return nil, err
 	}
-	tryLinger(c, ll.sec)
-	tryKeepAlive(c, true)
+	if ll.sec >= 0 {
+		tryLinger(c, ll.sec)
+	}
+	ll.tpt.setConnOptions(c)
 	// We're not calling OpenConnection in the resource manager here,
 	// since the manet.Conn doesn't allow us to save the scope.
 	// It's the caller's (usually the p2p/net/upgrader) responsibility
@@ -125,6 +131,16 @@ type TcpTransport struct {
 	// TCP connect timeout
 	connectTimeout time.Duration
 
+	// Socket options, see sockopt.go.
+	socketControl   ControlFunc
+	keepAlivePeriod time.Duration
+	noDelay         bool
+	readBuffer      int
+	writeBuffer     int
+	userTimeout     time.Duration
+	fastOpen        bool
+	multipathTCP    bool
+
 	rcmgr network.ResourceManager
 
 	reuse reuseport.Transport
@@ -139,15 +155,20 @@ func NewTCPTransport(upgrader transport.Upgrader, rcmgr network.ResourceManager,
 		rcmgr = &network.NullResourceManager{}
 	}
 	tr := &TcpTransport{
-		upgrader:       upgrader,
-		connectTimeout: defaultConnectTimeout, // can be set by using the WithConnectionTimeout option
-		rcmgr:          rcmgr,
+		upgrader:        upgrader,
+		connectTimeout:  defaultConnectTimeout, // can be set by using the WithConnectionTimeout option
+		keepAlivePeriod: keepAlivePeriod,
+		noDelay:         true,
+		rcmgr:           rcmgr,
 	}
 	for _, o := range opts {
 		if err := o(tr); err != nil {
 			return nil, err
 		}
 	}
+	tr.reuse.DialControl = tr.dialControl()
+	tr.reuse.ListenControl = tr.listenControl()
+	tr.reuse.MultipathTCP = tr.multipathTCP
 	return tr, nil
 }
 
@@ -170,7 +191,10 @@ func (t *TcpTransport) maDial(ctx context.Context, raddr ma.Multiaddr) (manet.Co
 	if t.UseReuseport() {
 		return t.reuse.DialContext(ctx, raddr)
 	}
-	var d manet.Dialer
+	d := manet.Dialer{Dialer: net.Dialer{Control: t.dialControl()}}
+	if t.multipathTCP {
+		mptcp.EnableDialer(&d.Dialer)
+	}
 	return d.DialContext(ctx, raddr)
 }
 
@@ -203,7 +227,7 @@ func (t *TcpTransport) dialWithScope(ctx context.Context, raddr ma.Multiaddr, p
 	// linger is 0, connections are _reset_ instead of closed with a FIN.
 	// This means we can immediately reuse the 5-tuple and reconnect.
 	tryLinger(conn, 0)
-	tryKeepAlive(conn, true)
+	t.setConnOptions(conn)
 	c := conn
 	if t.enableMetrics {
 		var err error
@@ -228,7 +252,23 @@ func (t *TcpTransport) maListen(laddr ma.Multiaddr) (manet.Listener, error) {
 	if t.UseReuseport() {
 		return t.reuse.Listen(laddr)
 	}
-	return manet.Listen(laddr)
+	control := t.listenControl()
+	if control == nil && !t.multipathTCP {
+		return manet.Listen(laddr)
+	}
+	nw, naddr, err := manet.DialArgs(laddr)
+	if err != nil {
+		return nil, err
+	}
+	lc := net.ListenConfig{Control: control}
+	if t.multipathTCP {
+		mptcp.EnableListenConfig(&lc)
+	}
+	nl, err := lc.Listen(context.Background(), nw, naddr)
+	if err != nil {
+		return nil, err
+	}
+	return manet.WrapNetListener(nl)
 }
 
 // Listen listens on the given multiaddr.
@@ -238,7 +278,9 @@ func (t *TcpTransport) Listen(laddr ma.Multiaddr) (transport.Listener, error) {
 		return nil, err
 	}
 	if t.enableMetrics {
-		list = newTracingListener(&tcpListener{list, 0})
+		list = newTracingListener(&tcpListener{list, 0, t})
+	} else if t.hasConnOptions() {
+		list = &tcpListener{list, -1, t}
 	}
 	return t.upgrader.UpgradeListener(t, list), nil
 }
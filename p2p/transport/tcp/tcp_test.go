@@ -3,7 +3,12 @@ package tcp
 import (
 	"context"
 	"errors"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/network"
@@ -12,6 +17,8 @@ import (
 	"github.com/libp2p/go-libp2p/core/sec"
 	"github.com/libp2p/go-libp2p/core/sec/insecure"
 	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/internal/mptcp"
+	tmux "github.com/libp2p/go-libp2p/p2p/muxer/testsuite"
 	"github.com/libp2p/go-libp2p/p2p/muxer/yamux"
 	tptu "github.com/libp2p/go-libp2p/p2p/net/upgrader"
 	ttransport "github.com/libp2p/go-libp2p/p2p/transport/testsuite"
@@ -45,6 +52,27 @@ func TestTcpTransport(t *testing.T) {
 	envReuseportVal = true
 }
 
+func BenchmarkTcpTransport(b *testing.B) {
+	peerA, ia := makeInsecureMuxer(b)
+	_, ib := makeInsecureMuxer(b)
+
+	ua, err := tptu.New(ia, muxers, nil, nil, nil)
+	require.NoError(b, err)
+	ta, err := NewTCPTransport(ua, nil)
+	require.NoError(b, err)
+	ub, err := tptu.New(ib, muxers, nil, nil, nil)
+	require.NoError(b, err)
+	tb, err := NewTCPTransport(ub, nil)
+	require.NoError(b, err)
+
+	zero := ma.StringCast("/ip4/127.0.0.1/tcp/0")
+	for _, link := range tmux.Links {
+		b.Run(link.String(), func(b *testing.B) {
+			ttransport.BenchmarkTransport(b, ta, tb, zero, peerA, link)
+		})
+	}
+}
+
 func TestTcpTransportWithMetrics(t *testing.T) {
 	peerA, ia := makeInsecureMuxer(t)
 	_, ib := makeInsecureMuxer(t)
@@ -62,6 +90,123 @@ func TestTcpTransportWithMetrics(t *testing.T) {
 	ttransport.SubtestTransport(t, ta, tb, zero, peerA)
 }
 
+func TestTcpTransportWithSocketOptions(t *testing.T) {
+	opts := []Option{WithKeepAlivePeriod(10 * time.Second), WithNoDelay(false), WithReadBuffer(1 << 20), WithWriteBuffer(1 << 20)}
+	if runtime.GOOS == "linux" {
+		opts = append(opts, WithUserTimeout(10*time.Second), EnableFastOpen())
+	}
+	for i := 0; i < 2; i++ {
+		peerA, ia := makeInsecureMuxer(t)
+		_, ib := makeInsecureMuxer(t)
+
+		ua, err := tptu.New(ia, muxers, nil, nil, nil)
+		require.NoError(t, err)
+		ta, err := NewTCPTransport(ua, nil, opts...)
+		require.NoError(t, err)
+		ub, err := tptu.New(ib, muxers, nil, nil, nil)
+		require.NoError(t, err)
+		tb, err := NewTCPTransport(ub, nil, opts...)
+		require.NoError(t, err)
+
+		zero := "/ip4/127.0.0.1/tcp/0"
+		ttransport.SubtestTransport(t, ta, tb, zero, peerA)
+
+		envReuseportVal = false
+	}
+	envReuseportVal = true
+}
+
+func TestSocketControl(t *testing.T) {
+	for _, reuse := range []bool{true, false} {
+		t.Run(fmt.Sprintf("reuseport: %t", reuse), func(t *testing.T) {
+			envReuseportVal = reuse
+			defer func() { envReuseportVal = true }()
+
+			var calls atomic.Int32
+			control := func(string, string, syscall.RawConn) error {
+				calls.Add(1)
+				return nil
+			}
+
+			peerA, ia := makeInsecureMuxer(t)
+			_, ib := makeInsecureMuxer(t)
+			ua, err := tptu.New(ia, muxers, nil, nil, nil)
+			require.NoError(t, err)
+			ta, err := NewTCPTransport(ua, nil, WithSocketControl(control))
+			require.NoError(t, err)
+			ub, err := tptu.New(ib, muxers, nil, nil, nil)
+			require.NoError(t, err)
+			tb, err := NewTCPTransport(ub, nil, WithSocketControl(control))
+			require.NoError(t, err)
+
+			ln, err := ta.Listen(ma.StringCast("/ip4/127.0.0.1/tcp/0"))
+			require.NoError(t, err)
+			defer ln.Close()
+			require.EqualValues(t, 1, calls.Load())
+
+			go func() {
+				c, err := ln.Accept()
+				if err == nil {
+					c.Close()
+				}
+			}()
+			c, err := tb.Dial(context.Background(), ln.Multiaddr(), peerA)
+			require.NoError(t, err)
+			defer c.Close()
+			require.EqualValues(t, 2, calls.Load())
+		})
+	}
+}
+
+func TestMultipathTCP(t *testing.T) {
+	if !mptcp.Available {
+		_, err := NewTCPTransport(nil, nil, WithMultipathTCP())
+		require.Error(t, err)
+		t.Skip("Multipath TCP requires Go 1.21")
+	}
+	for _, reuse := range []bool{true, false} {
+		t.Run(fmt.Sprintf("reuseport: %t", reuse), func(t *testing.T) {
+			envReuseportVal = reuse
+			defer func() { envReuseportVal = true }()
+
+			peerA, ia := makeInsecureMuxer(t)
+			_, ib := makeInsecureMuxer(t)
+			ua, err := tptu.New(ia, muxers, nil, nil, nil)
+			require.NoError(t, err)
+			ta, err := NewTCPTransport(ua, nil, WithMultipathTCP())
+			require.NoError(t, err)
+			ub, err := tptu.New(ib, muxers, nil, nil, nil)
+			require.NoError(t, err)
+			tb, err := NewTCPTransport(ub, nil, WithMultipathTCP())
+			require.NoError(t, err)
+
+			ttransport.SubtestTransport(t, ta, tb, "/ip4/127.0.0.1/tcp/0", peerA)
+		})
+	}
+}
+
+func TestSocketOptionErrors(t *testing.T) {
+	var u transport.Upgrader
+	for _, opt := range []Option{WithKeepAlivePeriod(-time.Second), WithReadBuffer(0), WithWriteBuffer(-1), WithUserTimeout(0)} {
+		_, err := NewTCPTransport(u, nil, opt)
+		require.Error(t, err)
+	}
+
+	tpt, err := NewTCPTransport(u, nil, WithSocketControl(func(string, string, syscall.RawConn) error {
+		return errors.New("denied")
+	}))
+	require.NoError(t, err)
+	_, err = tpt.Listen(ma.StringCast("/ip4/127.0.0.1/tcp/0"))
+	require.ErrorContains(t, err, "denied")
+
+	if runtime.GOOS != "linux" {
+		_, err := NewTCPTransport(u, nil, WithUserTimeout(time.Second))
+		require.Error(t, err)
+		_, err = NewTCPTransport(u, nil, EnableFastOpen())
+		require.Error(t, err)
+	}
+}
+
 func TestResourceManager(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -147,7 +292,7 @@ func TestTcpTransportCantListenUtp(t *testing.T) {
 	envReuseportVal = true
 }
 
-func makeInsecureMuxer(t *testing.T) (peer.ID, []sec.SecureTransport) {
+func makeInsecureMuxer(t testing.TB) (peer.ID, []sec.SecureTransport) {
 	t.Helper()
 	priv, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 256)
 	require.NoError(t, err)
@@ -0,0 +1,133 @@
+package ttransport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/transport"
+	tmux "github.com/libp2p/go-libp2p/p2p/muxer/testsuite"
+	"github.com/libp2p/go-libp2p/p2p/net/netem"
+
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// BenchmarkTransport benchmarks a transport over an emulated link.
+// ta listens on maddr, and tb dials it through a proxy that emulates the link. Only transports
+// running directly on top of TCP or UDP are supported.
+//
+// In addition to the benchmarks of the stream muxer test suite, it measures the time it takes
+// to establish a connection, and reports it in multiples of the RTT as rtt/op.
+func BenchmarkTransport(b *testing.B, ta, tb transport.Transport, maddr ma.Multiaddr, peerA peer.ID, link netem.Config) {
+	list, err := ta.Listen(maddr)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer list.Close()
+
+	raddr, closeProxy, err := proxy(list.Multiaddr(), link)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer closeProxy()
+
+	accepted := make(chan transport.CapableConn, 1)
+	go func() {
+		for {
+			c, err := list.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+	dial := func(b *testing.B) (client, server transport.CapableConn) {
+		b.Helper()
+		client, err := tb.Dial(context.Background(), raddr, peerA)
+		if err != nil {
+			b.Fatal(err)
+		}
+		server = <-accepted
+		b.Cleanup(func() {
+			client.Close()
+			server.Close()
+		})
+		return client, server
+	}
+
+	b.Run("Dial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			client, err := tb.Dial(context.Background(), raddr, peerA)
+			if err != nil {
+				b.Fatal(err)
+			}
+			// Wait for the server to accept the connection, so that the handshake is complete.
+			b.StopTimer()
+			client.Close()
+			(<-accepted).Close()
+			b.StartTimer()
+		}
+		if link.RTT > 0 {
+			b.ReportMetric(float64(b.Elapsed())/float64(b.N)/float64(link.RTT), "rtt/op")
+		}
+	})
+	b.Run("Throughput", func(b *testing.B) {
+		client, server := dial(b)
+		tmux.BenchmarkThroughput(b, client, server)
+	})
+	b.Run("StreamOpen", func(b *testing.B) {
+		client, server := dial(b)
+		tmux.BenchmarkStreamOpen(b, client, server, link.RTT)
+	})
+	b.Run("Fairness", func(b *testing.B) {
+		client, server := dial(b)
+		tmux.BenchmarkFairness(b, client, server)
+	})
+}
+
+// proxy starts a proxy for addr that emulates link.
+// It returns the address of the proxy, using the same protocols as addr.
+func proxy(addr ma.Multiaddr, link netem.Config) (ma.Multiaddr, func() error, error) {
+	ip, rest := ma.SplitFirst(addr)
+	port, rest := ma.SplitFirst(rest)
+	if ip == nil || port == nil {
+		return nil, nil, fmt.Errorf("unsupported address: %s", addr)
+	}
+	naddr, err := manet.ToNetAddr(ma.Join(ip, port))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		proxyAddr net.Addr
+		closeFn   func() error
+	)
+	switch a := naddr.(type) {
+	case *net.TCPAddr:
+		p, err := netem.NewTCPProxy(link, a.String())
+		if err != nil {
+			return nil, nil, err
+		}
+		proxyAddr, closeFn = p.Addr(), p.Close
+	case *net.UDPAddr:
+		p, err := netem.NewUDPProxy(link, a)
+		if err != nil {
+			return nil, nil, err
+		}
+		proxyAddr, closeFn = p.Addr(), p.Close
+	default:
+		return nil, nil, fmt.Errorf("unsupported address: %s", addr)
+	}
+	paddr, err := manet.FromNetAddr(proxyAddr)
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+	if rest != nil {
+		paddr = paddr.Encapsulate(rest)
+	}
+	return paddr, closeFn, nil
+}
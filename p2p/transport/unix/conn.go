@@ -0,0 +1,62 @@
+package unix
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// StatPeerCred is the key under which the credentials of the process on the other end of
+// the connection are stored in network.Stats.Extra, if available. The value is a PeerCred.
+const StatPeerCred = "unix.peercred"
+
+// PeerCred are the credentials of the process on the other end of a Unix domain socket.
+// For inbound connections, these are the credentials at the time the peer connected, for
+// outbound connections, the credentials at the time the peer started listening.
+type PeerCred struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// GetPeerCred returns the credentials of the process on the other end of c.
+// It returns false if c is not a Unix domain socket connection, or if peer credentials are
+// not supported on this platform (currently they are only supported on Linux).
+func GetPeerCred(c network.ConnStat) (PeerCred, bool) {
+	cred, ok := c.Stat().Extra[StatPeerCred].(PeerCred)
+	return cred, ok
+}
+
+// conn carries the stats of a Unix domain socket connection, which are picked up by the
+// upgrader.
+type conn struct {
+	manet.Conn
+	stat network.ConnStats
+}
+
+var _ network.ConnStat = &conn{}
+
+func newConn(c manet.Conn, dir network.Direction) *conn {
+	stat := network.ConnStats{
+		Stats: network.Stats{
+			Direction: dir,
+			Opened:    time.Now(),
+			Extra:     make(map[interface{}]interface{}),
+		},
+	}
+	if sc, ok := c.(syscall.Conn); ok {
+		if cred, err := getPeerCred(sc); err == nil {
+			stat.Extra[StatPeerCred] = cred
+		} else {
+			log.Debugw("failed to get peer credentials", "error", err)
+		}
+	}
+	return &conn{Conn: c, stat: stat}
+}
+
+func (c *conn) Stat() network.ConnStats {
+	return c.stat
+}
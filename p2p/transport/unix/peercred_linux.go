@@ -0,0 +1,27 @@
+//go:build linux
+
+package unix
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func getPeerCred(c syscall.Conn) (PeerCred, error) {
+	rc, err := c.SyscallConn()
+	if err != nil {
+		return PeerCred{}, err
+	}
+	var ucred *unix.Ucred
+	var serr error
+	if err := rc.Control(func(fd uintptr) {
+		ucred, serr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return PeerCred{}, err
+	}
+	if serr != nil {
+		return PeerCred{}, serr
+	}
+	return PeerCred{PID: ucred.Pid, UID: ucred.Uid, GID: ucred.Gid}, nil
+}
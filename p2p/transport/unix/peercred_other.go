@@ -0,0 +1,12 @@
+//go:build !linux
+
+package unix
+
+import (
+	"errors"
+	"syscall"
+)
+
+func getPeerCred(syscall.Conn) (PeerCred, error) {
+	return PeerCred{}, errors.New("peer credentials not supported on this platform")
+}
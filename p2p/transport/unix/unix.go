@@ -0,0 +1,149 @@
+// Package unix implements a libp2p transport over Unix domain sockets.
+//
+// It is meant for peers running on the same host, for example an application talking to a
+// libp2p daemon running as a sidecar. Addresses have the form /unix/<path>, e.g.
+// /unix/run/libp2p.sock.
+//
+// Connections are upgraded (secured and multiplexed) by the transport's upgrader, like any
+// other stream transport. When both peers run on the same host, the operating system
+// already isolates the traffic, and an upgrader using the insecure security transport can
+// be passed using WithUpgrader. Access to the socket can then be restricted using file
+// system permissions and, on Linux, by checking the peer's credentials (see GetPeerCred).
+package unix
+
+import (
+	"context"
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/transport"
+
+	logging "github.com/ipfs/go-log/v2"
+	ma "github.com/multiformats/go-multiaddr"
+	mafmt "github.com/multiformats/go-multiaddr-fmt"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+var log = logging.Logger("unix-tpt")
+
+var dialMatcher = mafmt.Base(ma.P_UNIX)
+
+type Option func(*UnixTransport) error
+
+// WithUpgrader sets the upgrader used to secure and multiplex connections, replacing the
+// upgrader passed to NewUnixTransport.
+//
+// This allows using different security settings for same-host peers, e.g. an upgrader
+// configured with the insecure security transport to skip encryption. Both peers need to
+// use the same security protocols.
+func WithUpgrader(u transport.Upgrader) Option {
+	return func(t *UnixTransport) error {
+		if u == nil {
+			return errors.New("upgrader must not be nil")
+		}
+		t.upgrader = u
+		return nil
+	}
+}
+
+// UnixTransport is the Unix domain socket transport.
+type UnixTransport struct {
+	upgrader transport.Upgrader
+	rcmgr    network.ResourceManager
+}
+
+var _ transport.Transport = &UnixTransport{}
+
+// NewUnixTransport creates a new Unix domain socket transport.
+func NewUnixTransport(upgrader transport.Upgrader, rcmgr network.ResourceManager, opts ...Option) (*UnixTransport, error) {
+	if rcmgr == nil {
+		rcmgr = &network.NullResourceManager{}
+	}
+	t := &UnixTransport{
+		upgrader: upgrader,
+		rcmgr:    rcmgr,
+	}
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// CanDial returns true if this transport believes it can dial the given multiaddr.
+func (t *UnixTransport) CanDial(addr ma.Multiaddr) bool {
+	return dialMatcher.Matches(addr)
+}
+
+// Dial dials the peer at the remote address.
+func (t *UnixTransport) Dial(ctx context.Context, raddr ma.Multiaddr, p peer.ID) (transport.CapableConn, error) {
+	connScope, err := t.rcmgr.OpenConnection(network.DirOutbound, true, raddr)
+	if err != nil {
+		log.Debugw("resource manager blocked outgoing connection", "peer", p, "addr", raddr, "error", err)
+		return nil, err
+	}
+	c, err := t.dialWithScope(ctx, raddr, p, connScope)
+	if err != nil {
+		connScope.Done()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (t *UnixTransport) dialWithScope(ctx context.Context, raddr ma.Multiaddr, p peer.ID, connScope network.ConnManagementScope) (transport.CapableConn, error) {
+	if err := connScope.SetPeer(p); err != nil {
+		log.Debugw("resource manager blocked outgoing connection for peer", "peer", p, "addr", raddr, "error", err)
+		return nil, err
+	}
+	var d manet.Dialer
+	c, err := d.DialContext(ctx, raddr)
+	if err != nil {
+		return nil, err
+	}
+	return t.upgrader.Upgrade(ctx, t, newConn(c, network.DirOutbound), network.DirOutbound, p, connScope)
+}
+
+// Listen listens on the given multiaddr.
+// The socket file is removed when the listener is closed.
+func (t *UnixTransport) Listen(laddr ma.Multiaddr) (transport.Listener, error) {
+	if !dialMatcher.Matches(laddr) {
+		return nil, errors.New("can only listen on /unix addresses")
+	}
+	l, err := manet.Listen(laddr)
+	if err != nil {
+		return nil, err
+	}
+	return t.upgrader.UpgradeListener(t, &listener{l}), nil
+}
+
+// Protocols returns the list of terminal protocols this transport can dial.
+func (t *UnixTransport) Protocols() []int {
+	return []int{ma.P_UNIX}
+}
+
+// Proxy always returns false for the Unix transport.
+func (t *UnixTransport) Proxy() bool {
+	return false
+}
+
+func (t *UnixTransport) String() string {
+	return "Unix"
+}
+
+type listener struct {
+	manet.Listener
+}
+
+func (l *listener) Accept() (manet.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	// We're not calling OpenConnection in the resource manager here,
+	// since the manet.Conn doesn't allow us to save the scope.
+	// It's the caller's (usually the p2p/net/upgrader) responsibility
+	// to call the resource manager.
+	return newConn(c, network.DirInbound), nil
+}
@@ -0,0 +1,130 @@
+package unix
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/sec"
+	"github.com/libp2p/go-libp2p/core/sec/insecure"
+	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/p2p/muxer/yamux"
+	tptu "github.com/libp2p/go-libp2p/p2p/net/upgrader"
+	ttransport "github.com/libp2p/go-libp2p/p2p/transport/testsuite"
+
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+)
+
+var muxers = []tptu.StreamMuxer{{ID: "/yamux", Muxer: yamux.DefaultTransport}}
+
+func makeInsecureMuxer(t *testing.T) (peer.ID, []sec.SecureTransport) {
+	t.Helper()
+	priv, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 256)
+	require.NoError(t, err)
+	id, err := peer.IDFromPrivateKey(priv)
+	require.NoError(t, err)
+	return id, []sec.SecureTransport{insecure.NewWithIdentity(insecure.ID, id, priv)}
+}
+
+func makeTransports(t *testing.T) (peerA peer.ID, ta, tb *UnixTransport) {
+	t.Helper()
+	peerA, ia := makeInsecureMuxer(t)
+	_, ib := makeInsecureMuxer(t)
+	ua, err := tptu.New(ia, muxers, nil, nil, nil)
+	require.NoError(t, err)
+	ta, err = NewUnixTransport(ua, nil)
+	require.NoError(t, err)
+	ub, err := tptu.New(ib, muxers, nil, nil, nil)
+	require.NoError(t, err)
+	tb, err = NewUnixTransport(ub, nil)
+	require.NoError(t, err)
+	return peerA, ta, tb
+}
+
+func socketAddr(t *testing.T) ma.Multiaddr {
+	t.Helper()
+	return ma.StringCast("/unix" + filepath.Join(t.TempDir(), "libp2p.sock"))
+}
+
+func TestUnixTransport(t *testing.T) {
+	peerA, ta, tb := makeTransports(t)
+	addr := socketAddr(t)
+	for _, f := range ttransport.Subtests {
+		name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+		t.Run(name, func(t *testing.T) {
+			// This test listens on the same address multiple times concurrently, which only
+			// works for transports that allocate a new port when listening on port 0.
+			if reflect.ValueOf(f).Pointer() == reflect.ValueOf(ttransport.SubtestStressManyConn10Stream50Msg).Pointer() {
+				t.Skip("can't listen on the same Unix socket concurrently")
+			}
+			f(t, ta, tb, addr, peerA)
+		})
+	}
+}
+
+func TestCanDial(t *testing.T) {
+	var u transport.Upgrader
+	tpt, err := NewUnixTransport(u, nil)
+	require.NoError(t, err)
+
+	require.True(t, tpt.CanDial(ma.StringCast("/unix/tmp/libp2p.sock")))
+	require.False(t, tpt.CanDial(ma.StringCast("/ip4/127.0.0.1/tcp/1234")))
+
+	_, err = tpt.Listen(ma.StringCast("/ip4/127.0.0.1/tcp/0"))
+	require.Error(t, err)
+}
+
+func TestSocketRemovedOnClose(t *testing.T) {
+	_, ta, _ := makeTransports(t)
+	addr := socketAddr(t)
+	l, err := ta.Listen(addr)
+	require.NoError(t, err)
+	path, err := addr.ValueForProtocol(ma.P_UNIX)
+	require.NoError(t, err)
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	require.NoError(t, l.Close())
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestPeerCred(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on Linux")
+	}
+	peerA, ta, tb := makeTransports(t)
+	l, err := ta.Listen(socketAddr(t))
+	require.NoError(t, err)
+	defer l.Close()
+
+	accepted := make(chan transport.CapableConn, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		accepted <- c
+	}()
+	c, err := tb.Dial(context.Background(), l.Multiaddr(), peerA)
+	require.NoError(t, err)
+	defer c.Close()
+	sc := <-accepted
+	defer sc.Close()
+
+	for _, c := range []transport.CapableConn{c, sc} {
+		cred, ok := GetPeerCred(c.(network.ConnStat))
+		require.True(t, ok)
+		require.Equal(t, int32(os.Getpid()), cred.PID)
+		require.Equal(t, uint32(os.Getuid()), cred.UID)
+		require.Equal(t, uint32(os.Getgid()), cred.GID)
+	}
+}
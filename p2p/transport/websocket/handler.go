@@ -0,0 +1,173 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+
+	ws "github.com/gorilla/websocket"
+)
+
+// ListenHandler is an http.Handler that accepts WebSocket connections on behalf
+// of the transport, allowing it to share a port with an existing net/http server.
+//
+// Install the ListenHandler as the server's handler (or mount it on a mux at
+// "/"), pass it to the transport using WithListenHandler, and listen on
+// ListenHandler.Multiaddr(). WebSocket upgrade requests are handed to the
+// transport, all other requests are passed on to the next handler. This way
+// the WebSocket transport and a libp2phttp.Host (using its ServeMux as next)
+// can be served from a single HTTP(S) port.
+//
+// TLS is terminated by the HTTP server, the transport's TLS config is not used.
+type ListenHandler struct {
+	laddr ma.Multiaddr
+	isWss bool
+	next  http.Handler
+
+	mx sync.Mutex
+	l  *listener
+}
+
+var _ http.Handler = (*ListenHandler)(nil)
+
+// NewListenHandler creates a new ListenHandler for srv. The listen multiaddr
+// is derived from the server config, see ServerMultiaddr. next may be nil, in
+// which case requests that are not WebSocket upgrade requests are answered
+// with a 404.
+func NewListenHandler(srv *http.Server, next http.Handler) (*ListenHandler, error) {
+	laddr, err := ServerMultiaddr(srv)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseWebsocketMultiaddr(laddr)
+	if err != nil {
+		return nil, err
+	}
+	if next == nil {
+		next = http.NotFoundHandler()
+	}
+	return &ListenHandler{
+		laddr: laddr,
+		isWss: parsed.isWSS,
+		next:  next,
+	}, nil
+}
+
+// ServerMultiaddr returns the WebSocket multiaddr that srv is reachable at.
+// The host and port are taken from srv.Addr. A server with a TLSConfig is
+// assumed to be serving TLS, resulting in a /tls/ws multiaddr, otherwise a
+// /ws multiaddr is returned. The port must be set explicitly, since the
+// port the server ends up listening on can't be determined in advance.
+func ServerMultiaddr(srv *http.Server) (ma.Multiaddr, error) {
+	host, portStr, err := net.SplitHostPort(srv.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server address %q: %w", srv.Addr, err)
+	}
+	port, err := net.LookupPort("tcp", portStr)
+	if err != nil {
+		return nil, err
+	}
+	if port == 0 {
+		return nil, errors.New("server address doesn't specify a port")
+	}
+
+	var tcpma ma.Multiaddr
+	if host == "" {
+		tcpma, err = manet.FromNetAddr(&net.TCPAddr{IP: net.IPv4zero, Port: port})
+	} else if ip := net.ParseIP(host); ip != nil {
+		tcpma, err = manet.FromNetAddr(&net.TCPAddr{IP: ip, Port: port})
+	} else {
+		tcpma, err = ma.NewMultiaddr("/dns/" + host + "/tcp/" + strconv.Itoa(port))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if srv.TLSConfig != nil {
+		return tcpma.Encapsulate(tlsWsComponent), nil
+	}
+	return tcpma.Encapsulate(wsComponent), nil
+}
+
+// Multiaddr returns the multiaddr the transport needs to listen on to accept
+// connections through this handler.
+func (h *ListenHandler) Multiaddr() ma.Multiaddr {
+	return h.laddr
+}
+
+func (h *ListenHandler) matches(a ma.Multiaddr) bool {
+	parsed, err := parseWebsocketMultiaddr(a)
+	if err != nil {
+		return false
+	}
+	own, err := parseWebsocketMultiaddr(h.laddr)
+	if err != nil {
+		return false
+	}
+	return parsed.isWSS == own.isWSS && parsed.restMultiaddr.Equal(own.restMultiaddr)
+}
+
+func (h *ListenHandler) listen() (*listener, error) {
+	parsed, err := parseWebsocketMultiaddr(h.laddr)
+	if err != nil {
+		return nil, err
+	}
+	netaddr, err := manet.ToNetAddr(parsed.restMultiaddr)
+	if err != nil {
+		// DNS multiaddrs can't be converted to a net.Addr.
+		_, hostport, err := manet.DialArgs(parsed.restMultiaddr)
+		if err != nil {
+			return nil, err
+		}
+		netaddr = NewAddrWithScheme(hostport, h.isWss)
+	}
+
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if h.l != nil {
+		return nil, fmt.Errorf("already listening on %s", h.laddr)
+	}
+	l := &listener{
+		isWss:    h.isWss,
+		laddr:    h.laddr,
+		netaddr:  netaddr,
+		incoming: make(chan *Conn),
+		closed:   make(chan struct{}),
+	}
+	l.onClose = func() {
+		h.mx.Lock()
+		if h.l == l {
+			h.l = nil
+		}
+		h.mx.Unlock()
+	}
+	h.l = l
+	return l, nil
+}
+
+func (h *ListenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ws.IsWebSocketUpgrade(r) {
+		h.mx.Lock()
+		l := h.l
+		h.mx.Unlock()
+		if l != nil {
+			l.ServeHTTP(w, r)
+			return
+		}
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// WithListenHandler makes the transport accept connections through h when
+// listening on h.Multiaddr(), instead of opening its own socket.
+func WithListenHandler(h *ListenHandler) Option {
+	return func(t *WebsocketTransport) error {
+		t.listenHandlers = append(t.listenHandlers, h)
+		return nil
+	}
+}
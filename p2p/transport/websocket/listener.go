@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/libp2p/go-libp2p/core/transport"
 
@@ -14,16 +15,20 @@ import (
 )
 
 type listener struct {
+	// nl is nil if connections are accepted through a ListenHandler.
 	nl     net.Listener
 	server http.Server
 	// The Go standard library sets the http.Server.TLSConfig no matter if this is a WS or WSS,
 	// so we can't rely on checking if server.TLSConfig is set.
 	isWss bool
 
-	laddr ma.Multiaddr
+	laddr   ma.Multiaddr
+	netaddr net.Addr
 
-	closed   chan struct{}
-	incoming chan *Conn
+	closeOnce sync.Once
+	onClose   func()
+	closed    chan struct{}
+	incoming  chan *Conn
 }
 
 func (pwma *parsedWebsocketMultiaddr) toMultiaddr() ma.Multiaddr {
@@ -75,6 +80,7 @@ func newListener(a ma.Multiaddr, tlsConf *tls.Config) (*listener, error) {
 	ln := &listener{
 		nl:       nl,
 		laddr:    parsed.toMultiaddr(),
+		netaddr:  nl.Addr(),
 		incoming: make(chan *Conn),
 		closed:   make(chan struct{}),
 	}
@@ -130,10 +136,17 @@ func (l *listener) Accept() (manet.Conn, error) {
 }
 
 func (l *listener) Addr() net.Addr {
-	return l.nl.Addr()
+	return l.netaddr
 }
 
 func (l *listener) Close() error {
+	if l.nl == nil {
+		l.closeOnce.Do(func() {
+			l.onClose()
+			close(l.closed)
+		})
+		return nil
+	}
 	l.server.Close()
 	err := l.nl.Close()
 	<-l.closed
@@ -87,6 +87,8 @@ type WebsocketTransport struct {
 
 	tlsClientConf *tls.Config
 	tlsConf       *tls.Config
+
+	listenHandlers []*ListenHandler
 }
 
 var _ transport.Transport = (*WebsocketTransport)(nil)
@@ -229,6 +231,11 @@ func (t *WebsocketTransport) maDial(ctx context.Context, raddr ma.Multiaddr) (ma
 }
 
 func (t *WebsocketTransport) maListen(a ma.Multiaddr) (manet.Listener, error) {
+	for _, h := range t.listenHandlers {
+		if h.matches(a) {
+			return h.listen()
+		}
+	}
 	l, err := newListener(a, t.tlsConf)
 	if err != nil {
 		return nil, err
@@ -548,3 +548,86 @@ func TestResolveMultiaddr(t *testing.T) {
 		})
 	}
 }
+
+func TestListenHandler(t *testing.T) {
+	nl, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("hello")) })
+	srv := &http.Server{Addr: nl.Addr().String()}
+	h, err := NewListenHandler(srv, mux)
+	require.NoError(t, err)
+	srv.Handler = h
+	go srv.Serve(nl)
+	defer srv.Close()
+
+	serverID, serverUpgrader := newUpgrader(t)
+	server, err := New(serverUpgrader, &network.NullResourceManager{}, WithListenHandler(h))
+	require.NoError(t, err)
+	l, err := server.Listen(h.Multiaddr())
+	require.NoError(t, err)
+	require.True(t, l.Multiaddr().Equal(h.Multiaddr()))
+
+	// listening on the same address twice is not possible
+	_, err = server.Listen(h.Multiaddr())
+	require.Error(t, err)
+
+	// plain HTTP requests are passed on to the mux
+	resp, err := http.Get("http://" + nl.Addr().String() + "/hello")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(body))
+
+	_, clientUpgrader := newUpgrader(t)
+	client, err := New(clientUpgrader, &network.NullResourceManager{})
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c, err := l.Accept()
+		require.NoError(t, err)
+		defer c.Close()
+		str, err := c.AcceptStream()
+		require.NoError(t, err)
+		defer str.Close()
+		out, err := io.ReadAll(str)
+		require.NoError(t, err)
+		require.Equal(t, "foobar", string(out))
+	}()
+	conn, err := client.Dial(context.Background(), l.Multiaddr(), serverID)
+	require.NoError(t, err)
+	defer conn.Close()
+	str, err := conn.OpenStream(context.Background())
+	require.NoError(t, err)
+	_, err = str.Write([]byte("foobar"))
+	require.NoError(t, err)
+	require.NoError(t, str.Close())
+	<-done
+
+	// after closing the listener, the address can be reused
+	require.NoError(t, l.Close())
+	l, err = server.Listen(h.Multiaddr())
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+}
+
+func TestServerMultiaddr(t *testing.T) {
+	for _, tc := range []struct {
+		srv      *http.Server
+		expected string
+	}{
+		{srv: &http.Server{Addr: "127.0.0.1:8080"}, expected: "/ip4/127.0.0.1/tcp/8080/ws"},
+		{srv: &http.Server{Addr: ":https", TLSConfig: &tls.Config{}}, expected: "/ip4/0.0.0.0/tcp/443/tls/ws"},
+		{srv: &http.Server{Addr: "[::1]:1234"}, expected: "/ip6/::1/tcp/1234/ws"},
+		{srv: &http.Server{Addr: "example.com:443", TLSConfig: &tls.Config{}}, expected: "/dns/example.com/tcp/443/tls/ws"},
+	} {
+		addr, err := ServerMultiaddr(tc.srv)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, addr.String())
+	}
+
+	_, err := ServerMultiaddr(&http.Server{Addr: "127.0.0.1:0"})
+	require.Error(t, err)
+}
@@ -5,12 +5,17 @@ import (
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/benbjohnson/clock"
 	ic "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	ds "github.com/ipfs/go-datastore"
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/multiformats/go-multihash"
 )
@@ -40,13 +45,45 @@ func newCertConfig(key ic.PrivKey, start, end time.Time) (*certConfig, error) {
 	}, nil
 }
 
+// CertHash is the hash of a certificate used by the transport, together with the certificate's validity period.
+type CertHash struct {
+	Hash      multihash.Multihash
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// certStoreNamespace is the datastore namespace certificate state is persisted in.
+const certStoreNamespace = "/libp2p/webtransport/certs"
+
+// storedCerts is the persisted certificate state.
+// Since certificates are derived deterministically from the host key and their start time,
+// it's sufficient to store the validity periods (as Unix milliseconds).
+type storedCerts struct {
+	Last    *storedCert `json:",omitempty"`
+	Current storedCert
+	Next    storedCert
+}
+
+type storedCert struct {
+	Start, End int64
+}
+
+func newStoredCert(c *certConfig) storedCert {
+	return storedCert{Start: c.Start().UnixMilli(), End: c.End().UnixMilli()}
+}
+
 // Certificate renewal logic:
 //  1. On startup, we generate one cert that is valid from now (-1h, to allow for clock skew), and another
 //     cert that is valid from the expiry date of the first certificate (again, with allowance for clock skew).
 //  2. Once we reach 1h before expiry of the first certificate, we switch over to the second certificate.
 //     At the same time, we stop advertising the certhash of the first cert and generate the next cert.
+//
+// If a datastore is used, the validity periods of the certificates are persisted after every rotation,
+// so that a restarted node continues using the same certificates.
 type certManager struct {
 	clock     clock.Clock
+	store     ds.Datastore // may be nil
+	storeKey  ds.Key
 	ctx       context.Context
 	ctxCancel context.CancelFunc
 	refCount  sync.WaitGroup
@@ -60,9 +97,24 @@ type certManager struct {
 	serializedCertHashes [][]byte
 }
 
-func newCertManager(hostKey ic.PrivKey, clock clock.Clock) (*certManager, error) {
-	m := &certManager{clock: clock}
+func newCertManager(hostKey ic.PrivKey, clock clock.Clock, store ds.Datastore) (*certManager, error) {
+	m := &certManager{clock: clock, store: store}
 	m.ctx, m.ctxCancel = context.WithCancel(context.Background())
+	if store != nil {
+		id, err := peer.IDFromPrivateKey(hostKey)
+		if err != nil {
+			return nil, err
+		}
+		m.storeKey = ds.NewKey(certStoreNamespace).ChildString(id.String())
+		restored, err := m.restore(hostKey)
+		if err != nil {
+			log.Debugw("failed to restore certificates", "error", err)
+		}
+		if restored {
+			m.background(hostKey)
+			return m, nil
+		}
+	}
 	if err := m.init(hostKey); err != nil {
 		return nil, err
 	}
@@ -121,7 +173,74 @@ func (m *certManager) rollConfig(hostKey ic.PrivKey) error {
 	if err := m.cacheSerializedCertHashes(); err != nil {
 		return err
 	}
-	return m.cacheAddrComponent()
+	if err := m.cacheAddrComponent(); err != nil {
+		return err
+	}
+	if m.store != nil {
+		if err := m.persist(); err != nil {
+			log.Errorw("persisting certificates failed", "error", err)
+		}
+	}
+	return nil
+}
+
+func (m *certManager) persist() error {
+	stored := storedCerts{
+		Current: newStoredCert(m.currentConfig),
+		Next:    newStoredCert(m.nextConfig),
+	}
+	if m.lastConfig != nil {
+		last := newStoredCert(m.lastConfig)
+		stored.Last = &last
+	}
+	b, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return m.store.Put(m.ctx, m.storeKey, b)
+}
+
+// restore loads the certificates from the datastore.
+// It returns false if no certificates were stored, or if the stored current certificate has already expired.
+func (m *certManager) restore(hostKey ic.PrivKey) (bool, error) {
+	b, err := m.store.Get(m.ctx, m.storeKey)
+	if err != nil {
+		if errors.Is(err, ds.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	var stored storedCerts
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return false, err
+	}
+	if !m.clock.Now().Before(time.UnixMilli(stored.Current.End).Add(-clockSkewAllowance)) {
+		return false, nil
+	}
+	if stored.Next.Start != time.UnixMilli(stored.Current.End).Add(-2*clockSkewAllowance).UnixMilli() {
+		return false, errors.New("inconsistent certificate state")
+	}
+	newConfig := func(c storedCert) (*certConfig, error) {
+		return newCertConfig(hostKey, time.UnixMilli(c.Start), time.UnixMilli(c.End))
+	}
+	if stored.Last != nil {
+		if m.lastConfig, err = newConfig(*stored.Last); err != nil {
+			return false, err
+		}
+	}
+	if m.currentConfig, err = newConfig(stored.Current); err != nil {
+		return false, err
+	}
+	if m.nextConfig, err = newConfig(stored.Next); err != nil {
+		return false, err
+	}
+	if err := m.cacheSerializedCertHashes(); err != nil {
+		return false, err
+	}
+	if err := m.cacheAddrComponent(); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 func (m *certManager) background(hostKey ic.PrivKey) {
@@ -165,6 +284,27 @@ func (m *certManager) AddrComponent() ma.Multiaddr {
 	return m.addrComp
 }
 
+// CertHashes returns the hashes of all certificates that haven't expired yet.
+func (m *certManager) CertHashes() []CertHash {
+	now := m.clock.Now()
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	hashes := make([]CertHash, 0, 3)
+	for _, c := range []*certConfig{m.lastConfig, m.currentConfig, m.nextConfig} {
+		if c == nil || !c.End().After(now) {
+			continue
+		}
+		h, err := multihash.Encode(c.sha256[:], multihash.SHA2_256)
+		if err != nil {
+			log.Errorw("failed to encode certificate hash", "error", err)
+			continue
+		}
+		hashes = append(hashes, CertHash{Hash: h, NotBefore: c.Start(), NotAfter: c.End()})
+	}
+	return hashes
+}
+
 func (m *certManager) SerializedCertHashes() [][]byte {
 	return m.serializedCertHashes
 }
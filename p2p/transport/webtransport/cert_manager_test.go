@@ -12,6 +12,8 @@ import (
 	"github.com/libp2p/go-libp2p/core/test"
 
 	"github.com/benbjohnson/clock"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/multiformats/go-multibase"
 	"github.com/multiformats/go-multihash"
@@ -46,7 +48,7 @@ func TestInitialCert(t *testing.T) {
 	cl.Add(1234567 * time.Hour)
 	priv, _, err := test.RandTestKeyPair(crypto.Ed25519, 256)
 	require.NoError(t, err)
-	m, err := newCertManager(priv, cl)
+	m, err := newCertManager(priv, cl, nil)
 	require.NoError(t, err)
 	defer m.Close()
 
@@ -70,7 +72,7 @@ func TestCertRenewal(t *testing.T) {
 	cl.Add(time.Hour * 24 * 365)
 	priv, _, err := test.SeededTestKeyPair(crypto.Ed25519, 256, 0)
 	require.NoError(t, err)
-	m, err := newCertManager(priv, cl)
+	m, err := newCertManager(priv, cl, nil)
 	require.NoError(t, err)
 	defer m.Close()
 
@@ -120,7 +122,7 @@ func TestDeterministicCertsAcrossReboots(t *testing.T) {
 			cl := clock.NewMock()
 			priv, _, err := test.SeededTestKeyPair(crypto.Ed25519, 256, 0)
 			require.NoError(t, err)
-			m, err := newCertManager(priv, cl)
+			m, err := newCertManager(priv, cl, nil)
 			require.NoError(t, err)
 			defer m.Close()
 
@@ -132,7 +134,7 @@ func TestDeterministicCertsAcrossReboots(t *testing.T) {
 
 			cl.Add(time.Hour)
 			// reboot
-			m, err = newCertManager(priv, cl)
+			m, err = newCertManager(priv, cl, nil)
 			require.NoError(t, err)
 			defer m.Close()
 
@@ -175,3 +177,67 @@ func TestGetCurrentBucketStartTimeIsWithinBounds(t *testing.T) {
 		return !bucketStart.After(start.Add(-clockSkewAllowance)) || bucketStart.Equal(start.Add(-clockSkewAllowance))
 	}, nil))
 }
+
+func TestCertsPersistedAcrossReboots(t *testing.T) {
+	cl := clock.NewMock()
+	cl.Add(time.Hour * 24 * 365)
+	priv, _, err := test.SeededTestKeyPair(crypto.Ed25519, 256, 0)
+	require.NoError(t, err)
+	store := dssync.MutexWrap(ds.NewMapDatastore())
+
+	m, err := newCertManager(priv, cl, store)
+	require.NoError(t, err)
+	firstConf := m.GetConfig()
+	// wait for the certificates to be rotated
+	cl.Set(m.currentConfig.End().Add(-clockSkewAllowance + time.Second))
+	require.Eventually(t, func() bool { return m.GetConfig() != firstConf }, 200*time.Millisecond, 10*time.Millisecond)
+	m.mx.RLock()
+	oldHashes := m.serializedCertHashes
+	oldAddrComp := m.addrComp
+	m.mx.RUnlock()
+	require.Len(t, oldHashes, 3)
+	require.NoError(t, m.Close())
+
+	// reboot
+	m, err = newCertManager(priv, cl, store)
+	require.NoError(t, err)
+	defer m.Close()
+	require.Equal(t, oldHashes, m.serializedCertHashes)
+	require.True(t, oldAddrComp.Equal(m.AddrComponent()))
+
+	hashes := m.CertHashes()
+	require.Len(t, hashes, 3)
+	for i, h := range hashes {
+		require.Equal(t, []byte(h.Hash), oldHashes[i])
+		require.True(t, h.NotAfter.After(cl.Now()))
+	}
+	require.True(t, hashes[0].NotBefore.Before(hashes[1].NotBefore))
+	require.True(t, hashes[1].NotBefore.Before(hashes[2].NotBefore))
+
+	// once the last certificate expires, it's not returned any more
+	cl.Set(hashes[0].NotAfter)
+	require.Len(t, m.CertHashes(), 2)
+}
+
+func TestExpiredCertsNotRestored(t *testing.T) {
+	cl := clock.NewMock()
+	cl.Add(time.Hour * 24 * 365)
+	priv, _, err := test.SeededTestKeyPair(crypto.Ed25519, 256, 0)
+	require.NoError(t, err)
+	store := dssync.MutexWrap(ds.NewMapDatastore())
+
+	m, err := newCertManager(priv, cl, store)
+	require.NoError(t, err)
+	require.NoError(t, m.Close())
+
+	cl.Add(3 * certValidity)
+	m, err = newCertManager(priv, cl, store)
+	require.NoError(t, err)
+	defer m.Close()
+	for _, h := range m.CertHashes() {
+		require.True(t, h.NotAfter.After(cl.Now()))
+	}
+	conf := m.GetConfig()
+	require.True(t, conf.Certificates[0].Leaf.NotBefore.Before(cl.Now()))
+	require.True(t, conf.Certificates[0].Leaf.NotAfter.After(cl.Now()))
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/sec"
 	tpt "github.com/libp2p/go-libp2p/core/transport"
 	"github.com/libp2p/go-libp2p/p2p/security/noise"
 	"github.com/libp2p/go-libp2p/p2p/security/noise/pb"
@@ -22,9 +23,8 @@ const queueLen = 16
 const handshakeTimeout = 10 * time.Second
 
 type listener struct {
-	transport       *transport
-	isStaticTLSConf bool
-	reuseListener   quicreuse.Listener
+	transport     *transport
+	reuseListener quicreuse.Listener
 
 	server webtransport.Server
 
@@ -41,20 +41,19 @@ type listener struct {
 
 var _ tpt.Listener = &listener{}
 
-func newListener(reuseListener quicreuse.Listener, t *transport, isStaticTLSConf bool) (tpt.Listener, error) {
+func newListener(reuseListener quicreuse.Listener, t *transport) (tpt.Listener, error) {
 	localMultiaddr, err := toWebtransportMultiaddr(reuseListener.Addr())
 	if err != nil {
 		return nil, err
 	}
 
 	ln := &listener{
-		reuseListener:   reuseListener,
-		transport:       t,
-		isStaticTLSConf: isStaticTLSConf,
-		queue:           make(chan tpt.CapableConn, queueLen),
-		serverClosed:    make(chan struct{}),
-		addr:            reuseListener.Addr(),
-		multiaddr:       localMultiaddr,
+		reuseListener: reuseListener,
+		transport:     t,
+		queue:         make(chan tpt.CapableConn, queueLen),
+		serverClosed:  make(chan struct{}),
+		addr:          reuseListener.Addr(),
+		multiaddr:     localMultiaddr,
 		server: webtransport.Server{
 			CheckOrigin: func(r *http.Request) bool { return true },
 		},
@@ -174,7 +173,7 @@ func (l *listener) handshake(ctx context.Context, sess *webtransport.Session) (*
 		return nil, err
 	}
 	var earlyData [][]byte
-	if !l.isStaticTLSConf {
+	if l.transport.certManager != nil {
 		earlyData = l.transport.certManager.SerializedCertHashes()
 	}
 
@@ -185,6 +184,9 @@ func (l *listener) handshake(ctx context.Context, sess *webtransport.Session) (*
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Noise session: %w", err)
 	}
+	if l.transport.credentials != nil {
+		ctx = sec.WithHandshakeCredentials(ctx, l.transport.credentials)
+	}
 	c, err := n.SecureInbound(ctx, &webtransportStream{Stream: str, wsess: sess}, "")
 	if err != nil {
 		return nil, err
@@ -17,12 +17,14 @@ import (
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/pnet"
+	"github.com/libp2p/go-libp2p/core/sec"
 	tpt "github.com/libp2p/go-libp2p/core/transport"
 	"github.com/libp2p/go-libp2p/p2p/security/noise"
 	"github.com/libp2p/go-libp2p/p2p/security/noise/pb"
 	"github.com/libp2p/go-libp2p/p2p/transport/quicreuse"
 
 	"github.com/benbjohnson/clock"
+	ds "github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log/v2"
 	ma "github.com/multiformats/go-multiaddr"
 	manet "github.com/multiformats/go-multiaddr/net"
@@ -60,6 +62,40 @@ func WithTLSClientConfig(c *tls.Config) Option {
 	}
 }
 
+// WithTLSConfig sets a tls.Config used for serving clients that indicate a server name (SNI) in
+// their ClientHello, e.g. browsers connecting to a /dns multiaddr. This allows using a regular
+// CA-signed certificate, for example one obtained from an ACME CA using tls.Config.GetCertificate.
+// Addresses containing a domain name are advertised without certificate hashes.
+//
+// Clients connecting without SNI (i.e. by IP address) are still served the self-signed certificates,
+// unless they are disabled using DisableSelfSignedCertificates.
+func WithTLSConfig(c *tls.Config) Option {
+	return func(t *transport) error {
+		t.staticTLSConf = c
+		return nil
+	}
+}
+
+// DisableSelfSignedCertificates disables the generation of self-signed certificates. All clients are
+// served using the tls.Config passed to WithTLSConfig, and addresses are advertised without certificate
+// hashes.
+func DisableSelfSignedCertificates() Option {
+	return func(t *transport) error {
+		t.noSelfSignedCerts = true
+		return nil
+	}
+}
+
+// WithCertStore persists the state of the self-signed certificates in a datastore.
+// This way, the same certificates (and certificate hashes) are used after a restart,
+// even around the time certificates are rotated.
+func WithCertStore(store ds.Datastore) Option {
+	return func(t *transport) error {
+		t.certStore = store
+		return nil
+	}
+}
+
 type transport struct {
 	privKey ic.PrivKey
 	pid     peer.ID
@@ -68,6 +104,7 @@ type transport struct {
 	connManager *quicreuse.ConnManager
 	rcmgr       network.ResourceManager
 	gater       connmgr.ConnectionGater
+	credentials sec.HandshakeCredentials // set if the connection gater exchanges handshake credentials
 
 	listenOnce     sync.Once
 	listenOnceErr  error
@@ -76,6 +113,9 @@ type transport struct {
 	staticTLSConf  *tls.Config
 	tlsClientConf  *tls.Config
 
+	noSelfSignedCerts bool
+	certStore         ds.Datastore
+
 	noise *noise.Transport
 
 	connMx sync.Mutex
@@ -98,11 +138,13 @@ func New(key ic.PrivKey, psk pnet.PSK, connManager *quicreuse.ConnManager, gater
 	if err != nil {
 		return nil, err
 	}
+	credentials, _ := gater.(sec.HandshakeCredentials)
 	t := &transport{
 		pid:         id,
 		privKey:     key,
 		rcmgr:       rcmgr,
 		gater:       gater,
+		credentials: credentials,
 		clock:       clock.New(),
 		connManager: connManager,
 		conns:       map[uint64]*conn{},
@@ -112,6 +154,13 @@ func New(key ic.PrivKey, psk pnet.PSK, connManager *quicreuse.ConnManager, gater
 			return nil, err
 		}
 	}
+	if t.noSelfSignedCerts && t.staticTLSConf == nil {
+		return nil, errors.New("self-signed certificates can only be disabled when a TLS config is set")
+	}
+	if t.staticTLSConf != nil {
+		t.staticTLSConf = t.staticTLSConf.Clone()
+		t.staticTLSConf.NextProtos = []string{http3.NextProtoH3}
+	}
 	n, err := noise.New(noise.ID, key, nil)
 	if err != nil {
 		return nil, err
@@ -147,10 +196,6 @@ func (t *transport) dialWithScope(ctx context.Context, raddr ma.Multiaddr, p pee
 		return nil, err
 	}
 
-	if len(certHashes) == 0 {
-		return nil, errors.New("can't dial webtransport without certhashes")
-	}
-
 	sni, _ := extractSNI(raddr)
 
 	if err := scope.SetPeer(p); err != nil {
@@ -186,6 +231,9 @@ func (t *transport) dial(ctx context.Context, addr ma.Multiaddr, url, sni string
 	}
 	tlsConf.NextProtos = append(tlsConf.NextProtos, http3.NextProtoH3)
 
+	// Without certificate hashes, the server's certificate is verified using the regular WebPKI
+	// verification, based on the RootCAs of the tls.Config passed to WithTLSClientConfig.
+
 	if sni != "" {
 		tlsConf.ServerName = sni
 	}
@@ -239,7 +287,7 @@ func (t *transport) upgrade(ctx context.Context, sess *webtransport.Session, p p
 	// We will verify that the certhashes we used to dial is a subset of the certhashes we received from the server.
 	var verified bool
 	n, err := t.noise.WithSessionOptions(noise.EarlyData(newEarlyDataReceiver(func(b *pb.NoiseExtensions) error {
-		decodedCertHashes, err := decodeCertHashesFromProtobuf(b.WebtransportCerthashes)
+		decodedCertHashes, err := decodeCertHashesFromProtobuf(b.GetWebtransportCerthashes())
 		if err != nil {
 			return err
 		}
@@ -261,6 +309,9 @@ func (t *transport) upgrade(ctx context.Context, sess *webtransport.Session, p p
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Noise transport: %w", err)
 	}
+	if t.credentials != nil {
+		ctx = sec.WithHandshakeCredentials(ctx, t.credentials)
+	}
 	c, err := n.SecureOutbound(ctx, &webtransportStream{Stream: str, wsess: sess}, p)
 	if err != nil {
 		return nil, err
@@ -302,30 +353,35 @@ func (t *transport) Listen(laddr ma.Multiaddr) (tpt.Listener, error) {
 	if certhashCount > 0 {
 		return nil, fmt.Errorf("cannot listen on a specific certhash non-WebTransport addr: %s", laddr)
 	}
-	if t.staticTLSConf == nil {
+	if !t.noSelfSignedCerts {
 		t.listenOnce.Do(func() {
-			t.certManager, t.listenOnceErr = newCertManager(t.privKey, t.clock)
+			t.certManager, t.listenOnceErr = newCertManager(t.privKey, t.clock, t.certStore)
 			t.hasCertManager.Store(true)
 		})
 		if t.listenOnceErr != nil {
 			return nil, t.listenOnceErr
 		}
-	} else {
-		return nil, errors.New("static TLS config not supported on WebTransport")
 	}
-	tlsConf := t.staticTLSConf.Clone()
-	if tlsConf == nil {
-		tlsConf = &tls.Config{GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
-			return t.certManager.GetConfig(), nil
-		}}
+	tlsConf := &tls.Config{
+		GetConfigForClient: t.getConfigForClient,
+		NextProtos:         []string{http3.NextProtoH3},
 	}
-	tlsConf.NextProtos = append(tlsConf.NextProtos, http3.NextProtoH3)
 
 	ln, err := t.connManager.ListenQUIC(laddr, tlsConf, t.allowWindowIncrease)
 	if err != nil {
 		return nil, err
 	}
-	return newListener(ln, t, t.staticTLSConf != nil)
+	return newListener(ln, t)
+}
+
+// getConfigForClient selects the certificate served to a client. Clients that send a server name
+// are served the static TLS config (if any), since they are dialing a domain name and expect a
+// certificate valid for that name. All other clients are served the self-signed certificate.
+func (t *transport) getConfigForClient(chi *tls.ClientHelloInfo) (*tls.Config, error) {
+	if t.staticTLSConf != nil && (t.certManager == nil || chi.ServerName != "") {
+		return t.staticTLSConf, nil
+	}
+	return t.certManager.GetConfig(), nil
 }
 
 func (t *transport) Protocols() []int {
@@ -411,9 +467,26 @@ func (t *transport) Resolve(_ context.Context, maddr ma.Multiaddr) ([]ma.Multiad
 
 // AddCertHashes adds the current certificate hashes to a multiaddress.
 // If called before Listen, it's a no-op.
+// When a static TLS config is used, multiaddrs containing a domain name are returned unchanged,
+// since clients dialing them will be served the static certificate.
 func (t *transport) AddCertHashes(m ma.Multiaddr) (ma.Multiaddr, bool) {
+	if t.staticTLSConf != nil {
+		if sni, _ := extractSNI(m); sni != "" {
+			return m, true
+		}
+	}
 	if !t.hasCertManager.Load() {
 		return m, false
 	}
 	return m.Encapsulate(t.certManager.AddrComponent()), true
 }
+
+// CertHashes returns the hashes of the self-signed certificates that are currently valid,
+// together with their validity periods. This includes certificates that will only become
+// valid in the future. If called before Listen, it returns nil.
+func (t *transport) CertHashes() []CertHash {
+	if !t.hasCertManager.Load() {
+		return nil
+	}
+	return t.certManager.CertHashes()
+}
@@ -3,16 +3,21 @@ package libp2pwebtransport_test
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net"
 	"os"
 	"runtime"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"testing/quick"
@@ -827,3 +832,129 @@ func TestServerRotatesCertCorrectlyAfterSteps(t *testing.T) {
 		require.True(t, found, "Failed after hour: %v", i)
 	}
 }
+
+// generateCASignedTLSConfig creates a CA and a certificate for localhost signed by that CA.
+// It returns a server tls.Config using the certificate, and a certificate pool containing the CA.
+func generateCASignedTLSConfig(t *testing.T) (*tls.Config, *x509.CertPool) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, caKey.Public(), caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, ca, key.Public(), caKey)
+	require.NoError(t, err)
+	cert := &tls.Certificate{Certificate: [][]byte{certDER}, PrivateKey: key}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return cert, nil },
+	}, pool
+}
+
+func TestExternalTLSConfig(t *testing.T) {
+	serverTLSConf, pool := generateCASignedTLSConfig(t)
+
+	dialAndExchange := func(t *testing.T, tr tpt.Transport, addr ma.Multiaddr, serverID peer.ID, ln tpt.Listener) {
+		t.Helper()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			conn, err := ln.Accept()
+			require.NoError(t, err)
+			str, err := conn.AcceptStream()
+			require.NoError(t, err)
+			data, err := io.ReadAll(str)
+			require.NoError(t, err)
+			require.Equal(t, "foobar", string(data))
+			conn.Close()
+		}()
+		conn, err := tr.Dial(context.Background(), addr, serverID)
+		require.NoError(t, err)
+		defer conn.Close()
+		str, err := conn.OpenStream(context.Background())
+		require.NoError(t, err)
+		_, err = str.Write([]byte("foobar"))
+		require.NoError(t, err)
+		require.NoError(t, str.Close())
+		<-done
+	}
+
+	_, clientKey := newIdentity(t)
+	cl, err := libp2pwebtransport.New(clientKey, nil, newConnManager(t), nil, nil, libp2pwebtransport.WithTLSClientConfig(&tls.Config{RootCAs: pool}))
+	require.NoError(t, err)
+	defer cl.(io.Closer).Close()
+
+	t.Run("with self-signed certificates", func(t *testing.T) {
+		serverID, serverKey := newIdentity(t)
+		tr, err := libp2pwebtransport.New(serverKey, nil, newConnManager(t), nil, nil, libp2pwebtransport.WithTLSConfig(serverTLSConf))
+		require.NoError(t, err)
+		defer tr.(io.Closer).Close()
+		ln, err := tr.Listen(ma.StringCast("/ip4/127.0.0.1/udp/0/quic-v1/webtransport"))
+		require.NoError(t, err)
+		defer ln.Close()
+		require.Len(t, extractCertHashes(ln.Multiaddr()), 2)
+
+		// dialing the IP address with certhashes uses the self-signed certificate
+		dialAndExchange(t, cl, ln.Multiaddr(), serverID, ln)
+		// dialing with SNI uses the CA-signed certificate
+		addr, err := ma.NewMultiaddr(strings.Replace(stripCertHashes(ln.Multiaddr()).String(), "/quic-v1/", "/quic-v1/sni/localhost/", 1))
+		require.NoError(t, err)
+		dialAndExchange(t, cl, addr, serverID, ln)
+
+		// domain name addresses are advertised without certhashes
+		dnsAddr := ma.StringCast("/dns/localhost/udp/1234/quic-v1/webtransport")
+		withHashes, ok := tr.(interface {
+			AddCertHashes(ma.Multiaddr) (ma.Multiaddr, bool)
+		}).AddCertHashes(dnsAddr)
+		require.True(t, ok)
+		require.Equal(t, dnsAddr, withHashes)
+	})
+
+	t.Run("without self-signed certificates", func(t *testing.T) {
+		serverID, serverKey := newIdentity(t)
+		tr, err := libp2pwebtransport.New(serverKey, nil, newConnManager(t), nil, nil,
+			libp2pwebtransport.WithTLSConfig(serverTLSConf),
+			libp2pwebtransport.DisableSelfSignedCertificates(),
+		)
+		require.NoError(t, err)
+		defer tr.(io.Closer).Close()
+		ln, err := tr.Listen(ma.StringCast("/ip4/127.0.0.1/udp/0/quic-v1/webtransport"))
+		require.NoError(t, err)
+		defer ln.Close()
+		require.Empty(t, extractCertHashes(ln.Multiaddr()))
+
+		addr, err := ma.NewMultiaddr(strings.Replace(ln.Multiaddr().String(), "/quic-v1/", "/quic-v1/sni/localhost/", 1))
+		require.NoError(t, err)
+		dialAndExchange(t, cl, addr, serverID, ln)
+	})
+
+	t.Run("disabling self-signed certificates requires a TLS config", func(t *testing.T) {
+		_, serverKey := newIdentity(t)
+		_, err := libp2pwebtransport.New(serverKey, nil, newConnManager(t), nil, nil, libp2pwebtransport.DisableSelfSignedCertificates())
+		require.Error(t, err)
+	})
+}